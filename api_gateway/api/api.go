@@ -12,12 +12,16 @@ import (
 	_ "gitlab.com/telegram_clone/api_gateway/api/docs" // for swagger
 
 	grpcPkg "gitlab.com/telegram_clone/api_gateway/pkg/grpc_client"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+	"gitlab.com/telegram_clone/api_gateway/pkg/upload"
 )
 
 type RouterOptions struct {
 	Cfg        *config.Config
 	GrpcClient grpcPkg.GrpcClientI
 	Logger     *logrus.Logger
+	Storage    storage.Storage
+	Uploads    *upload.Store
 }
 
 // @title           Swagger for blog api
@@ -35,6 +39,8 @@ func New(opt *RouterOptions) *gin.Engine {
 		Cfg:        opt.Cfg,
 		GrpcClient: opt.GrpcClient,
 		Logger:     opt.Logger,
+		Storage:    opt.Storage,
+		Uploads:    opt.Uploads,
 	})
 
 	apiV1 := router.Group("/v1")
@@ -53,12 +59,15 @@ func New(opt *RouterOptions) *gin.Engine {
 	apiV1.DELETE("/users/:id", handlerV1.AuthMiddleware("users", "delete"), handlerV1.DeleteUser)
 	apiV1.GET("/users/email/:email", handlerV1.GetUserByEmail)
 	apiV1.GET("/users/me", handlerV1.AuthMiddleware("users", "get-profile"), handlerV1.GetUserByToken)
+	apiV1.PUT("/users/me/last-seen-visibility", handlerV1.AuthMiddleware("users", "update-privacy"), handlerV1.SetLastSeenVisibility)
 
 	apiV1.POST("/chats", handlerV1.AuthMiddleware("chats", "create"), handlerV1.CreateChat)
 	apiV1.PUT("/chats/:id", handlerV1.AuthMiddleware("chats", "update"), handlerV1.UpdateChat)
 	apiV1.DELETE("/chats/:id", handlerV1.AuthMiddleware("chats", "delete"), handlerV1.DeleteChat)
 	apiV1.GET("/chats", handlerV1.AuthMiddleware("chats", "get"), handlerV1.GetAllChats)
 	apiV1.GET("/chats/:id", handlerV1.AuthMiddleware("chats", "get-all"), handlerV1.GetChat)
+	apiV1.PUT("/chats/:id/settings", handlerV1.AuthMiddleware("chats", "update-settings"), handlerV1.UpdateChatSettings)
+	apiV1.GET("/chats/:id/pinned", handlerV1.AuthMiddleware("chats", "pinned"), handlerV1.GetPinnedMessages)
 
 	// group chat endpoints
 	apiV1.POST("/chats/add-member", handlerV1.AuthMiddleware("chats", "add-member"), handlerV1.AddMember)
@@ -66,9 +75,35 @@ func New(opt *RouterOptions) *gin.Engine {
 	apiV1.DELETE("/chats/leave", handlerV1.AuthMiddleware("chats", "leave"), handlerV1.LeaveChat)
 	apiV1.GET("/chats/members", handlerV1.AuthMiddleware("chats", "get-members"), handlerV1.GetChatMembers)
 
-	apiV1.GET("/messages", handlerV1.GetAllMessages)
+	apiV1.GET("/messages", handlerV1.AuthMiddleware("messages", "get-all"), handlerV1.GetAllMessages)
+	apiV1.POST("/messages/read", handlerV1.AuthMiddleware("messages", "read"), handlerV1.MarkRead)
+	apiV1.POST("/messages/forward", handlerV1.AuthMiddleware("messages", "forward"), handlerV1.ForwardMessages)
+	apiV1.GET("/messages/scheduled", handlerV1.AuthMiddleware("messages", "schedule"), handlerV1.GetScheduledMessages)
+	apiV1.PUT("/messages/scheduled/:id", handlerV1.AuthMiddleware("messages", "schedule"), handlerV1.UpdateScheduledMessage)
+	apiV1.DELETE("/messages/scheduled/:id", handlerV1.AuthMiddleware("messages", "schedule"), handlerV1.CancelScheduledMessage)
+	apiV1.GET("/messages/:id/read-by", handlerV1.AuthMiddleware("messages", "read-by"), handlerV1.GetMessageReadBy)
+	apiV1.PUT("/messages/:id", handlerV1.AuthMiddleware("messages", "update"), handlerV1.UpdateMessage)
+	apiV1.DELETE("/messages/:id", handlerV1.AuthMiddleware("messages", "delete"), handlerV1.DeleteMessage)
+	apiV1.POST("/messages/delete", handlerV1.AuthMiddleware("messages", "delete"), handlerV1.DeleteMessages)
+	apiV1.GET("/messages/:id/revisions", handlerV1.AuthMiddleware("messages", "revisions"), handlerV1.GetMessageRevisions)
+	apiV1.POST("/messages/:id/pin", handlerV1.AuthMiddleware("messages", "pin"), handlerV1.PinMessage)
+	apiV1.DELETE("/messages/:id/pin", handlerV1.AuthMiddleware("messages", "pin"), handlerV1.UnpinMessage)
+	apiV1.GET("/messages/:id/thread", handlerV1.AuthMiddleware("messages", "thread"), handlerV1.GetThread)
+	apiV1.POST("/messages/:id/reactions", handlerV1.AuthMiddleware("messages", "react"), handlerV1.AddReaction)
+	apiV1.DELETE("/messages/:id/reactions/:emoji", handlerV1.AuthMiddleware("messages", "react"), handlerV1.RemoveReaction)
 
 	apiV1.POST("/users/file-upload", handlerV1.AuthMiddleware("users", "users/file-upload"), handlerV1.UsersFileUpload)
+	apiV1.POST("/media", handlerV1.AuthMiddleware("media", "upload"), handlerV1.UploadMedia)
+	apiV1.GET("/media/*key", handlerV1.DownloadMedia)
+	apiV1.POST("/images", handlerV1.AuthMiddleware("images", "upload"), handlerV1.UploadImage)
+
+	// resumable uploads
+	apiV1.OPTIONS("/uploads", handlerV1.UploadOptions)
+	apiV1.POST("/uploads", handlerV1.AuthMiddleware("uploads", "upload"), handlerV1.CreateUpload)
+	apiV1.HEAD("/uploads/:id", handlerV1.AuthMiddleware("uploads", "upload"), handlerV1.GetUploadOffset)
+	apiV1.PATCH("/uploads/:id", handlerV1.AuthMiddleware("uploads", "upload"), handlerV1.UploadChunk)
+	apiV1.DELETE("/uploads/:id", handlerV1.AuthMiddleware("uploads", "upload"), handlerV1.CancelUpload)
+	apiV1.POST("/uploads/:id/finish", handlerV1.AuthMiddleware("uploads", "upload"), handlerV1.FinishUpload)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return router
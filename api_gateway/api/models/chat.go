@@ -7,6 +7,38 @@ type Chat struct {
 	UserInfo GetUserInfo `json:"user_info"`
 	ChatType string      `json:"chat_type"`
 	ImageUrl string      `json:"image_url"`
+	// Sizes of an uploaded image
+	ImageThumbnails []*Thumbnail `json:"image_thumbnails,omitempty"`
+
+	// Set in the chat list
+	LastMessage        *LastMessage `json:"last_message,omitempty"`
+	UnreadCount        int64        `json:"unread_count"`
+	UnreadMentionCount int64        `json:"unread_mention_count"`
+	LastActivityAt     string       `json:"last_activity_at,omitempty"`
+
+	Settings ChatSettings `json:"settings"`
+}
+
+type ChatSettings struct {
+	// Emojis the members may react with, any emoji when empty
+	AllowedReactions []string `json:"allowed_reactions"`
+	// Messages of the chat can't be forwarded to other chats
+	DisallowForwarding bool `json:"disallow_forwarding"`
+	// Messages can be edited for this long after they were sent, forever
+	// when 0
+	EditWindowSeconds int64 `json:"edit_window_seconds"`
+	// Authors can delete their messages for everyone for this long after
+	// they were sent, forever when 0. The owner of the chat always can.
+	DeleteWindowSeconds int64 `json:"delete_window_seconds"`
+}
+
+type LastMessage struct {
+	ID        int64       `json:"id"`
+	Message   string      `json:"message"`
+	Type      string      `json:"type"`
+	UserID    int64       `json:"user_id"`
+	UserInfo  GetUserInfo `json:"user_info"`
+	CreatedAt string      `json:"created_at"`
 }
 
 type ChatReq struct {
@@ -32,9 +64,8 @@ type GetUserInfo struct {
 }
 
 type GetAllChatsParams struct {
-	Limit  int64 `json:"limit" binding:"required" default:"10"`
-	Page   int64 `json:"page" binding:"required" default:"1"`
-	UserID int64 `json:"user_id"`
+	Limit int64 `json:"limit" binding:"required" default:"10"`
+	Page  int64 `json:"page" binding:"required" default:"1"`
 }
 
 type GetAllChatsRes struct {
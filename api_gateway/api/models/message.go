@@ -1,28 +1,231 @@
 package models
 
 type Message struct {
-	ID int64 `json:"id"`
-	Message string `json:"message"`
-	UserID int64 `json:"user_id"`
-	UserInfo GetUserInfo `json:"user_info"`
+	ID              int64       `json:"id"`
+	Message         string      `json:"message"`
+	UserID          int64       `json:"user_id"`
+	UserInfo        GetUserInfo `json:"user_info"`
+	ChatID          int64       `json:"chat_id"`
+	CreatedAt       string      `json:"created_at"`
+	ClientMessageID string      `json:"client_message_id,omitempty"`
+
+	ReplyToMessageID int64          `json:"reply_to_message_id,omitempty"`
+	ReplyTo          *QuotedMessage `json:"reply_to,omitempty"`
+
+	ThreadRootID int64          `json:"thread_root_id,omitempty"`
+	Thread       *ThreadSummary `json:"thread,omitempty"`
+
+	Reactions []*Reaction `json:"reactions"`
+
+	// Set when the message was forwarded from another chat
+	ForwardedFrom *ForwardedFrom `json:"forwarded_from,omitempty"`
+	// Set once the message was edited
+	EditedAt string `json:"edited_at,omitempty"`
+	// Set once the message was deleted for everyone, Message is empty
+	DeletedAt string `json:"deleted_at,omitempty"`
+
+	// "text", "photo", "video", "file", "voice", "sticker", "location",
+	// "contact", or "system" for the messages written by the chat service,
+	// e.g. when a message is pinned
+	Type   string `json:"type"`
+	Pinned bool   `json:"pinned"`
+
+	// Media of the message, Message is an optional caption for them
+	Attachments []*Attachment `json:"attachments,omitempty"`
+	Location    *Location     `json:"location,omitempty"`
+	Contact     *Contact      `json:"contact,omitempty"`
+
+	// Set for a scheduled message, it is sent to the chat at this time
+	SendAt string `json:"send_at,omitempty"`
+}
+
+// Attachment is an uploaded file, it is sent by its uploader with a message
+// through the websocket service.
+type Attachment struct {
+	ID       int64  `json:"id"`
+	URL      string `json:"url"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	// Pixels for images and videos, 0 when unknown
+	Width  int32 `json:"width,omitempty"`
+	Height int32 `json:"height,omitempty"`
+	// Seconds for videos and voice notes, 0 when unknown
+	Duration  int32  `json:"duration,omitempty"`
+	CreatedAt string `json:"created_at"`
+	// Scaled down copies of photos
+	Thumbnails []*Thumbnail `json:"thumbnails,omitempty"`
+}
+
+// Thumbnail is an uploaded image scaled down to fit a square of Size pixels,
+// an image already smaller than that is kept as it is.
+type Thumbnail struct {
+	Size int    `json:"size"`
+	URL  string `json:"url"`
+}
+
+// Image is an uploaded image, set its key as the image_url of a chat.
+type Image struct {
+	Key        string       `json:"key"`
+	URL        string       `json:"url"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	Thumbnails []*Thumbnail `json:"thumbnails"`
+}
+
+// Upload is a file being uploaded in chunks, its chunks are sent to the
+// address of the upload from the offset it is at.
+type Upload struct {
+	ID        string `json:"id"`
+	FileName  string `json:"file_name,omitempty"`
+	Length    int64  `json:"length"`
+	Offset    int64  `json:"offset"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// FinishUploadRequest gives the rendering metadata of a file uploaded in
+// chunks the gateway can't read from it.
+type FinishUploadRequest struct {
+	Width    int32 `json:"width"`
+	Height   int32 `json:"height"`
+	Duration int32 `json:"duration"`
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
+	UserID      int64  `json:"user_id,omitempty"`
+}
+
+type PinResult struct {
+	Message Message `json:"message"`
+	// Appended to the chat, not set when the message was already pinned
+	SystemMessage *Message `json:"system_message,omitempty"`
+}
+
+type DeleteMessagesRequest struct {
+	MessageIDs []int64 `json:"message_ids" binding:"required"`
+	// Hides the messages for the authorized user instead of deleting them
+	// for everyone
+	OnlyMe bool `json:"only_me"`
+}
+
+type DeletedMessage struct {
+	ID     int64 `json:"id"`
 	ChatID int64 `json:"chat_id"`
+}
+
+// DeletedMessages are the messages deleted by the call, the ones deleted
+// before are left out.
+type DeletedMessages struct {
+	Messages []*DeletedMessage `json:"messages"`
+	OnlyMe   bool              `json:"only_me"`
+}
+
+type UpdateMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+type UpdateScheduledMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+	// RFC3339, sent on the next run of the dispatcher when in the past
+	SendAt string `json:"send_at" binding:"required"`
+}
+
+// MessageRevision is a previous text of an edited message, CreatedAt is
+// when it was written.
+type MessageRevision struct {
+	Message   string `json:"message"`
 	CreatedAt string `json:"created_at"`
 }
 
+type MessageRevisions struct {
+	Revisions []*MessageRevision `json:"revisions"`
+	Count     int64              `json:"count"`
+}
+
+// ForwardedFrom is the original of a forwarded message, a message forwarded
+// again keeps the first original.
+type ForwardedFrom struct {
+	MessageID int64        `json:"message_id"`
+	ChatID    int64        `json:"chat_id"`
+	UserID    int64        `json:"user_id"`
+	UserInfo  *GetUserInfo `json:"user_info,omitempty"`
+	CreatedAt string       `json:"created_at"`
+}
+
+type ForwardRequest struct {
+	MessageIDs []int64 `json:"message_ids" binding:"required"`
+	// Chat the messages are forwarded to
+	ChatID int64 `json:"chat_id" binding:"required"`
+}
+
+type Reaction struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+	// The authorized user reacted with the emoji
+	Reacted bool `json:"reacted"`
+}
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+type MessageReactions struct {
+	MessageID int64       `json:"message_id"`
+	ChatID    int64       `json:"chat_id"`
+	Reactions []*Reaction `json:"reactions"`
+}
+
+// ThreadSummary is the thread started by a message, or the thread of a
+// thread reply.
+type ThreadSummary struct {
+	RootID      int64  `json:"root_id"`
+	ReplyCount  int64  `json:"reply_count"`
+	LastReplyAt string `json:"last_reply_at,omitempty"`
+}
+
+// QuotedMessage is the preview of a replied message, only ID is set when it
+// was deleted.
+type QuotedMessage struct {
+	ID       int64        `json:"id"`
+	Message  string       `json:"message,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	UserID   int64        `json:"user_id,omitempty"`
+	UserInfo *GetUserInfo `json:"user_info,omitempty"`
+	Deleted  bool         `json:"deleted,omitempty"`
+}
+
 type MessageReq struct {
 	Message string `json:"message" binding:"required"`
-	UserID int64 `json:"user_id" binding:"required"`
-	ChatID int64 `json:"chat_id" binding:"required"`
+	UserID  int64  `json:"user_id" binding:"required"`
+	ChatID  int64  `json:"chat_id" binding:"required"`
 }
 
 type GetAllMessagesRes struct {
 	Messages []*Message `json:"messages" binding:"required"`
-	Count int64 `json:"count" binding:"required"`
+	Count    int64      `json:"count" binding:"required"`
 }
 
 type GetAllMessagesParams struct {
-	Limit int64 `json:"limit" binding:"required" default:"10"`
-	Page  int64 `json:"page" binding:"required" default:"1"`
-	ChatID int64  `json:"chat_id"`
+	Limit  int64 `json:"limit" binding:"required" default:"10"`
+	Page   int64 `json:"page" binding:"required" default:"1"`
+	ChatID int64 `json:"chat_id"`
 }
 
+type MarkReadRequest struct {
+	ChatID    int64 `json:"chat_id" binding:"required"`
+	MessageID int64 `json:"message_id" binding:"required"`
+}
+
+type ReadCursor struct {
+	ChatID            int64 `json:"chat_id"`
+	UserID            int64 `json:"user_id"`
+	LastReadMessageID int64 `json:"last_read_message_id"`
+}
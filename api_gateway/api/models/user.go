@@ -9,6 +9,14 @@ type User struct {
 	ProfileImageUrl string `json:"profile_image_url"`
 	Type            string `json:"type"`
 	CreatedAt       string `json:"created_at"`
+
+	// Sizes of the uploaded profile image
+	ProfileImageThumbnails []*Thumbnail `json:"profile_image_thumbnails,omitempty"`
+
+	// Presence is left out when the privacy settings of the user hide it
+	Online             bool   `json:"online"`
+	LastSeen           string `json:"last_seen,omitempty"`
+	LastSeenVisibility string `json:"last_seen_visibility,omitempty"`
 }
 
 type CreateUserRequest struct {
@@ -28,6 +36,10 @@ type UpdateUserRequest struct {
 	ProfileImageUrl string `json:"profile_image_url"`
 }
 
+type SetLastSeenVisibilityRequest struct {
+	Visibility string `json:"visibility" binding:"required,oneof=everybody chats nobody"`
+}
+
 type GetAllUsersResponse struct {
 	Users []*User `json:"users"`
 	Count int32   `json:"count"`
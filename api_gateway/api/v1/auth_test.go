@@ -109,14 +109,18 @@ func TestLoginMock(t *testing.T) {
 }
 
 func mockAuthMiddleware(t *testing.T, ctrl *gomock.Controller) string {
+	return mockAuthMiddlewareFor(t, ctrl, "users", "create")
+}
+
+func mockAuthMiddlewareFor(t *testing.T, ctrl *gomock.Controller, resource, action string) string {
 	accessToken := faker.UUIDHyphenated()
 
 	// mocking auth
 	authService := mock_grpc.NewMockAuthServiceClient(ctrl)
 	authService.EXPECT().VerifyToken(context.Background(), &pbc.VerifyTokenRequest{
 		AccessToken: accessToken,
-		Resource:    "users",
-		Action:      "create",
+		Resource:    resource,
+		Action:      action,
 	}).Times(1).Return(&pbc.AuthPayload{
 		Id:            faker.UUIDHyphenated(),
 		UserId:        1,
@@ -53,11 +53,11 @@ func (h *handlerV1) CreateChat(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, parseChat(chat))
+	c.JSON(http.StatusCreated, h.parseChat(chat))
 }
 
-func parseChat(chat *pbc.Chat) models.Chat {
-	return models.Chat{
+func (h *handlerV1) parseChat(chat *pbc.Chat) models.Chat {
+	result := models.Chat{
 		ID:     chat.Id,
 		Name:   chat.Name,
 		UserID: chat.UserId,
@@ -66,12 +66,47 @@ func parseChat(chat *pbc.Chat) models.Chat {
 			LastName:  chat.UserInfo.LastName,
 			Email:     chat.UserInfo.Email,
 			Username:  chat.UserInfo.Username,
-			ImageUrl:  chat.UserInfo.ImageUrl,
+			ImageUrl:  h.mediaURL(chat.UserInfo.ImageUrl),
 			CreatedAt: chat.UserInfo.CreatedAt,
 		},
 		ChatType: chat.ChatType,
-		ImageUrl: chat.ImageUrl,
+		ImageUrl: h.mediaURL(chat.ImageUrl),
+
+		ImageThumbnails: h.imageThumbnails(chat.ImageUrl),
+
+		UnreadCount:        chat.UnreadCount,
+		UnreadMentionCount: chat.UnreadMentionCount,
+		LastActivityAt:     chat.LastActivityAt,
+
+		Settings: models.ChatSettings{
+			AllowedReactions: make([]string, 0),
+		},
+	}
+	if chat.Settings != nil {
+		result.Settings.AllowedReactions = append(result.Settings.AllowedReactions, chat.Settings.AllowedReactions...)
+		result.Settings.DisallowForwarding = chat.Settings.DisallowForwarding
+		result.Settings.EditWindowSeconds = chat.Settings.EditWindowSeconds
+		result.Settings.DeleteWindowSeconds = chat.Settings.DeleteWindowSeconds
+	}
+	if chat.LastMessage != nil {
+		result.LastMessage = &models.LastMessage{
+			ID:      chat.LastMessage.Id,
+			Message: chat.LastMessage.Message,
+			Type:    chat.LastMessage.Type,
+			UserID:  chat.LastMessage.UserId,
+			UserInfo: models.GetUserInfo{
+				FirstName: chat.LastMessage.UserInfo.FirstName,
+				LastName:  chat.LastMessage.UserInfo.LastName,
+				Email:     chat.LastMessage.UserInfo.Email,
+				Username:  chat.LastMessage.UserInfo.Username,
+				ImageUrl:  h.mediaURL(chat.LastMessage.UserInfo.ImageUrl),
+				CreatedAt: chat.LastMessage.UserInfo.CreatedAt,
+			},
+			CreatedAt: chat.LastMessage.CreatedAt,
+		}
 	}
+
+	return result
 }
 
 // @Security ApiKeyAuth
@@ -108,7 +143,7 @@ func (h *handlerV1) GetChat(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, parseChat(chat))
+	c.JSON(http.StatusOK, h.parseChat(chat))
 }
 
 // @Security ApiKeyAuth
@@ -150,7 +185,7 @@ func (h *handlerV1) DeleteChat(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Router /chats [get]
 // @Summary Get all chats
-// @Description Get all chats
+// @Description Get the chats of the user with their last message and unread counts, most recently active first
 // @Tags chat
 // @Accept json
 // @Produce json
@@ -165,10 +200,16 @@ func (h *handlerV1) GetAllChats(c *gin.Context) {
 		return
 	}
 
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
 	result, err := h.grpcClient.ChatService().GetAll(context.Background(), &pbc.GetAllChatsParams{
 		Page:   req.Page,
 		Limit:  req.Limit,
-		UserId: req.UserID,
+		UserId: payload.UserID,
 	})
 	if err != nil {
 		h.logger.WithError(err).Error("failed to get all chats")
@@ -185,7 +226,7 @@ func (h *handlerV1) GetAllChats(c *gin.Context) {
 		Count: result.Count,
 	}
 	for _, v := range result.Chats {
-		res := parseChat(v)
+		res := h.parseChat(v)
 		response.Chats = append(response.Chats, &res)
 
 	}
@@ -225,7 +266,124 @@ func (h *handlerV1) UpdateChat(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, parseChat(chat))
+	c.JSON(http.StatusCreated, h.parseChat(chat))
+}
+
+// @Security ApiKeyAuth
+// @Router /chats/{id}/settings [put]
+// @Summary Update chat settings
+// @Description Replaces the settings of a chat, only its owner can change them
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param settings body models.ChatSettings true "Settings"
+// @Success 200 {object} models.Chat
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UpdateChatSettings(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req models.ChatSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	chat, err := h.grpcClient.ChatService().UpdateSettings(context.Background(), &pbc.UpdateChatSettingsRequest{
+		ChatId: id,
+		UserId: payload.UserID,
+		Settings: &pbc.ChatSettings{
+			AllowedReactions:    req.AllowedReactions,
+			DisallowForwarding:  req.DisallowForwarding,
+			EditWindowSeconds:   req.EditWindowSeconds,
+			DeleteWindowSeconds: req.DeleteWindowSeconds,
+		},
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to update chat settings")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.parseChat(chat))
+}
+
+// @Security ApiKeyAuth
+// @Router /chats/{id}/pinned [get]
+// @Summary Get pinned messages
+// @Description Get the pinned messages of a chat of the user, most recently pinned first
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param filter query models.GetAllParams false "Filter"
+// @Success 200 {object} models.GetAllMessagesRes
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) GetPinnedMessages(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	params, err := validateGetAllParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.ChatService().GetPinnedMessages(context.Background(), &pbc.GetPinnedMessagesParams{
+		ChatId: id,
+		UserId: payload.UserID,
+		Limit:  int64(params.Limit),
+		Page:   int64(params.Page),
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get pinned messages")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := models.GetAllMessagesRes{
+		Messages: make([]*models.Message, 0),
+		Count:    result.Count,
+	}
+	for _, v := range result.Messages {
+		res := h.parseMessage(v)
+		response.Messages = append(response.Messages, &res)
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // @Security ApiKeyAuth
@@ -370,7 +528,7 @@ func (h *handlerV1) GetChatMembers(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, getUsersResponse(result))
+	c.JSON(http.StatusOK, h.getUsersResponse(result))
 }
 
 func validateGetChatMembersParams(c *gin.Context) (*models.GetChatMembersParams, error) {
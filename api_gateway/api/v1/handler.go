@@ -9,6 +9,8 @@ import (
 	"gitlab.com/telegram_clone/api_gateway/api/models"
 	"gitlab.com/telegram_clone/api_gateway/config"
 	grpcPkg "gitlab.com/telegram_clone/api_gateway/pkg/grpc_client"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+	"gitlab.com/telegram_clone/api_gateway/pkg/upload"
 )
 
 var (
@@ -25,12 +27,16 @@ type handlerV1 struct {
 	cfg        *config.Config
 	grpcClient grpcPkg.GrpcClientI
 	logger     *logrus.Logger
+	storage    storage.Storage
+	uploads    *upload.Store
 }
 
 type HandlerV1Options struct {
 	Cfg        *config.Config
 	GrpcClient grpcPkg.GrpcClientI
 	Logger     *logrus.Logger
+	Storage    storage.Storage
+	Uploads    *upload.Store
 }
 
 func New(options *HandlerV1Options) *handlerV1 {
@@ -38,6 +44,8 @@ func New(options *HandlerV1Options) *handlerV1 {
 		cfg:        options.Cfg,
 		grpcClient: options.GrpcClient,
 		logger:     options.Logger,
+		storage:    options.Storage,
+		uploads:    options.Uploads,
 	}
 }
 
@@ -124,8 +132,17 @@ func validateGetAllMessagesParams(c *gin.Context) (*models.GetAllMessagesParams,
 		}
 	}
 
+	var chatID int64
+	if c.Query("chat_id") != "" {
+		chatID, err = strconv.ParseInt(c.Query("chat_id"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &models.GetAllMessagesParams{
-		Limit: limit,
-		Page:  page,
+		Limit:  limit,
+		Page:   page,
+		ChatID: chatID,
 	}, nil
 }
\ No newline at end of file
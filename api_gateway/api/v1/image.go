@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gitlab.com/telegram_clone/api_gateway/api/models"
+	"gitlab.com/telegram_clone/api_gateway/pkg/imaging"
+)
+
+// Largest image accepted, it is decoded in memory.
+const maxImageSize = 20 << 20
+
+// Sides of the squares the thumbnails of uploaded images fit, in pixels.
+var thumbnailSizes = []int{160, 320, 640}
+
+var ErrImageTooLarge = fmt.Errorf("images up to %d MB are accepted", maxImageSize>>20)
+
+// Keys of processed images, <id>/original<ext> with the thumbnails next to
+// it as <id>/<size><ext>.
+var imageKey = regexp.MustCompile(`^([0-9a-f-]{36})/(original|\d+)(\.jpg|\.png|\.gif)$`)
+
+// saveImage stores an uploaded image, turned upright and without its
+// metadata, with its thumbnails and returns the key of the original.
+func (h *handlerV1) saveImage(fh *multipart.FileHeader) (string, *imaging.Image, error) {
+	if fh.Size > maxImageSize {
+		return "", nil, ErrImageTooLarge
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return h.storeImage(data)
+}
+
+// storeImage processes an image read in full and stores it with its
+// thumbnails.
+func (h *handlerV1) storeImage(data []byte) (string, *imaging.Image, error) {
+	img, err := imaging.Process(data, thumbnailSizes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id := uuid.New().String()
+	key := id + "/original" + img.Ext
+
+	err = h.storage.Put(context.Background(), key, bytes.NewReader(img.Data), int64(len(img.Data)), img.MimeType)
+	for _, t := range img.Thumbnails {
+		if err != nil {
+			break
+		}
+		thumbnailKey := id + "/" + strconv.Itoa(t.Size) + img.Ext
+		err = h.storage.Put(context.Background(), thumbnailKey, bytes.NewReader(t.Data), int64(len(t.Data)), img.MimeType)
+	}
+	if err != nil {
+		h.deleteImage(key)
+		return "", nil, err
+	}
+
+	return key, img, nil
+}
+
+// deleteImage removes a stored image with its thumbnails.
+func (h *handlerV1) deleteImage(key string) {
+	h.storage.Delete(context.Background(), key)
+
+	m := imageKey.FindStringSubmatch(key)
+	if m == nil {
+		return
+	}
+	for _, size := range thumbnailSizes {
+		h.storage.Delete(context.Background(), m[1]+"/"+strconv.Itoa(size)+m[3])
+	}
+}
+
+// imageError answers a request whose image couldn't be saved.
+func (h *handlerV1) imageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, imaging.ErrNotImage):
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+	case errors.Is(err, imaging.ErrTooManyPixels), errors.Is(err, ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+	default:
+		h.logger.WithError(err).Error("failed to save image")
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// imageThumbnails returns the thumbnails of a stored image, none for the
+// files stored as they were uploaded and the addresses users set.
+func (h *handlerV1) imageThumbnails(stored string) []*models.Thumbnail {
+	m := imageKey.FindStringSubmatch(stored)
+	if m == nil || m[2] != "original" {
+		return nil
+	}
+
+	thumbnails := make([]*models.Thumbnail, 0, len(thumbnailSizes))
+	for _, size := range thumbnailSizes {
+		thumbnails = append(thumbnails, &models.Thumbnail{
+			Size: size,
+			URL:  h.mediaURL(m[1] + "/" + strconv.Itoa(size) + m[3]),
+		})
+	}
+
+	return thumbnails
+}
+
+// originalKey returns the key of the image a thumbnail was made of, it is
+// what the chat service knows of. Other keys are returned as they are.
+func originalKey(key string) string {
+	m := imageKey.FindStringSubmatch(key)
+	if m == nil {
+		return key
+	}
+	return m[1] + "/original" + m[3]
+}
+
+// @Security ApiKeyAuth
+// @Router /images [post]
+// @Summary Upload an image
+// @Description Stores a jpeg, png or gif image, turned upright and without its metadata, with thumbnails of 160, 320 and 640 pixels. Set the key as the image_url of a chat to use it as its image, the image is served from then on
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Image"
+// @Success 200 {object} models.Image
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UploadImage(c *gin.Context) {
+	var req File
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	key, img, err := h.saveImage(req.File)
+	if err != nil {
+		h.imageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Image{
+		Key:        key,
+		URL:        h.mediaURL(key),
+		Width:      img.Width,
+		Height:     img.Height,
+		Thumbnails: h.imageThumbnails(key),
+	})
+}
@@ -4,17 +4,22 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gitlab.com/telegram_clone/api_gateway/api"
 	"gitlab.com/telegram_clone/api_gateway/config"
 	grpcPkg "gitlab.com/telegram_clone/api_gateway/pkg/grpc_client"
 	"gitlab.com/telegram_clone/api_gateway/pkg/logger"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+	"gitlab.com/telegram_clone/api_gateway/pkg/upload"
 )
 
 var (
 	router   *gin.Engine
 	grpcConn grpcPkg.GrpcClientI
+	media    storage.Storage
+	uploads  *upload.Store
 )
 
 func TestMain(m *testing.M) {
@@ -28,12 +33,29 @@ func TestMain(m *testing.M) {
 		log.Fatalf("failed to get grpc connections: %v", err)
 	}
 
+	cfg.MediaStorage = "local"
+	cfg.MediaSigningKey = "secret"
+	cfg.MediaLocalDir, err = os.MkdirTemp("", "media")
+	if err != nil {
+		log.Fatalf("failed to create media dir: %v", err)
+	}
+	media, err = storage.New(cfg)
+	if err != nil {
+		log.Fatalf("failed to set up media storage: %v", err)
+	}
+
+	uploads = upload.New(media, 1<<20, time.Hour)
+
 	ginEngine := api.New(&api.RouterOptions{
 		Cfg:        &cfg,
 		GrpcClient: grpcConn,
 		Logger:     lgr,
+		Storage:    media,
+		Uploads:    uploads,
 	})
 
 	router = ginEngine
-	os.Exit(m.Run())
+	code := m.Run()
+	os.RemoveAll(cfg.MediaLocalDir)
+	os.Exit(code)
 }
@@ -0,0 +1,335 @@
+package v1
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // image.DecodeConfig formats
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
+	"gitlab.com/telegram_clone/api_gateway/pkg/imaging"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrInvalidMediaURL = errors.New("media url is invalid or has expired")
+
+// Largest file accepted by POST /media.
+const maxMediaSize = 50 << 20
+
+// MediaUpload is a file sent by the client. The rendering metadata the
+// gateway can't read from the file itself, e.g. the length of a voice note,
+// is given by the client.
+type MediaUpload struct {
+	File     *multipart.FileHeader `form:"file" binding:"required"`
+	Width    int32                 `form:"width"`
+	Height   int32                 `form:"height"`
+	Duration int32                 `form:"duration"`
+}
+
+// @Security ApiKeyAuth
+// @Router /media [post]
+// @Summary Upload a file to send it with a message
+// @Description Stores a file and returns it as an attachment. Send it with message.create through the websocket service, with a message type its mime type fits (image/* for photos, video/* for videos, audio/* for voice notes). The mime type is read from the file and the dimensions of images are read from them, width, height and duration can be given for the other files. Jpeg, png and gif images are stored turned upright and without their metadata, with thumbnails
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File"
+// @Param width formData int false "Width in pixels"
+// @Param height formData int false "Height in pixels"
+// @Param duration formData int false "Duration in seconds"
+// @Success 200 {object} models.Attachment
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UploadMedia(c *gin.Context) {
+	var req MediaUpload
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.File.Size > maxMediaSize {
+		c.JSON(http.StatusRequestEntityTooLarge, errorResponse(fmt.Errorf("files up to %d MB can be uploaded", maxMediaSize>>20)))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	mimeType, width, height, err := inspectMedia(req.File)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if width == 0 {
+		width, height = req.Width, req.Height
+	}
+
+	size := req.File.Size
+
+	// Photos are stored upright, without the location and camera details
+	// their metadata may carry, and with thumbnails
+	var key string
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+		var img *imaging.Image
+		key, img, err = h.saveImage(req.File)
+		if err != nil {
+			h.imageError(c, err)
+			return
+		}
+		mimeType, size = img.MimeType, int64(len(img.Data))
+		width, height = int32(img.Width), int32(img.Height)
+	default:
+		key, err = h.saveUpload(req.File, mimeType)
+		if err != nil {
+			h.logger.WithError(err).Error("failed to save media")
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	attachment, err := h.grpcClient.MessageService().CreateAttachment(context.Background(), &pbc.Attachment{
+		UserId:   payload.UserID,
+		Url:      key,
+		FileName: filepath.Base(req.File.Filename),
+		MimeType: mimeType,
+		Size:     size,
+		Width:    width,
+		Height:   height,
+		Duration: req.Duration,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to create attachment")
+		h.deleteImage(key)
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.parseAttachment(attachment))
+}
+
+// saveUpload stores the file under a new key and returns the key, which is
+// what the chat service keeps instead of an address.
+func (h *handlerV1) saveUpload(fh *multipart.FileHeader, mimeType string) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	key := uuid.New().String() + filepath.Ext(fh.Filename)
+	if err := h.storage.Put(context.Background(), key, f, fh.Size, mimeType); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// mediaURL returns the signed address clients download a stored file from.
+// Files uploaded before the storage drivers are kept as /media/<key>,
+// addresses users set themselves are returned as they are.
+func (h *handlerV1) mediaURL(stored string) string {
+	if stored == "" {
+		return ""
+	}
+	key := strings.TrimPrefix(stored, "/media/")
+	if key == stored && (strings.HasPrefix(stored, "/") || strings.Contains(stored, "://")) {
+		return stored
+	}
+
+	// Rounded up so the address stays the same for a while and clients can
+	// cache the file, it is valid for at least the ttl
+	ttl := h.cfg.MediaURLTTL
+	expires := time.Now().Truncate(ttl).Add(2 * ttl).Unix()
+
+	return fmt.Sprintf("%s/%s?expires=%d&signature=%s",
+		strings.TrimSuffix(h.cfg.MediaBaseURL, "/"),
+		(&url.URL{Path: key}).EscapedPath(),
+		expires,
+		h.signMedia(key, expires),
+	)
+}
+
+func (h *handlerV1) signMedia(key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(h.cfg.MediaSigningKey))
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyMediaURL checks the signature of a media address and returns when
+// it expires.
+func (h *handlerV1) verifyMediaURL(key, expires, signature string) (time.Time, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidMediaURL
+	}
+	if !hmac.Equal([]byte(h.signMedia(key, unix)), []byte(signature)) {
+		return time.Time{}, ErrInvalidMediaURL
+	}
+
+	expiresAt := time.Unix(unix, 0)
+	if time.Now().After(expiresAt) {
+		return time.Time{}, ErrInvalidMediaURL
+	}
+
+	return expiresAt, nil
+}
+
+// @Router /media/{key} [get]
+// @Summary Download an uploaded file
+// @Description Files are downloaded with the signed addresses given for them, which expire. Attachments can also be downloaded by the members of the chats they were sent to with their access token instead. Range requests are supported. Files no longer used, e.g. attachments of deleted messages, aren't found
+// @Tags media
+// @Produce octet-stream
+// @Param key path string true "Key"
+// @Param expires query int false "Expiry of the signed address"
+// @Param signature query string false "Signature of the signed address"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+func (h *handlerV1) DownloadMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	// Without a signed address only the attachments of the chats of the
+	// user are found, the answer is revalidated as the user may leave them
+	var userID int64
+	cacheControl := "private, no-cache"
+	if c.Query("signature") != "" {
+		expiresAt, err := h.verifyMediaURL(key, c.Query("expires"), c.Query("signature"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		cacheControl = fmt.Sprintf("private, max-age=%d", int64(time.Until(expiresAt).Seconds()))
+	} else {
+		userID = h.requesterID(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, errorResponse(ErrInvalidMediaURL))
+			return
+		}
+	}
+
+	// Thumbnails are served as long as their image is
+	media, err := h.grpcClient.MessageService().GetMedia(context.Background(), &pbc.GetMediaRequest{
+		Url:    originalKey(key),
+		UserId: userID,
+	})
+	if err != nil {
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		h.logger.WithError(err).Error("failed to get media")
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	object, info, err := h.storage.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		h.logger.WithError(err).Error("failed to open media")
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer object.Close()
+
+	contentType := media.MimeType
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+
+	header := c.Writer.Header()
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if info.ETag != "" {
+		header.Set("ETag", info.ETag)
+	}
+	header.Set("Cache-Control", cacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
+	// Browsers only show media, any other file could run scripts as the
+	// gateway's site
+	if !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "video/") &&
+		!strings.HasPrefix(contentType, "audio/") || contentType == "image/svg+xml" {
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": media.FileName})
+		if media.FileName == "" || disposition == "" {
+			disposition = "attachment"
+		}
+		header.Set("Content-Disposition", disposition)
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime, object)
+}
+
+// inspectMedia returns the mime type of the file, read from its content
+// and from its extension when the content says nothing, and the dimensions
+// of images.
+func inspectMedia(fh *multipart.FileHeader) (mimeType string, width, height int32, err error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", 0, 0, err
+	}
+
+	mimeType = detectMimeType(head[:n], fh.Filename)
+
+	if strings.HasPrefix(mimeType, "image/") {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", 0, 0, err
+		}
+		// Formats without a decoder, e.g. webp, keep the dimensions given
+		// by the client.
+		if config, _, err := image.DecodeConfig(f); err == nil {
+			width, height = int32(config.Width), int32(config.Height)
+		}
+	}
+
+	return mimeType, width, height, nil
+}
+
+// detectMimeType reads the mime type from the first 512 bytes of a file,
+// the extension of its name tells it when the content doesn't.
+func detectMimeType(head []byte, fileName string) string {
+	mimeType := http.DetectContentType(head)
+	if mimeType == "application/octet-stream" || strings.HasPrefix(mimeType, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(fileName)); byExt != "" {
+			mimeType = byExt
+		}
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+
+	return mimeType
+}
@@ -0,0 +1,301 @@
+package v1_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/api_gateway/api/models"
+	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
+	"gitlab.com/telegram_clone/api_gateway/pkg/grpc_client/mock_grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUploadMedia(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	// The extension doesn't matter, the type is read from the content
+	part, err := form.CreateFormFile("file", "photo.bin")
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(part, image.NewRGBA(image.Rect(0, 0, 64, 48))))
+	require.NoError(t, form.Close())
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().CreateAttachment(context.Background(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, req *pbc.Attachment, _ ...interface{}) (*pbc.Attachment, error) {
+			assert.Equal(t, int64(1), req.UserId)
+			assert.Equal(t, "photo.bin", req.FileName)
+			assert.Equal(t, "image/png", req.MimeType)
+			assert.Equal(t, int32(64), req.Width)
+			assert.Equal(t, int32(48), req.Height)
+			// The key of the stored file is kept, not its address
+			f, _, err := media.Open(context.Background(), req.Url)
+			require.NoError(t, err)
+			f.Close()
+			f, _, err = media.Open(context.Background(), strings.Replace(req.Url, "original", "160", 1))
+			require.NoError(t, err)
+			f.Close()
+
+			return &pbc.Attachment{
+				Id:       12,
+				UserId:   req.UserId,
+				Url:      req.Url,
+				MimeType: req.MimeType,
+				Size:     req.Size,
+				Width:    req.Width,
+				Height:   req.Height,
+			}, nil
+		},
+	)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "media", "upload")
+
+	req, _ := http.NewRequest("POST", "/v1/media", &body)
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Content-Type", form.FormDataContentType())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.Attachment
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), response.ID)
+	assert.Equal(t, "image/png", response.MimeType)
+	assert.Regexp(t, `^/v1/media/[0-9a-f-]+/original\.png\?expires=\d+&signature=[0-9a-f]{64}$`, response.URL)
+	require.Len(t, response.Thumbnails, 3)
+	assert.Equal(t, 160, response.Thumbnails[0].Size)
+	assert.Contains(t, response.Thumbnails[0].URL, "/160.png?")
+}
+
+func TestUploadMediaFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "notes.txt")
+	require.NoError(t, err)
+	part.Write([]byte("shopping list"))
+	require.NoError(t, form.Close())
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().CreateAttachment(context.Background(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, req *pbc.Attachment, _ ...interface{}) (*pbc.Attachment, error) {
+			assert.Equal(t, "text/plain", req.MimeType)
+			assert.Equal(t, int64(13), req.Size)
+			assert.Regexp(t, `^[0-9a-f-]+\.txt$`, req.Url)
+			t.Cleanup(func() { media.Delete(context.Background(), req.Url) })
+
+			return &pbc.Attachment{Id: 13, Url: req.Url, MimeType: req.MimeType}, nil
+		},
+	)
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "media", "upload")
+
+	req, _ := http.NewRequest("POST", "/v1/media", &body)
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Content-Type", form.FormDataContentType())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.Attachment
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Thumbnails)
+}
+
+// signMediaURL signs an address of the object the way the gateway does with
+// the key set up in TestMain.
+func signMediaURL(key string, expires time.Time) string {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	fmt.Fprintf(mac, "%s\n%d", key, expires.Unix())
+	return fmt.Sprintf("/v1/media/%s?expires=%d&signature=%x", key, expires.Unix(), mac.Sum(nil))
+}
+
+func TestDownloadMedia(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := faker.UUIDHyphenated() + ".png"
+	content := "0123456789"
+	require.NoError(t, media.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), "image/png"))
+	t.Cleanup(func() { media.Delete(context.Background(), key) })
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetMedia(context.Background(), &pbc.GetMediaRequest{Url: key}).AnyTimes().Return(&pbc.Attachment{
+		Url:      key,
+		MimeType: "image/png",
+	}, nil)
+	grpcConn.SetMessageService(messageService)
+
+	url := signMediaURL(key, time.Now().Add(time.Hour))
+
+	req, _ := http.NewRequest("GET", url, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, content, rec.Body.String())
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Regexp(t, `^private, max-age=\d+$`, rec.Header().Get("Cache-Control"))
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, _ = http.NewRequest("GET", url, nil)
+	req.Header.Set("Range", "bytes=2-5")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "2345", rec.Body.String())
+	assert.Equal(t, "bytes 2-5/10", rec.Header().Get("Content-Range"))
+
+	req, _ = http.NewRequest("GET", url, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	for name, url := range map[string]string{
+		"expired":  signMediaURL(key, time.Now().Add(-time.Minute)),
+		"tampered": strings.Replace(url, "signature=", "signature=0", 1),
+		"other":    strings.Replace(signMediaURL("other.png", time.Now().Add(time.Hour)), "other.png", key, 1),
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code, name)
+	}
+
+	// Without a signature an access token is needed
+	req, _ = http.NewRequest("GET", "/v1/media/"+key, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestDownloadMediaNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := faker.UUIDHyphenated() + ".pdf"
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetMedia(context.Background(), &pbc.GetMediaRequest{Url: key}).Times(1).
+		Return(nil, status.Error(codes.NotFound, "media not found"))
+	// Still referred to, but gone from the storage
+	messageService.EXPECT().GetMedia(context.Background(), &pbc.GetMediaRequest{Url: key, UserId: 1}).Times(1).
+		Return(&pbc.Attachment{Url: key, MimeType: "application/pdf"}, nil)
+	grpcConn.SetMessageService(messageService)
+
+	req, _ := http.NewRequest("GET", signMediaURL(key, time.Now().Add(time.Hour)), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Chat members download attachments with their access token
+	accessToken := faker.UUIDHyphenated()
+	authService := mock_grpc.NewMockAuthServiceClient(ctrl)
+	authService.EXPECT().VerifyToken(context.Background(), &pbc.VerifyTokenRequest{
+		AccessToken: accessToken,
+	}).Times(1).Return(&pbc.AuthPayload{UserId: 1}, nil)
+	grpcConn.SetAuthService(authService)
+
+	req, _ = http.NewRequest("GET", "/v1/media/"+key, nil)
+	req.Header.Add("Authorization", accessToken)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDownloadMediaAsMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := faker.UUIDHyphenated() + ".html"
+	content := "<script>alert(1)</script>"
+	require.NoError(t, media.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), "text/html"))
+	t.Cleanup(func() { media.Delete(context.Background(), key) })
+
+	accessToken := faker.UUIDHyphenated()
+	authService := mock_grpc.NewMockAuthServiceClient(ctrl)
+	authService.EXPECT().VerifyToken(context.Background(), &pbc.VerifyTokenRequest{
+		AccessToken: accessToken,
+	}).Times(1).Return(&pbc.AuthPayload{UserId: 1}, nil)
+	grpcConn.SetAuthService(authService)
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetMedia(context.Background(), &pbc.GetMediaRequest{Url: key, UserId: 1}).Times(1).Return(&pbc.Attachment{
+		Url:      key,
+		FileName: "page.html",
+		MimeType: "text/html",
+	}, nil)
+	grpcConn.SetMessageService(messageService)
+
+	req, _ := http.NewRequest("GET", "/v1/media/"+key, nil)
+	req.Header.Add("Authorization", accessToken)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, content, rec.Body.String())
+	assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+	// Files other than media are downloaded rather than shown
+	assert.Equal(t, `attachment; filename=page.html`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+}
+
+func TestDownloadThumbnail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := faker.UUIDHyphenated()
+	require.NoError(t, media.Put(context.Background(), id+"/160.png", strings.NewReader("small"), 5, "image/png"))
+
+	// Served as long as the image it was made of is
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetMedia(context.Background(), &pbc.GetMediaRequest{Url: id + "/original.png"}).Times(1).
+		Return(&pbc.Attachment{Url: id + "/original.png"}, nil)
+	grpcConn.SetMessageService(messageService)
+
+	req, _ := http.NewRequest("GET", signMediaURL(id+"/160.png", time.Now().Add(time.Hour)), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "small", rec.Body.String())
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+}
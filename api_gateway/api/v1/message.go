@@ -3,6 +3,7 @@ package v1
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gitlab.com/telegram_clone/api_gateway/api/models"
@@ -12,35 +13,129 @@ import (
 	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
 )
 
-func parseMessage(message *pbc.ChatMessage) models.Message {
-	return models.Message{
-		ID:      message.Id,
-		Message: message.Message,
-		UserID: message.UserId,
-		UserInfo: models.GetUserInfo{
-			FirstName: message.UserInfo.FirstName,
-			LastName:  message.UserInfo.LastName,
-			Email:     message.UserInfo.Email,
-			Username:  message.UserInfo.Username,
-			ImageUrl:  message.UserInfo.ImageUrl,
-			CreatedAt: message.UserInfo.CreatedAt,
-		},
-		ChatID: message.ChatId,
-		CreatedAt: message.CreatedAt,
+func (h *handlerV1) parseMessage(message *pbc.ChatMessage) models.Message {
+	result := models.Message{
+		ID:               message.Id,
+		Message:          message.Message,
+		UserID:           message.UserId,
+		UserInfo:         h.parseMessageUserInfo(message.UserInfo),
+		ChatID:           message.ChatId,
+		CreatedAt:        message.CreatedAt,
+		ClientMessageID:  message.ClientMessageId,
+		ReplyToMessageID: message.ReplyToMessageId,
+		ThreadRootID:     message.ThreadRootId,
+		EditedAt:         message.EditedAt,
+		DeletedAt:        message.DeletedAt,
+		Type:             message.Type,
+		Pinned:           message.Pinned,
+		SendAt:           message.SendAt,
+		Reactions:        parseReactions(message.Reactions),
 	}
+	if message.ReplyTo != nil {
+		result.ReplyTo = &models.QuotedMessage{
+			ID:      message.ReplyTo.Id,
+			Message: message.ReplyTo.Message,
+			Type:    message.ReplyTo.Type,
+			UserID:  message.ReplyTo.UserId,
+			Deleted: message.ReplyTo.Deleted,
+		}
+		if message.ReplyTo.UserInfo != nil {
+			userInfo := h.parseMessageUserInfo(message.ReplyTo.UserInfo)
+			result.ReplyTo.UserInfo = &userInfo
+		}
+	}
+
+	if message.ForwardedFrom != nil {
+		result.ForwardedFrom = &models.ForwardedFrom{
+			MessageID: message.ForwardedFrom.MessageId,
+			ChatID:    message.ForwardedFrom.ChatId,
+			UserID:    message.ForwardedFrom.UserId,
+			CreatedAt: message.ForwardedFrom.CreatedAt,
+		}
+		if message.ForwardedFrom.UserInfo != nil {
+			userInfo := h.parseMessageUserInfo(message.ForwardedFrom.UserInfo)
+			result.ForwardedFrom.UserInfo = &userInfo
+		}
+	}
+	if message.Thread != nil {
+		result.Thread = &models.ThreadSummary{
+			RootID:      message.Thread.RootId,
+			ReplyCount:  message.Thread.ReplyCount,
+			LastReplyAt: message.Thread.LastReplyAt,
+		}
+	}
+
+	for _, a := range message.Attachments {
+		result.Attachments = append(result.Attachments, h.parseAttachment(a))
+	}
+	if message.Location != nil {
+		result.Location = &models.Location{
+			Latitude:  message.Location.Latitude,
+			Longitude: message.Location.Longitude,
+		}
+	}
+	if message.Contact != nil {
+		result.Contact = &models.Contact{
+			PhoneNumber: message.Contact.PhoneNumber,
+			FirstName:   message.Contact.FirstName,
+			LastName:    message.Contact.LastName,
+			UserID:      message.Contact.UserId,
+		}
+	}
+
+	return result
 }
 
+func (h *handlerV1) parseAttachment(a *pbc.Attachment) *models.Attachment {
+	return &models.Attachment{
+		ID:        a.Id,
+		URL:       h.mediaURL(a.Url),
+		FileName:  a.FileName,
+		MimeType:  a.MimeType,
+		Size:      a.Size,
+		Width:     a.Width,
+		Height:    a.Height,
+		Duration:  a.Duration,
+		CreatedAt: a.CreatedAt,
+
+		Thumbnails: h.imageThumbnails(a.Url),
+	}
+}
+
+func parseReactions(reactions []*pbc.Reaction) []*models.Reaction {
+	result := make([]*models.Reaction, 0, len(reactions))
+	for _, r := range reactions {
+		result = append(result, &models.Reaction{
+			Emoji:   r.Emoji,
+			Count:   r.Count,
+			Reacted: r.Reacted,
+		})
+	}
+	return result
+}
 
+func (h *handlerV1) parseMessageUserInfo(info *pbc.GetUserInfo) models.GetUserInfo {
+	return models.GetUserInfo{
+		FirstName: info.GetFirstName(),
+		LastName:  info.GetLastName(),
+		Email:     info.GetEmail(),
+		Username:  info.GetUsername(),
+		ImageUrl:  h.mediaURL(info.GetImageUrl()),
+		CreatedAt: info.GetCreatedAt(),
+	}
+}
 
+// @Security ApiKeyAuth
 // @Router /messages [get]
 // @Summary Get all messages
-// @Description Get all messages
+// @Description Get the messages of a chat of the authorized user, or of all of the chats of the user without chat_id
 // @Tags message
 // @Accept json
 // @Produce json
 // @Param filter query models.GetAllMessagesParams false "Filter"
 // @Success 200 {object} models.GetAllMessagesRes
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 400 {object} models.ErrorResponse
 func (h *handlerV1) GetAllMessages(c *gin.Context) {
 	req, err := validateGetAllMessagesParams(c)
@@ -49,10 +144,19 @@ func (h *handlerV1) GetAllMessages(c *gin.Context) {
 		return
 	}
 
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// Only the chats the user is a member of are found, the attachments come
+	// with signed addresses
 	result, err := h.grpcClient.MessageService().GetAll(context.Background(), &pbc.GetAllMessagesParams{
-		Page:  req.Page,
-		Limit: req.Limit,
+		Page:   req.Page,
+		Limit:  req.Limit,
 		ChatId: req.ChatID,
+		UserId: payload.UserID,
 	})
 	if err != nil {
 		h.logger.WithError(err).Error("failed to get all messages")
@@ -66,13 +170,777 @@ func (h *handlerV1) GetAllMessages(c *gin.Context) {
 
 	response := models.GetAllMessagesRes{
 		Messages: make([]*models.Message, 0),
-		Count: result.Count,
+		Count:    result.Count,
+	}
+	for _, v := range result.Messages {
+		res := h.parseMessage(v)
+		response.Messages = append(response.Messages, &res)
+
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/thread [get]
+// @Summary Get the replies of a thread
+// @Description Get the replies of the thread started by a message, newest first
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param filter query models.GetAllParams false "Filter"
+// @Success 200 {object} models.GetAllMessagesRes
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) GetThread(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	params, err := validateGetAllParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.MessageService().GetThread(context.Background(), &pbc.GetThreadParams{
+		RootId: id,
+		UserId: payload.UserID,
+		Limit:  int64(params.Limit),
+		Page:   int64(params.Page),
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get thread")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := models.GetAllMessagesRes{
+		Messages: make([]*models.Message, 0),
+		Count:    result.Count,
+	}
+	for _, v := range result.Messages {
+		res := h.parseMessage(v)
+		response.Messages = append(response.Messages, &res)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/pin [post]
+// @Summary Pin a message
+// @Description Pins a message to its chat and appends a system message about it. The owner of a group chat and both members of a private chat can pin
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Success 200 {object} models.PinResult
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) PinMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.MessageService().Pin(context.Background(), &pbc.PinRequest{
+		MessageId: id,
+		UserId:    payload.UserID,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to pin message")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	response := models.PinResult{
+		Message: h.parseMessage(result.Message),
+	}
+	if result.SystemMessage != nil {
+		systemMessage := h.parseMessage(result.SystemMessage)
+		response.SystemMessage = &systemMessage
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/pin [delete]
+// @Summary Unpin a message
+// @Description Unpins a message, the same users as for pinning can unpin
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Success 200 {object} models.Message
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UnpinMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	message, err := h.grpcClient.MessageService().Unpin(context.Background(), &pbc.PinRequest{
+		MessageId: id,
+		UserId:    payload.UserID,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to unpin message")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.parseMessage(message))
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/reactions [post]
+// @Summary React to a message
+// @Description React to a message with an emoji allowed in its chat, members only
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param reaction body models.ReactionRequest true "Reaction"
+// @Success 200 {object} models.MessageReactions
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) AddReaction(c *gin.Context) {
+	var req models.ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	h.react(c, req.Emoji, true)
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/reactions/{emoji} [delete]
+// @Summary Remove a reaction
+// @Description Take back the reaction of the user to a message
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param emoji path string true "Emoji"
+// @Success 200 {object} models.MessageReactions
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) RemoveReaction(c *gin.Context) {
+	h.react(c, c.Param("emoji"), false)
+}
+
+func (h *handlerV1) react(c *gin.Context, emoji string, add bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	req := &pbc.ReactionRequest{
+		MessageId: id,
+		UserId:    payload.UserID,
+		Emoji:     emoji,
+	}
+	var result *pbc.MessageReactions
+	if add {
+		result, err = h.grpcClient.MessageService().AddReaction(context.Background(), req)
+	} else {
+		result, err = h.grpcClient.MessageService().RemoveReaction(context.Background(), req)
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("failed to react to message")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageReactions{
+		MessageID: result.MessageId,
+		ChatID:    result.ChatId,
+		Reactions: parseReactions(result.Reactions),
+	})
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/forward [post]
+// @Summary Forward messages
+// @Description Forwards messages to another chat of the user, the new messages keep their original sender, chat and time
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param forward body models.ForwardRequest true "Forward"
+// @Success 201 {object} models.GetAllMessagesRes
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) ForwardMessages(c *gin.Context) {
+	var req models.ForwardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.MessageService().Forward(context.Background(), &pbc.ForwardRequest{
+		MessageIds: req.MessageIDs,
+		ChatId:     req.ChatID,
+		UserId:     payload.UserID,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to forward messages")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	response := models.GetAllMessagesRes{
+		Messages: make([]*models.Message, 0),
+		Count:    result.Count,
+	}
+	for _, v := range result.Messages {
+		res := h.parseMessage(v)
+		response.Messages = append(response.Messages, &res)
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id} [delete]
+// @Summary Delete a message
+// @Description Deletes a message for everyone, or hides it for the user with only_me. Authors can delete their messages for everyone within the delete window of the chat, its owner anytime
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param only_me query bool false "Hide the message for the user only"
+// @Success 200 {object} models.ResponseOK
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) DeleteMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	onlyMe, err := strconv.ParseBool(c.DefaultQuery("only_me", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := h.deleteMessages(c, []int64{id}, onlyMe); !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ResponseOK{
+		Message: "success",
+	})
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/delete [post]
+// @Summary Delete messages
+// @Description Deletes up to 100 messages at once, for everyone or only for the user. None is deleted when one of them can't be
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param delete body models.DeleteMessagesRequest true "Delete"
+// @Success 200 {object} models.DeletedMessages
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) DeleteMessages(c *gin.Context) {
+	var req models.DeleteMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, ok := h.deleteMessages(c, req.MessageIDs, req.OnlyMe)
+	if !ok {
+		return
+	}
+
+	response := models.DeletedMessages{
+		Messages: make([]*models.DeletedMessage, 0, len(result.Messages)),
+		OnlyMe:   result.OnlyMe,
+	}
+	for _, m := range result.Messages {
+		response.Messages = append(response.Messages, &models.DeletedMessage{
+			ID:     m.Id,
+			ChatID: m.ChatId,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// deleteMessages deletes the messages for the authorized user, the error
+// response is written when it fails.
+func (h *handlerV1) deleteMessages(c *gin.Context, ids []int64, onlyMe bool) (*pbc.DeletedMessages, bool) {
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	result, err := h.grpcClient.MessageService().DeleteMessages(context.Background(), &pbc.DeleteMessagesRequest{
+		MessageIds: ids,
+		UserId:     payload.UserID,
+		OnlyMe:     onlyMe,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to delete messages")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return nil, false
+	}
+
+	return result, true
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id} [put]
+// @Summary Edit a message
+// @Description Replaces the text of a message of the user, the previous text is kept as a revision
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param message body models.UpdateMessageRequest true "Message"
+// @Success 200 {object} models.Message
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UpdateMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req models.UpdateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	message, err := h.grpcClient.MessageService().Update(context.Background(), &pbc.ChatMessage{
+		Id:      id,
+		Message: req.Message,
+		UserId:  payload.UserID,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to update message")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.parseMessage(message))
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/scheduled [get]
+// @Summary Get the scheduled messages
+// @Description Get the scheduled messages of the authorized user, the next due first. Only their author can see them until they are sent
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param filter query models.GetAllMessagesParams false "Filter"
+// @Success 200 {object} models.GetAllMessagesRes
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) GetScheduledMessages(c *gin.Context) {
+	req, err := validateGetAllMessagesParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.MessageService().GetScheduled(context.Background(), &pbc.GetScheduledMessagesParams{
+		UserId: payload.UserID,
+		ChatId: req.ChatID,
+		Limit:  req.Limit,
+		Page:   req.Page,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get scheduled messages")
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := models.GetAllMessagesRes{
+		Messages: make([]*models.Message, 0, len(result.Messages)),
+		Count:    result.Count,
 	}
 	for _, v := range result.Messages {
-		res := parseMessage(v)
+		res := h.parseMessage(v)
 		response.Messages = append(response.Messages, &res)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/scheduled/{id} [put]
+// @Summary Edit a scheduled message
+// @Description Changes the text and the send time of a scheduled message of the user
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param message body models.UpdateScheduledMessageRequest true "Message"
+// @Success 200 {object} models.Message
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UpdateScheduledMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req models.UpdateScheduledMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	message, err := h.grpcClient.MessageService().UpdateScheduled(context.Background(), &pbc.ChatMessage{
+		Id:      id,
+		Message: req.Message,
+		UserId:  payload.UserID,
+		SendAt:  req.SendAt,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to update scheduled message")
+		switch s, _ := status.FromError(err); s.Code() {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.parseMessage(message))
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/scheduled/{id} [delete]
+// @Summary Cancel a scheduled message
+// @Description Cancels a scheduled message of the user before it is sent
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Success 200 {object} models.ResponseOK
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) CancelScheduledMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
 
+	_, err = h.grpcClient.MessageService().CancelScheduled(context.Background(), &pbc.ChatIdRequest{
+		Id:     id,
+		UserId: payload.UserID,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to cancel scheduled message")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ResponseOK{
+		Message: "Successfully cancelled",
+	})
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/revisions [get]
+// @Summary Get the revisions of a message
+// @Description Get the previous texts of an edited message, newest first. Only its author and the owner of its chat can see them
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param filter query models.GetAllParams false "Filter"
+// @Success 200 {object} models.MessageRevisions
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) GetMessageRevisions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	params, err := validateGetAllParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.MessageService().GetRevisions(context.Background(), &pbc.GetRevisionsParams{
+		MessageId: id,
+		UserId:    payload.UserID,
+		Limit:     int64(params.Limit),
+		Page:      int64(params.Page),
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get message revisions")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := models.MessageRevisions{
+		Revisions: make([]*models.MessageRevision, 0, len(result.Revisions)),
+		Count:     result.Count,
+	}
+	for _, r := range result.Revisions {
+		response.Revisions = append(response.Revisions, &models.MessageRevision{
+			Message:   r.Message,
+			CreatedAt: r.CreatedAt,
+		})
 	}
 	c.JSON(http.StatusOK, response)
 }
 
+// @Security ApiKeyAuth
+// @Router /messages/read [post]
+// @Summary Mark messages read
+// @Description Moves the read cursor of the user in the chat up to the message, it never moves back
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param read body models.MarkReadRequest true "Read"
+// @Success 200 {object} models.ReadCursor
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) MarkRead(c *gin.Context) {
+	var req models.MarkReadRequest
+
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	cursor, err := h.grpcClient.MessageService().MarkRead(context.Background(), &pbc.MarkReadRequest{
+		ChatId:    req.ChatID,
+		UserId:    payload.UserID,
+		MessageId: req.MessageID,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to mark read")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ReadCursor{
+		ChatID:            cursor.ChatId,
+		UserID:            cursor.UserId,
+		LastReadMessageID: cursor.LastReadMessageId,
+	})
+}
+
+// @Security ApiKeyAuth
+// @Router /messages/{id}/read-by [get]
+// @Summary Get the members who read a message
+// @Description Get the members who read a message, its author excluded
+// @Tags message
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param filter query models.GetAllParams false "Filter"
+// @Success 200 {object} models.GetAllUsersResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) GetMessageReadBy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	params, err := validateGetAllParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := h.grpcClient.MessageService().GetReadBy(context.Background(), &pbc.GetReadByParams{
+		MessageId: id,
+		UserId:    payload.UserID,
+		Limit:     int64(params.Limit),
+		Page:      int64(params.Page),
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get read by")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.getUsersResponse(result))
+}
@@ -1,55 +1,471 @@
 package v1_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"gitlab.com/telegram_clone/api_gateway/api/models"
+	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
+	"gitlab.com/telegram_clone/api_gateway/pkg/grpc_client/mock_grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestGetAllMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Messages are listed for the authorized user only
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetAll(context.Background(), &pbc.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: 3,
+		UserId: 1,
+	}).Times(1).Return(&pbc.GetAllMessages{
+		Messages: []*pbc.ChatMessage{{Id: 1, ChatId: 3, UserId: 2, Message: "hi"}},
+		Count:    1,
+	}, nil)
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "get-all")
+
 	resp := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/v1/messages", nil)
+	req, _ := http.NewRequest("GET", "/v1/messages?chat_id=3", nil)
+	req.Header.Add("Authorization", accessToken)
 	router.ServeHTTP(resp, req)
 
-	assert.Equal(t, 200, resp.Code)
+	assert.Equal(t, http.StatusOK, resp.Code)
 
-	fmt.Println(resp.Body.String())
+	var response models.GetAllMessagesRes
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Count)
 }
 
 func TestGetAllMessagesCases(t *testing.T) {
 	testCases := []struct {
 		name          string
 		query         string
+		authorized    bool
+		buildStubs    func(messageService *mock_grpc.MockMessageServiceClient)
 		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
 	}{
 		{
-			name:  "success case",
-			query: "?limit=10&page=1",
+			name:       "incorrect limit param",
+			query:      "?limit=ads&page=1",
+			authorized: true,
 			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
-				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Equal(t, http.StatusBadRequest, response.Code)
 			},
 		},
 		{
-			name:  "incorrect limit param",
-			query: "?limit=ads&page=1",
+			name:  "without access token",
+			query: "?chat_id=3",
 			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
-				assert.Equal(t, http.StatusBadRequest, response.Code)
+				assert.Equal(t, http.StatusUnauthorized, response.Code)
+			},
+		},
+		{
+			name:       "chat of other users",
+			query:      "?chat_id=3",
+			authorized: true,
+			buildStubs: func(messageService *mock_grpc.MockMessageServiceClient) {
+				messageService.EXPECT().GetAll(context.Background(), gomock.Any()).Times(1).
+					Return(nil, status.Error(codes.NotFound, "not found"))
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, response.Code)
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(messageService)
+			}
+			grpcConn.SetMessageService(messageService)
+
 			resp := httptest.NewRecorder()
 			url := fmt.Sprintf("/v1/messages%s", tc.query)
 
 			req, _ := http.NewRequest("GET", url, nil)
+			if tc.authorized {
+				req.Header.Add("Authorization", mockAuthMiddlewareFor(t, ctrl, "messages", "get-all"))
+			}
 			router.ServeHTTP(resp, req)
 
 			tc.checkResponse(t, resp)
 		})
 	}
 }
+
+func TestMarkRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqBody := models.MarkReadRequest{
+		ChatID:    3,
+		MessageID: 42,
+	}
+
+	// The reader is always the authorized user
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().MarkRead(context.Background(), &pbc.MarkReadRequest{
+		ChatId:    reqBody.ChatID,
+		UserId:    1,
+		MessageId: reqBody.MessageID,
+	}).Times(1).Return(&pbc.ReadCursor{
+		ChatId:            reqBody.ChatID,
+		UserId:            1,
+		LastReadMessageId: reqBody.MessageID,
+		SenderIds:         []int64{2},
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "read")
+
+	payload, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/v1/messages/read", bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.ReadCursor
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, reqBody.MessageID, response.LastReadMessageID)
+}
+
+func TestGetThread(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetThread(context.Background(), &pbc.GetThreadParams{
+		RootId: 17,
+		UserId: 1,
+		Limit:  10,
+		Page:   1,
+	}).Times(1).Return(&pbc.GetAllMessages{
+		Messages: []*pbc.ChatMessage{
+			{
+				Id:           42,
+				Message:      "reply",
+				ChatId:       3,
+				ThreadRootId: 17,
+				UserInfo:     &pbc.GetUserInfo{},
+			},
+		},
+		Count: 1,
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "thread")
+
+	req, _ := http.NewRequest("GET", "/v1/messages/17/thread?limit=10&page=1", nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.GetAllMessagesRes
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), response.Count)
+	assert.Equal(t, int64(17), response.Messages[0].ThreadRootID)
+}
+
+func TestAddReaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().AddReaction(context.Background(), &pbc.ReactionRequest{
+		MessageId: 17,
+		UserId:    1,
+		Emoji:     "👍",
+	}).Times(1).Return(&pbc.MessageReactions{
+		MessageId: 17,
+		ChatId:    3,
+		Reactions: []*pbc.Reaction{
+			{Emoji: "👍", Count: 2, Reacted: true},
+		},
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "react")
+
+	payload, err := json.Marshal(models.ReactionRequest{Emoji: "👍"})
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/v1/messages/17/reactions", bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.MessageReactions
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), response.Reactions[0].Count)
+	assert.True(t, response.Reactions[0].Reacted)
+}
+
+func TestForwardMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqBody := models.ForwardRequest{
+		MessageIDs: []int64{17, 18},
+		ChatID:     5,
+	}
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().Forward(context.Background(), &pbc.ForwardRequest{
+		MessageIds: reqBody.MessageIDs,
+		ChatId:     reqBody.ChatID,
+		UserId:     1,
+	}).Times(1).Return(nil, status.Error(codes.PermissionDenied, "forwarding is disallowed in the chat"))
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "forward")
+
+	payload, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/v1/messages/forward", bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestUpdateMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqBody := models.UpdateMessageRequest{
+		Message: "edited",
+	}
+
+	// The author is always the authorized user
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().Update(context.Background(), &pbc.ChatMessage{
+		Id:      17,
+		Message: reqBody.Message,
+		UserId:  1,
+	}).Times(1).Return(&pbc.ChatMessage{
+		Id:        17,
+		Message:   reqBody.Message,
+		UserId:    1,
+		UserInfo:  &pbc.GetUserInfo{},
+		ChatId:    3,
+		EditedAt:  "2022-11-20T10:00:00Z",
+		CreatedAt: "2022-11-20T09:00:00Z",
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "update")
+
+	payload, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("PUT", "/v1/messages/17", bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.Message
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-11-20T10:00:00Z", response.EditedAt)
+}
+
+func TestDeleteMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqBody := models.DeleteMessagesRequest{
+		MessageIDs: []int64{17, 18},
+		OnlyMe:     true,
+	}
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().DeleteMessages(context.Background(), &pbc.DeleteMessagesRequest{
+		MessageIds: reqBody.MessageIDs,
+		UserId:     1,
+		OnlyMe:     true,
+	}).Times(1).Return(&pbc.DeletedMessages{
+		Messages: []*pbc.DeletedMessage{
+			{Id: 17, ChatId: 3},
+			{Id: 18, ChatId: 3},
+		},
+		OnlyMe: true,
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "delete")
+
+	payload, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/v1/messages/delete", bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.DeletedMessages
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Messages, 2)
+	assert.True(t, response.OnlyMe)
+}
+
+func TestPinMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().Pin(context.Background(), &pbc.PinRequest{
+		MessageId: 17,
+		UserId:    1,
+	}).Times(1).Return(&pbc.PinResult{
+		Message: &pbc.ChatMessage{
+			Id:       17,
+			UserInfo: &pbc.GetUserInfo{},
+			ChatId:   3,
+			Type:     "text",
+			Pinned:   true,
+		},
+		SystemMessage: &pbc.ChatMessage{
+			Id:               18,
+			Message:          "pinned a message",
+			UserId:           1,
+			UserInfo:         &pbc.GetUserInfo{},
+			ChatId:           3,
+			ReplyToMessageId: 17,
+			Type:             "system",
+		},
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "pin")
+
+	req, _ := http.NewRequest("POST", "/v1/messages/17/pin", nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.PinResult
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Message.Pinned)
+	assert.Equal(t, "system", response.SystemMessage.Type)
+	assert.Equal(t, int64(17), response.SystemMessage.ReplyToMessageID)
+}
+
+func TestGetScheduledMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().GetScheduled(context.Background(), &pbc.GetScheduledMessagesParams{
+		UserId: 1,
+		ChatId: 3,
+		Limit:  10,
+		Page:   1,
+	}).Times(1).Return(&pbc.GetAllMessages{
+		Messages: []*pbc.ChatMessage{
+			{
+				Id:       5,
+				Message:  "announcement",
+				UserId:   1,
+				UserInfo: &pbc.GetUserInfo{},
+				ChatId:   3,
+				Type:     "text",
+				SendAt:   "2022-12-01T09:00:00Z",
+			},
+		},
+		Count: 1,
+	}, nil)
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "schedule")
+
+	req, _ := http.NewRequest("GET", "/v1/messages/scheduled?chat_id=3", nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.GetAllMessagesRes
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), response.Count)
+	assert.Equal(t, "2022-12-01T09:00:00Z", response.Messages[0].SendAt)
+}
+
+func TestCancelScheduledMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().CancelScheduled(context.Background(), &pbc.ChatIdRequest{
+		Id:     5,
+		UserId: 1,
+	}).Times(1).Return(nil, status.Error(codes.NotFound, "scheduled message not found"))
+
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "messages", "schedule")
+
+	req, _ := http.NewRequest("DELETE", "/v1/messages/scheduled/5", nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -61,6 +61,24 @@ func (h *handlerV1) AuthMiddleware(resource, action string) gin.HandlerFunc {
 	}
 }
 
+// requesterID returns the id of the user the request is authorized as on
+// routes open to anonymous users as well, 0 if there is no valid token.
+func (h *handlerV1) requesterID(c *gin.Context) int64 {
+	accessToken := c.GetHeader(authorizationHeaderKey)
+	if len(accessToken) == 0 {
+		return 0
+	}
+
+	payload, err := h.grpcClient.AuthService().VerifyToken(context.Background(), &pbc.VerifyTokenRequest{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return 0
+	}
+
+	return payload.UserId
+}
+
 func (m *handlerV1) GetAuthPayload(ctx *gin.Context) (*Payload, error) {
 	i, exists := ctx.Get(authorizationPayloadKey)
 	if !exists {
@@ -0,0 +1,455 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gitlab.com/telegram_clone/api_gateway/api/models"
+	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
+	"gitlab.com/telegram_clone/api_gateway/pkg/imaging"
+	"gitlab.com/telegram_clone/api_gateway/pkg/upload"
+)
+
+// Resumable uploads follow the tus protocol (https://tus.io) with its
+// creation, checksum, termination and expiration extensions, finished with
+// POST /uploads/{id}/finish which turns the file into an attachment.
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,checksum,termination,expiration"
+	// Content type of the chunks
+	offsetOctetStream = "application/offset+octet-stream"
+	// Status tus answers a chunk or file whose checksum doesn't match with
+	statusChecksumMismatch = 460
+)
+
+var (
+	ErrInvalidUploadLength = errors.New("Upload-Length header must be a positive number")
+	ErrInvalidUploadOffset = errors.New("Upload-Offset header must be a positive number")
+	ErrChunkContentType    = errors.New("chunks must be sent as " + offsetOctetStream)
+	ErrChunkLength         = errors.New("Content-Length header is required")
+)
+
+// tusHeaders sets the headers every answer of the resumable upload routes
+// has, with the progress of the upload when there is one.
+func (h *handlerV1) tusHeaders(c *gin.Context, u *upload.Upload) {
+	c.Header("Tus-Resumable", tusVersion)
+	if u == nil {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	c.Header("Upload-Expires", h.uploads.ExpiresAt(u).UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+}
+
+// uploadMetadata reads the file name and type from an Upload-Metadata
+// header, a list of keys with their base64 values: filename ZG9nLm1wNA==,
+// filetype dmlkZW8vbXA0
+func uploadMetadata(header string) (fileName, fileType string) {
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "filename", "name":
+			fileName = string(decoded)
+		case "filetype", "type":
+			fileType = string(decoded)
+		}
+	}
+	return fileName, fileType
+}
+
+// processedImage tells whether a file of the declared type, or with the
+// extension of the name without one, is an image processed in memory when
+// the upload is finished.
+func processedImage(fileName, fileType string) bool {
+	if fileType == "" {
+		fileType = mime.TypeByExtension(filepath.Ext(fileName))
+	}
+	fileType, _, _ = strings.Cut(fileType, ";")
+
+	switch strings.TrimSpace(strings.ToLower(fileType)) {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// uploadError answers a request whose upload failed.
+func (h *handlerV1) uploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, upload.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, upload.ErrOffsetMismatch), errors.Is(err, upload.ErrIncomplete):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, upload.ErrTooLarge), errors.Is(err, upload.ErrExceedsLength):
+		c.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+	case errors.Is(err, upload.ErrChecksumMismatch):
+		c.JSON(statusChecksumMismatch, errorResponse(err))
+	case errors.Is(err, upload.ErrShortChunk):
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+	case errors.Is(err, upload.ErrLocked):
+		c.JSON(http.StatusLocked, errorResponse(err))
+	default:
+		h.logger.WithError(err).Error("failed to upload")
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+func (h *handlerV1) parseUpload(u *upload.Upload) *models.Upload {
+	return &models.Upload{
+		ID:        u.ID,
+		FileName:  u.FileName,
+		Length:    u.Length,
+		Offset:    u.Offset,
+		ExpiresAt: h.uploads.ExpiresAt(u).Format(time.RFC3339),
+	}
+}
+
+// @Router /uploads [options]
+// @Summary Resumable upload capabilities
+// @Description Tells the tus version, extensions, checksum algorithms and largest file of the resumable uploads
+// @Tags uploads
+// @Success 204
+func (h *handlerV1) UploadOptions(c *gin.Context) {
+	h.tusHeaders(c, nil)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Checksum-Algorithm", upload.Algorithms)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.uploads.MaxSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// @Security ApiKeyAuth
+// @Router /uploads [post]
+// @Summary Start a resumable upload
+// @Description Starts uploading a large file in chunks, e.g. a video from a phone. Send the chunks with PATCH /uploads/{id}, ask where the upload is at with HEAD /uploads/{id} after a dropped connection and finish it with POST /uploads/{id}/finish. Uploads which get no chunk until Upload-Expires are removed. Jpeg, png and gif images are processed like with POST /media, larger ones than it takes are refused here from their filetype or filename
+// @Tags uploads
+// @Produce json
+// @Param Upload-Length header int true "Size of the file in bytes"
+// @Param Upload-Metadata header string false "filename and filetype with their base64 values"
+// @Success 201 {object} models.Upload
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) CreateUpload(c *gin.Context) {
+	h.tusHeaders(c, nil)
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(ErrInvalidUploadLength))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// Refused before the client sends all of it rather than when finished
+	fileName, fileType := uploadMetadata(c.GetHeader("Upload-Metadata"))
+	if length > maxImageSize && processedImage(fileName, fileType) {
+		h.imageError(c, ErrImageTooLarge)
+		return
+	}
+
+	u, err := h.uploads.Create(c.Request.Context(), payload.UserID, length, fileName)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	h.tusHeaders(c, u)
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+u.ID)
+	c.JSON(http.StatusCreated, h.parseUpload(u))
+}
+
+// @Security ApiKeyAuth
+// @Router /uploads/{id} [head]
+// @Summary Get the offset of a resumable upload
+// @Description Upload-Offset tells how much of the file is stored, the next chunk starts there
+// @Tags uploads
+// @Param id path string true "ID"
+// @Success 200
+// @Failure 404
+func (h *handlerV1) GetUploadOffset(c *gin.Context) {
+	h.tusHeaders(c, nil)
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.uploads.Get(c.Request.Context(), c.Param("id"), payload.UserID)
+	if err != nil {
+		if !errors.Is(err, upload.ErrNotFound) {
+			h.logger.WithError(err).Error("failed to get upload")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	h.tusHeaders(c, u)
+	c.Status(http.StatusOK)
+}
+
+// @Security ApiKeyAuth
+// @Router /uploads/{id} [patch]
+// @Summary Send a chunk of a resumable upload
+// @Description Stores the chunk at Upload-Offset, which must be the offset of the upload. A chunk which doesn't arrive whole or doesn't match its checksum isn't kept, send it again from the offset HEAD /uploads/{id} tells. Chunks sent while another request writes the upload get 423
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Param id path string true "ID"
+// @Param Upload-Offset header int true "Offset the chunk starts at"
+// @Param Upload-Checksum header string false "Checksum of the chunk: sha1, sha256 or md5 and the base64 digest"
+// @Success 204
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 460 {object} models.ErrorResponse
+// @Failure 423 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 411 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) UploadChunk(c *gin.Context) {
+	h.tusHeaders(c, nil)
+
+	if c.ContentType() != offsetOctetStream {
+		c.JSON(http.StatusUnsupportedMediaType, errorResponse(ErrChunkContentType))
+		return
+	}
+	if c.Request.ContentLength < 0 {
+		c.JSON(http.StatusLengthRequired, errorResponse(ErrChunkLength))
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(ErrInvalidUploadOffset))
+		return
+	}
+
+	var checksum *upload.Checksum
+	if header := c.GetHeader("Upload-Checksum"); header != "" {
+		checksum, err = upload.ParseChecksum(header)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	u, err := h.uploads.Get(c.Request.Context(), c.Param("id"), payload.UserID)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	err = h.uploads.Append(c.Request.Context(), u, offset, c.Request.Body, c.Request.ContentLength, checksum)
+	h.tusHeaders(c, u)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Security ApiKeyAuth
+// @Router /uploads/{id} [delete]
+// @Summary Cancel a resumable upload
+// @Tags uploads
+// @Param id path string true "ID"
+// @Success 204
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 423 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+func (h *handlerV1) CancelUpload(c *gin.Context) {
+	h.tusHeaders(c, nil)
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	u, err := h.uploads.Get(c.Request.Context(), c.Param("id"), payload.UserID)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	unlock, err := h.uploads.Lock(c.Request.Context(), u.ID)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	defer unlock()
+
+	if err := h.uploads.Delete(c.Request.Context(), u.ID); err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Security ApiKeyAuth
+// @Router /uploads/{id}/finish [post]
+// @Summary Finish a resumable upload
+// @Description Stores the uploaded file and returns it as an attachment, like POST /media does. With Upload-Checksum the whole file is checked against it. The upload is removed once it is finished. It gets 423 while another request writes the upload
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param id path string true "ID"
+// @Param Upload-Checksum header string false "Checksum of the whole file: sha1, sha256 or md5 and the base64 digest"
+// @Param data body models.FinishUploadRequest false "Data"
+// @Success 200 {object} models.Attachment
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 460 {object} models.ErrorResponse
+// @Failure 423 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+func (h *handlerV1) FinishUpload(c *gin.Context) {
+	h.tusHeaders(c, nil)
+
+	var req models.FinishUploadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	var checksum *upload.Checksum
+	if header := c.GetHeader("Upload-Checksum"); header != "" {
+		var err error
+		checksum, err = upload.ParseChecksum(header)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.uploads.Get(ctx, c.Param("id"), payload.UserID)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	// Finished once, a request finishing it meanwhile removed it
+	unlock, err := h.uploads.Lock(ctx, u.ID)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	defer unlock()
+	u, err = h.uploads.Get(ctx, u.ID, payload.UserID)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+
+	f, err := h.uploads.Open(ctx, u, checksum)
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 512)
+	head, err := r.Peek(512)
+	if err != nil && err != io.EOF {
+		h.uploadError(c, err)
+		return
+	}
+
+	mimeType := detectMimeType(head, u.FileName)
+	size, width, height := u.Length, req.Width, req.Height
+
+	var key string
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+		if u.Length > maxImageSize {
+			h.imageError(c, ErrImageTooLarge)
+			return
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			h.uploadError(c, err)
+			return
+		}
+
+		var img *imaging.Image
+		key, img, err = h.storeImage(data)
+		if err != nil {
+			h.imageError(c, err)
+			return
+		}
+		mimeType, size = img.MimeType, int64(len(img.Data))
+		width, height = int32(img.Width), int32(img.Height)
+	default:
+		key = uuid.New().String() + filepath.Ext(u.FileName)
+		if err := h.storage.Put(ctx, key, r, u.Length, mimeType); err != nil {
+			h.storage.Delete(context.Background(), key)
+			h.uploadError(c, err)
+			return
+		}
+	}
+
+	attachment, err := h.grpcClient.MessageService().CreateAttachment(context.Background(), &pbc.Attachment{
+		UserId:   payload.UserID,
+		Url:      key,
+		FileName: u.FileName,
+		MimeType: mimeType,
+		Size:     size,
+		Width:    width,
+		Height:   height,
+		Duration: req.Duration,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to create attachment")
+		h.deleteImage(key)
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Left to the collector when it fails, the file is stored already
+	if err := h.uploads.Delete(ctx, u.ID); err != nil {
+		h.logger.WithError(err).Error("failed to delete finished upload")
+	}
+
+	c.JSON(http.StatusOK, h.parseAttachment(attachment))
+}
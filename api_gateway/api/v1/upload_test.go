@@ -0,0 +1,264 @@
+package v1_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/api_gateway/api/models"
+	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
+	"gitlab.com/telegram_clone/api_gateway/pkg/grpc_client/mock_grpc"
+)
+
+func uploadChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256 " + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// createUpload starts an upload of the length and returns its address.
+func createUpload(t *testing.T, ctrl *gomock.Controller, length int, fileName string) string {
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+
+	req, _ := http.NewRequest("POST", "/v1/uploads", nil)
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Upload-Length", strconv.Itoa(length))
+	req.Header.Add("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(fileName))+",type dGV4dA==")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "1.0.0", rec.Header().Get("Tus-Resumable"))
+	assert.Equal(t, "0", rec.Header().Get("Upload-Offset"))
+	assert.NotEmpty(t, rec.Header().Get("Upload-Expires"))
+
+	var response models.Upload
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, fileName, response.FileName)
+	assert.Equal(t, int64(length), response.Length)
+
+	location := rec.Header().Get("Location")
+	assert.Equal(t, "/v1/uploads/"+response.ID, location)
+	t.Cleanup(func() { uploads.Delete(context.Background(), response.ID) })
+
+	return location
+}
+
+func uploadChunk(t *testing.T, ctrl *gomock.Controller, location string, offset int, chunk, checksum string) *httptest.ResponseRecorder {
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+
+	req, _ := http.NewRequest("PATCH", location, strings.NewReader(chunk))
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Content-Type", "application/offset+octet-stream")
+	req.Header.Add("Upload-Offset", strconv.Itoa(offset))
+	if checksum != "" {
+		req.Header.Add("Upload-Checksum", checksum)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func uploadOffset(t *testing.T, ctrl *gomock.Controller, location string) string {
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+
+	req, _ := http.NewRequest("HEAD", location, nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	return rec.Header().Get("Upload-Offset")
+}
+
+func TestResumableUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	content := "a long video, or so"
+	location := createUpload(t, ctrl, len(content), "cat.mp4")
+
+	rec := uploadChunk(t, ctrl, location, 0, content[:10], uploadChecksum(content[:10]))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("Upload-Offset"))
+
+	// A chunk sent again after a dropped connection
+	rec = uploadChunk(t, ctrl, location, 0, content[:10], "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// The client asks where to continue from
+	assert.Equal(t, "10", uploadOffset(t, ctrl, location))
+
+	rec = uploadChunk(t, ctrl, location, 10, content[10:]+"!", "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = uploadChunk(t, ctrl, location, 10, content[10:], "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	messageService := mock_grpc.NewMockMessageServiceClient(ctrl)
+	messageService.EXPECT().CreateAttachment(context.Background(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, req *pbc.Attachment, _ ...interface{}) (*pbc.Attachment, error) {
+			assert.Equal(t, int64(1), req.UserId)
+			assert.Equal(t, "cat.mp4", req.FileName)
+			assert.Equal(t, "video/mp4", req.MimeType)
+			assert.Equal(t, int64(len(content)), req.Size)
+			assert.Equal(t, int32(12), req.Duration)
+			assert.Regexp(t, `^[0-9a-f-]+\.mp4$`, req.Url)
+
+			f, _, err := media.Open(context.Background(), req.Url)
+			require.NoError(t, err)
+			stored, err := io.ReadAll(f)
+			f.Close()
+			require.NoError(t, err)
+			assert.Equal(t, content, string(stored))
+			t.Cleanup(func() { media.Delete(context.Background(), req.Url) })
+
+			return &pbc.Attachment{Id: 14, Url: req.Url, MimeType: req.MimeType, Size: req.Size}, nil
+		},
+	)
+	grpcConn.SetMessageService(messageService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+	req, _ := http.NewRequest("POST", location+"/finish", strings.NewReader(`{"duration": 12}`))
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Upload-Checksum", uploadChecksum(content))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response models.Attachment
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, int64(14), response.ID)
+
+	// Finished uploads are removed
+	keys, err := media.List(context.Background(), "uploads/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestUploadChunkChecksumMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	location := createUpload(t, ctrl, 10, "notes.txt")
+
+	rec := uploadChunk(t, ctrl, location, 0, "0123456789", uploadChecksum("0123456780"))
+	assert.Equal(t, 460, rec.Code)
+	assert.Equal(t, "0", uploadOffset(t, ctrl, location))
+
+	rec = uploadChunk(t, ctrl, location, 0, "0123456789", "crc32 AAAAAA==")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFinishIncompleteUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	location := createUpload(t, ctrl, 10, "notes.txt")
+	rec := uploadChunk(t, ctrl, location, 0, "01234", "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+	req, _ := http.NewRequest("POST", location+"/finish", nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestCreateUploadImageTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, metadata := range []string{
+		"filename " + base64.StdEncoding.EncodeToString([]byte("holidays.JPG")),
+		"filetype " + base64.StdEncoding.EncodeToString([]byte("image/png")),
+	} {
+		accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+
+		req, _ := http.NewRequest("POST", "/v1/uploads", nil)
+		req.Header.Add("Authorization", accessToken)
+		req.Header.Add("Upload-Length", strconv.Itoa(25<<20))
+		req.Header.Add("Upload-Metadata", metadata)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		// Refused before any of it is sent, it couldn't be finished
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, metadata)
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "images up to 20 MB are accepted", response.Error)
+	}
+}
+
+func TestUploadLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	location := createUpload(t, ctrl, 10, "notes.txt")
+
+	// Another request writes the upload
+	unlock, err := uploads.Lock(context.Background(), strings.TrimPrefix(location, "/v1/uploads/"))
+	require.NoError(t, err)
+
+	rec := uploadChunk(t, ctrl, location, 0, "0123456789", "")
+	assert.Equal(t, http.StatusLocked, rec.Code)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+	req, _ := http.NewRequest("POST", location+"/finish", nil)
+	req.Header.Add("Authorization", accessToken)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusLocked, rec.Code)
+
+	unlock()
+	assert.Equal(t, "0", uploadOffset(t, ctrl, location))
+	rec = uploadChunk(t, ctrl, location, 0, "0123456789", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestCreateUploadTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "uploads", "upload")
+
+	req, _ := http.NewRequest("POST", "/v1/uploads", nil)
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Upload-Length", strconv.Itoa(2<<20))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req, _ = http.NewRequest("OPTIONS", "/v1/uploads", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, strconv.Itoa(1<<20), rec.Header().Get("Tus-Max-Size"))
+	assert.Contains(t, rec.Header().Get("Tus-Extension"), "checksum")
+}
@@ -49,7 +49,7 @@ func (h *handlerV1) CreateUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, parseUserModel(user))
+	c.JSON(http.StatusCreated, h.parseUserModel(user))
 }
 
 // @Security ApiKeyAuth
@@ -89,16 +89,17 @@ func (h *handlerV1) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, parseUserModel(user))
+	c.JSON(http.StatusOK, h.parseUserModel(user))
 }
 
 // @Router /users/{id} [get]
 // @Summary Get user by id
-// @Description Get user by id
+// @Description Get user by id. Online and last seen are returned only if the privacy settings of the user let the requester see them.
 // @Tags user
 // @Accept json
 // @Produce json
 // @Param id path int true "ID"
+// @Param Authorization header string false "Access token of the requester"
 // @Success 200 {object} models.User
 // @Failure 500 {object} models.ErrorResponse
 func (h *handlerV1) GetUser(c *gin.Context) {
@@ -109,7 +110,10 @@ func (h *handlerV1) GetUser(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.grpcClient.UserService().Get(context.Background(), &pbc.GetUserRequest{Id: int64(id)})
+	resp, err := h.grpcClient.UserService().Get(context.Background(), &pbc.GetUserRequest{
+		Id:          int64(id),
+		RequesterId: h.requesterID(c),
+	})
 	if err != nil {
 		h.logger.WithError(err).Error("failed to get user")
 		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
@@ -120,7 +124,7 @@ func (h *handlerV1) GetUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, parseUserModel(resp))
+	c.JSON(http.StatusOK, h.parseUserModel(resp))
 }
 
 // @Router /users/email/{email} [get]
@@ -146,19 +150,25 @@ func (h *handlerV1) GetUserByEmail(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, parseUserModel(resp))
+	c.JSON(http.StatusOK, h.parseUserModel(resp))
 }
 
-func parseUserModel(user *pbc.User) models.User {
+func (h *handlerV1) parseUserModel(user *pbc.User) models.User {
 	return models.User{
 		ID:              user.Id,
 		FirstName:       user.FirstName,
 		LastName:        user.LastName,
 		Email:           user.Email,
 		Username:        user.Username,
-		ProfileImageUrl: user.ProfileImageUrl,
-		Type:            user.Type,
-		CreatedAt:       user.CreatedAt,
+		ProfileImageUrl: h.mediaURL(user.ProfileImageUrl),
+
+		ProfileImageThumbnails: h.imageThumbnails(user.ProfileImageUrl),
+		Type:                   user.Type,
+		CreatedAt:              user.CreatedAt,
+
+		Online:             user.Online,
+		LastSeen:           user.LastSeen,
+		LastSeenVisibility: user.LastSeenVisibility,
 	}
 }
 
@@ -189,17 +199,17 @@ func (h *handlerV1) GetAllUsers(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, getUsersResponse(result))
+	c.JSON(http.StatusOK, h.getUsersResponse(result))
 }
 
-func getUsersResponse(data *pbc.GetAllUsersResponse) *models.GetAllUsersResponse {
+func (h *handlerV1) getUsersResponse(data *pbc.GetAllUsersResponse) *models.GetAllUsersResponse {
 	response := models.GetAllUsersResponse{
 		Users: make([]*models.User, 0),
 		Count: data.Count,
 	}
 
 	for _, user := range data.Users {
-		u := parseUserModel(user)
+		u := h.parseUserModel(user)
 		response.Users = append(response.Users, &u)
 	}
 
@@ -258,7 +268,10 @@ func (h *handlerV1) GetUserByToken(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.grpcClient.UserService().Get(context.Background(), &pbc.GetUserRequest{Id: payload.UserID})
+	resp, err := h.grpcClient.UserService().Get(context.Background(), &pbc.GetUserRequest{
+		Id:          payload.UserID,
+		RequesterId: payload.UserID,
+	})
 	// fmt.Println(resp)
 	if err != nil {
 		h.logger.WithError(err).Error("failed to get user")
@@ -270,5 +283,47 @@ func (h *handlerV1) GetUserByToken(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, parseUserModel(resp))
+	c.JSON(http.StatusOK, h.parseUserModel(resp))
+}
+
+// @Security ApiKeyAuth
+// @Router /users/me/last-seen-visibility [put]
+// @Summary Set who can see online and last seen
+// @Description Set who can see whether the user is online and when it was last seen: everybody, chats (users sharing a chat) or nobody
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param visibility body models.SetLastSeenVisibilityRequest true "Visibility"
+// @Success 200 {object} models.User
+// @Failure 500 {object} models.ErrorResponse
+func (h *handlerV1) SetLastSeenVisibility(c *gin.Context) {
+	var req models.SetLastSeenVisibilityRequest
+
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := h.GetAuthPayload(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	user, err := h.grpcClient.UserService().SetLastSeenVisibility(context.Background(), &pbc.SetLastSeenVisibilityRequest{
+		UserId:     payload.UserID,
+		Visibility: req.Visibility,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to set last seen visibility")
+		if s, _ := status.FromError(err); s.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.parseUserModel(user))
 }
@@ -4,11 +4,8 @@ import (
 	"context"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"gitlab.com/telegram_clone/api_gateway/api/models"
 	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
 )
@@ -20,13 +17,15 @@ type File struct {
 // @Security ApiKeyAuth
 // @Router /users/file-upload [post]
 // @Summary File upload
-// @Description File upload
+// @Description Sets the profile image of the user. Jpeg, png and gif images are accepted, they are stored turned upright and without their metadata, with thumbnails
 // @Tags users/file-upload
-// @Accept json
+// @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "File"
-// @Success 200 {object} models.ResponseOK
+// @Success 200 {object} models.User
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
 func (h *handlerV1) UsersFileUpload(c *gin.Context) {
 	var file File
 
@@ -38,18 +37,9 @@ func (h *handlerV1) UsersFileUpload(c *gin.Context) {
 		return
 	}
 
-	id := uuid.New()
-	fileName := id.String() + filepath.Ext(file.File.Filename)
-	dst, _ := os.Getwd()
-	if _, err := os.Stat(dst + "/media"); os.IsNotExist(err) {
-		os.Mkdir(dst+"/media", os.ModePerm)
-	}
-
-	filePath := "/media/" + fileName
-	if err = c.SaveUploadedFile(file.File, dst+filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: err.Error(),
-		})
+	key, _, err := h.saveImage(file.File)
+	if err != nil {
+		h.imageError(c, err)
 		return
 	}
 	payload, err := h.GetAuthPayload(c)
@@ -61,13 +51,14 @@ func (h *handlerV1) UsersFileUpload(c *gin.Context) {
 	}
 	user, err := h.grpcClient.UserService().SetUserImage(context.Background(), &pbc.SetUserImageRequest{
 		UserId:   int64(payload.UserID),
-		ImageUrl: filePath,
+		ImageUrl: key,
 	})
 	if err != nil {
+		h.deleteImage(key)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, parseUserModel(user))
+	c.JSON(http.StatusOK, h.parseUserModel(user))
 }
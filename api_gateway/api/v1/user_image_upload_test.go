@@ -0,0 +1,85 @@
+package v1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/api_gateway/api/models"
+	pbc "gitlab.com/telegram_clone/api_gateway/genproto/chat_service"
+	"gitlab.com/telegram_clone/api_gateway/pkg/grpc_client/mock_grpc"
+)
+
+func uploadProfileImage(t *testing.T, accessToken, fileName string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	part.Write(content)
+	require.NoError(t, form.Close())
+
+	req, _ := http.NewRequest("POST", "/v1/users/file-upload", &body)
+	req.Header.Add("Authorization", accessToken)
+	req.Header.Add("Content-Type", form.FormDataContentType())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestUsersFileUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var photo bytes.Buffer
+	require.NoError(t, jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 800, 600)), nil))
+
+	userService := mock_grpc.NewMockUserServiceClient(ctrl)
+	userService.EXPECT().SetUserImage(context.Background(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, req *pbc.SetUserImageRequest, _ ...interface{}) (*pbc.User, error) {
+			assert.Equal(t, int64(1), req.UserId)
+			assert.Regexp(t, `^[0-9a-f-]+/original\.jpg$`, req.ImageUrl)
+			t.Cleanup(func() { media.Delete(context.Background(), req.ImageUrl) })
+
+			return &pbc.User{Id: 1, ProfileImageUrl: req.ImageUrl}, nil
+		},
+	)
+	grpcConn.SetUserService(userService)
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "users", "users/file-upload")
+	// The name the client gives doesn't matter
+	rec := uploadProfileImage(t, accessToken, "avatar.gif", photo.Bytes())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.User
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response.ProfileImageUrl, "/original.jpg?")
+	require.Len(t, response.ProfileImageThumbnails, 3)
+	assert.Equal(t, 640, response.ProfileImageThumbnails[2].Size)
+	assert.Contains(t, response.ProfileImageThumbnails[2].URL, "/640.jpg?")
+}
+
+func TestUsersFileUploadRejectsNonImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	accessToken := mockAuthMiddlewareFor(t, ctrl, "users", "users/file-upload")
+	rec := uploadProfileImage(t, accessToken, "avatar.jpg", []byte("%PDF-1.4 not an image"))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "only jpeg, png and gif images are accepted", response.Error)
+}
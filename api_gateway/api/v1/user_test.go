@@ -115,3 +115,38 @@ func TestCreateUser(t *testing.T) {
 	assert.Equal(t, reqBody.FirstName, response.FirstName)
 	assert.Equal(t, reqBody.Email, response.Email)
 }
+
+func TestGetUserReturnsPresence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lastSeen := time.Now().Format(time.RFC3339)
+
+	// Anonymous requests see presence only if it is visible to everybody
+	userService := mock_grpc.NewMockUserServiceClient(ctrl)
+	userService.EXPECT().Get(context.Background(), &pbc.GetUserRequest{
+		Id: 5,
+	}).Times(1).Return(&pbc.User{
+		Id:        5,
+		FirstName: faker.FirstName(),
+		LastName:  faker.LastName(),
+		Email:     faker.Email(),
+		Type:      "user",
+		CreatedAt: time.Now().Format(time.RFC3339),
+		LastSeen:  lastSeen,
+	}, nil)
+
+	grpcConn.SetUserService(userService)
+
+	req, _ := http.NewRequest("GET", "/v1/users/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.User
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.False(t, response.Online)
+	assert.Equal(t, lastSeen, response.LastSeen)
+}
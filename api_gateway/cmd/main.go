@@ -9,6 +9,8 @@ import (
 	"gitlab.com/telegram_clone/api_gateway/config"
 	grpcPkg "gitlab.com/telegram_clone/api_gateway/pkg/grpc_client"
 	"gitlab.com/telegram_clone/api_gateway/pkg/logger"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+	"gitlab.com/telegram_clone/api_gateway/pkg/upload"
 )
 
 func main() {
@@ -21,10 +23,24 @@ func main() {
 		log.Fatalf("failed to get grpc connections: %v", err)
 	}
 
+	if cfg.MediaSigningKey == "" {
+		log.Fatalf("MEDIA_SIGNING_KEY is required")
+	}
+
+	media, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("failed to set up media storage: %v", err)
+	}
+
+	uploads := upload.New(media, cfg.UploadMaxSize, cfg.UploadExpiry)
+	go uploads.RunCollector(cfg.UploadGCInterval, logrus)
+
 	apiServer := api.New(&api.RouterOptions{
 		Cfg:        &cfg,
 		GrpcClient: grpcConn,
 		Logger:     logrus,
+		Storage:    media,
+		Uploads:    uploads,
 	})
 
 	err = apiServer.Run(cfg.HttpPort)
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -9,6 +11,30 @@ type Config struct {
 	HttpPort            string
 	ChatServiceGrpcPort string
 	ChatServiceHost     string
+
+	// Where uploaded files are kept: local or s3
+	MediaStorage  string
+	MediaLocalDir string
+	// Address of the media download route, e.g. https://api.example.com/v1/media
+	MediaBaseURL string
+	// Secret the media urls are signed with
+	MediaSigningKey string
+	// How long a media url is valid at least, it is valid for up to twice
+	// as long so it stays the same and clients can cache the file
+	MediaURLTTL time.Duration
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// Largest file uploaded in chunks, in bytes
+	UploadMaxSize int64
+	// Chunked uploads which get no chunk for this long are abandoned and
+	// removed by the collector, which runs every UploadGCInterval
+	UploadExpiry     time.Duration
+	UploadGCInterval time.Duration
 }
 
 func Load(path string) Config {
@@ -17,10 +43,35 @@ func Load(path string) Config {
 	conf := viper.New()
 	conf.AutomaticEnv()
 
+	conf.SetDefault("MEDIA_STORAGE", "local")
+	conf.SetDefault("MEDIA_LOCAL_DIR", "./media")
+	conf.SetDefault("MEDIA_BASE_URL", "/v1/media")
+	conf.SetDefault("MEDIA_URL_TTL", time.Hour)
+	conf.SetDefault("UPLOAD_MAX_SIZE", 2<<30)
+	conf.SetDefault("UPLOAD_EXPIRY", 24*time.Hour)
+	conf.SetDefault("UPLOAD_GC_INTERVAL", time.Hour)
+
 	cfg := Config{
 		HttpPort:            conf.GetString("HTTP_PORT"),
 		ChatServiceHost:     conf.GetString("CHAT_SERVICE_HOST"),
 		ChatServiceGrpcPort: conf.GetString("CHAT_SERVICE_GRPC_PORT"),
+
+		MediaStorage:  conf.GetString("MEDIA_STORAGE"),
+		MediaLocalDir: conf.GetString("MEDIA_LOCAL_DIR"),
+		MediaBaseURL:  conf.GetString("MEDIA_BASE_URL"),
+
+		MediaSigningKey: conf.GetString("MEDIA_SIGNING_KEY"),
+		MediaURLTTL:     conf.GetDuration("MEDIA_URL_TTL"),
+
+		S3Endpoint:  conf.GetString("S3_ENDPOINT"),
+		S3Region:    conf.GetString("S3_REGION"),
+		S3Bucket:    conf.GetString("S3_BUCKET"),
+		S3AccessKey: conf.GetString("S3_ACCESS_KEY"),
+		S3SecretKey: conf.GetString("S3_SECRET_KEY"),
+
+		UploadMaxSize:    conf.GetInt64("UPLOAD_MAX_SIZE"),
+		UploadExpiry:     conf.GetDuration("UPLOAD_EXPIRY"),
+		UploadGCInterval: conf.GetDuration("UPLOAD_GC_INTERVAL"),
 	}
 
 	return cfg
@@ -25,8 +25,42 @@ const _ = grpc.SupportPackageIsVersion7
 type MessageServiceClient interface {
 	Create(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*ChatMessage, error)
 	Update(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*ChatMessage, error)
+	// Deletes the message for everyone
 	Delete(ctx context.Context, in *ChatIdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Deletes the messages for everyone or hides them for the user, all or
+	// none of them
+	DeleteMessages(ctx context.Context, in *DeleteMessagesRequest, opts ...grpc.CallOption) (*DeletedMessages, error)
 	GetAll(ctx context.Context, in *GetAllMessagesParams, opts ...grpc.CallOption) (*GetAllMessages, error)
+	// Replies of a thread, newest first
+	GetThread(ctx context.Context, in *GetThreadParams, opts ...grpc.CallOption) (*GetAllMessages, error)
+	// Messages of all chats of the user newer than a cursor
+	GetAllSince(ctx context.Context, in *GetMessagesSinceParams, opts ...grpc.CallOption) (*GetAllMessages, error)
+	// Moves the read cursor of the member forward
+	MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*ReadCursor, error)
+	// Members who have read the message, its author excluded
+	GetReadBy(ctx context.Context, in *GetReadByParams, opts ...grpc.CallOption) (*GetAllUsersResponse, error)
+	// Reacts to a message with an emoji allowed in the chat, members only
+	AddReaction(ctx context.Context, in *ReactionRequest, opts ...grpc.CallOption) (*MessageReactions, error)
+	RemoveReaction(ctx context.Context, in *ReactionRequest, opts ...grpc.CallOption) (*MessageReactions, error)
+	// Copies the messages to another chat, in the order of their ids
+	Forward(ctx context.Context, in *ForwardRequest, opts ...grpc.CallOption) (*GetAllMessages, error)
+	// Previous texts of an edited message, newest first
+	GetRevisions(ctx context.Context, in *GetRevisionsParams, opts ...grpc.CallOption) (*MessageRevisions, error)
+	// Pins the message to its chat and appends a system message about it
+	Pin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*PinResult, error)
+	Unpin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*ChatMessage, error)
+	// Scheduled messages of the user, the next due first
+	GetScheduled(ctx context.Context, in *GetScheduledMessagesParams, opts ...grpc.CallOption) (*GetAllMessages, error)
+	// Changes the text and send_at of a scheduled message of the user
+	UpdateScheduled(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*ChatMessage, error)
+	CancelScheduled(ctx context.Context, in *ChatIdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Stores an uploaded file so the uploader can send it with a message
+	CreateAttachment(ctx context.Context, in *Attachment, opts ...grpc.CallOption) (*Attachment, error)
+	// Finds what refers to a stored file, NotFound once nothing does. With a
+	// user only attachments they uploaded or that were sent to their chats
+	// are found, without one profile and chat images are found too, with
+	// only the url set
+	GetMedia(ctx context.Context, in *GetMediaRequest, opts ...grpc.CallOption) (*Attachment, error)
 }
 
 type messageServiceClient struct {
@@ -64,6 +98,15 @@ func (c *messageServiceClient) Delete(ctx context.Context, in *ChatIdRequest, op
 	return out, nil
 }
 
+func (c *messageServiceClient) DeleteMessages(ctx context.Context, in *DeleteMessagesRequest, opts ...grpc.CallOption) (*DeletedMessages, error) {
+	out := new(DeletedMessages)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/DeleteMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *messageServiceClient) GetAll(ctx context.Context, in *GetAllMessagesParams, opts ...grpc.CallOption) (*GetAllMessages, error) {
 	out := new(GetAllMessages)
 	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetAll", in, out, opts...)
@@ -73,14 +116,183 @@ func (c *messageServiceClient) GetAll(ctx context.Context, in *GetAllMessagesPar
 	return out, nil
 }
 
+func (c *messageServiceClient) GetThread(ctx context.Context, in *GetThreadParams, opts ...grpc.CallOption) (*GetAllMessages, error) {
+	out := new(GetAllMessages)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetAllSince(ctx context.Context, in *GetMessagesSinceParams, opts ...grpc.CallOption) (*GetAllMessages, error) {
+	out := new(GetAllMessages)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetAllSince", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*ReadCursor, error) {
+	out := new(ReadCursor)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/MarkRead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetReadBy(ctx context.Context, in *GetReadByParams, opts ...grpc.CallOption) (*GetAllUsersResponse, error) {
+	out := new(GetAllUsersResponse)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetReadBy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) AddReaction(ctx context.Context, in *ReactionRequest, opts ...grpc.CallOption) (*MessageReactions, error) {
+	out := new(MessageReactions)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/AddReaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) RemoveReaction(ctx context.Context, in *ReactionRequest, opts ...grpc.CallOption) (*MessageReactions, error) {
+	out := new(MessageReactions)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/RemoveReaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) Forward(ctx context.Context, in *ForwardRequest, opts ...grpc.CallOption) (*GetAllMessages, error) {
+	out := new(GetAllMessages)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/Forward", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetRevisions(ctx context.Context, in *GetRevisionsParams, opts ...grpc.CallOption) (*MessageRevisions, error) {
+	out := new(MessageRevisions)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetRevisions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) Pin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*PinResult, error) {
+	out := new(PinResult)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/Pin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) Unpin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*ChatMessage, error) {
+	out := new(ChatMessage)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/Unpin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetScheduled(ctx context.Context, in *GetScheduledMessagesParams, opts ...grpc.CallOption) (*GetAllMessages, error) {
+	out := new(GetAllMessages)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetScheduled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) UpdateScheduled(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*ChatMessage, error) {
+	out := new(ChatMessage)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/UpdateScheduled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) CancelScheduled(ctx context.Context, in *ChatIdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/CancelScheduled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) CreateAttachment(ctx context.Context, in *Attachment, opts ...grpc.CallOption) (*Attachment, error) {
+	out := new(Attachment)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/CreateAttachment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetMedia(ctx context.Context, in *GetMediaRequest, opts ...grpc.CallOption) (*Attachment, error) {
+	out := new(Attachment)
+	err := c.cc.Invoke(ctx, "/genproto.MessageService/GetMedia", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MessageServiceServer is the server API for MessageService service.
 // All implementations must embed UnimplementedMessageServiceServer
 // for forward compatibility
 type MessageServiceServer interface {
 	Create(context.Context, *ChatMessage) (*ChatMessage, error)
 	Update(context.Context, *ChatMessage) (*ChatMessage, error)
+	// Deletes the message for everyone
 	Delete(context.Context, *ChatIdRequest) (*emptypb.Empty, error)
+	// Deletes the messages for everyone or hides them for the user, all or
+	// none of them
+	DeleteMessages(context.Context, *DeleteMessagesRequest) (*DeletedMessages, error)
 	GetAll(context.Context, *GetAllMessagesParams) (*GetAllMessages, error)
+	// Replies of a thread, newest first
+	GetThread(context.Context, *GetThreadParams) (*GetAllMessages, error)
+	// Messages of all chats of the user newer than a cursor
+	GetAllSince(context.Context, *GetMessagesSinceParams) (*GetAllMessages, error)
+	// Moves the read cursor of the member forward
+	MarkRead(context.Context, *MarkReadRequest) (*ReadCursor, error)
+	// Members who have read the message, its author excluded
+	GetReadBy(context.Context, *GetReadByParams) (*GetAllUsersResponse, error)
+	// Reacts to a message with an emoji allowed in the chat, members only
+	AddReaction(context.Context, *ReactionRequest) (*MessageReactions, error)
+	RemoveReaction(context.Context, *ReactionRequest) (*MessageReactions, error)
+	// Copies the messages to another chat, in the order of their ids
+	Forward(context.Context, *ForwardRequest) (*GetAllMessages, error)
+	// Previous texts of an edited message, newest first
+	GetRevisions(context.Context, *GetRevisionsParams) (*MessageRevisions, error)
+	// Pins the message to its chat and appends a system message about it
+	Pin(context.Context, *PinRequest) (*PinResult, error)
+	Unpin(context.Context, *PinRequest) (*ChatMessage, error)
+	// Scheduled messages of the user, the next due first
+	GetScheduled(context.Context, *GetScheduledMessagesParams) (*GetAllMessages, error)
+	// Changes the text and send_at of a scheduled message of the user
+	UpdateScheduled(context.Context, *ChatMessage) (*ChatMessage, error)
+	CancelScheduled(context.Context, *ChatIdRequest) (*emptypb.Empty, error)
+	// Stores an uploaded file so the uploader can send it with a message
+	CreateAttachment(context.Context, *Attachment) (*Attachment, error)
+	// Finds what refers to a stored file, NotFound once nothing does. With a
+	// user only attachments they uploaded or that were sent to their chats
+	// are found, without one profile and chat images are found too, with
+	// only the url set
+	GetMedia(context.Context, *GetMediaRequest) (*Attachment, error)
 	mustEmbedUnimplementedMessageServiceServer()
 }
 
@@ -97,9 +309,57 @@ func (UnimplementedMessageServiceServer) Update(context.Context, *ChatMessage) (
 func (UnimplementedMessageServiceServer) Delete(context.Context, *ChatIdRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedMessageServiceServer) DeleteMessages(context.Context, *DeleteMessagesRequest) (*DeletedMessages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMessages not implemented")
+}
 func (UnimplementedMessageServiceServer) GetAll(context.Context, *GetAllMessagesParams) (*GetAllMessages, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
 }
+func (UnimplementedMessageServiceServer) GetThread(context.Context, *GetThreadParams) (*GetAllMessages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThread not implemented")
+}
+func (UnimplementedMessageServiceServer) GetAllSince(context.Context, *GetMessagesSinceParams) (*GetAllMessages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllSince not implemented")
+}
+func (UnimplementedMessageServiceServer) MarkRead(context.Context, *MarkReadRequest) (*ReadCursor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkRead not implemented")
+}
+func (UnimplementedMessageServiceServer) GetReadBy(context.Context, *GetReadByParams) (*GetAllUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReadBy not implemented")
+}
+func (UnimplementedMessageServiceServer) AddReaction(context.Context, *ReactionRequest) (*MessageReactions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddReaction not implemented")
+}
+func (UnimplementedMessageServiceServer) RemoveReaction(context.Context, *ReactionRequest) (*MessageReactions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveReaction not implemented")
+}
+func (UnimplementedMessageServiceServer) Forward(context.Context, *ForwardRequest) (*GetAllMessages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Forward not implemented")
+}
+func (UnimplementedMessageServiceServer) GetRevisions(context.Context, *GetRevisionsParams) (*MessageRevisions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRevisions not implemented")
+}
+func (UnimplementedMessageServiceServer) Pin(context.Context, *PinRequest) (*PinResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pin not implemented")
+}
+func (UnimplementedMessageServiceServer) Unpin(context.Context, *PinRequest) (*ChatMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unpin not implemented")
+}
+func (UnimplementedMessageServiceServer) GetScheduled(context.Context, *GetScheduledMessagesParams) (*GetAllMessages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScheduled not implemented")
+}
+func (UnimplementedMessageServiceServer) UpdateScheduled(context.Context, *ChatMessage) (*ChatMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateScheduled not implemented")
+}
+func (UnimplementedMessageServiceServer) CancelScheduled(context.Context, *ChatIdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduled not implemented")
+}
+func (UnimplementedMessageServiceServer) CreateAttachment(context.Context, *Attachment) (*Attachment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAttachment not implemented")
+}
+func (UnimplementedMessageServiceServer) GetMedia(context.Context, *GetMediaRequest) (*Attachment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMedia not implemented")
+}
 func (UnimplementedMessageServiceServer) mustEmbedUnimplementedMessageServiceServer() {}
 
 // UnsafeMessageServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -167,6 +427,24 @@ func _MessageService_Delete_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessageService_DeleteMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).DeleteMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/DeleteMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).DeleteMessages(ctx, req.(*DeleteMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MessageService_GetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetAllMessagesParams)
 	if err := dec(in); err != nil {
@@ -185,6 +463,276 @@ func _MessageService_GetAll_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessageService_GetThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/GetThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetThread(ctx, req.(*GetThreadParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetAllSince_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMessagesSinceParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetAllSince(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/GetAllSince",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetAllSince(ctx, req.(*GetMessagesSinceParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_MarkRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).MarkRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/MarkRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).MarkRead(ctx, req.(*MarkReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetReadBy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReadByParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetReadBy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/GetReadBy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetReadBy(ctx, req.(*GetReadByParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_AddReaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).AddReaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/AddReaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).AddReaction(ctx, req.(*ReactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_RemoveReaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).RemoveReaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/RemoveReaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).RemoveReaction(ctx, req.(*ReactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_Forward_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).Forward(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/Forward",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).Forward(ctx, req.(*ForwardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRevisionsParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/GetRevisions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetRevisions(ctx, req.(*GetRevisionsParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_Pin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).Pin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/Pin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).Pin(ctx, req.(*PinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_Unpin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).Unpin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/Unpin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).Unpin(ctx, req.(*PinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetScheduledMessagesParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/GetScheduled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetScheduled(ctx, req.(*GetScheduledMessagesParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_UpdateScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).UpdateScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/UpdateScheduled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).UpdateScheduled(ctx, req.(*ChatMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_CancelScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).CancelScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/CancelScheduled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).CancelScheduled(ctx, req.(*ChatIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_CreateAttachment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Attachment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).CreateAttachment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/CreateAttachment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).CreateAttachment(ctx, req.(*Attachment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetMedia_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMediaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetMedia(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.MessageService/GetMedia",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetMedia(ctx, req.(*GetMediaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MessageService_ServiceDesc is the grpc.ServiceDesc for MessageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -204,10 +752,74 @@ var MessageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _MessageService_Delete_Handler,
 		},
+		{
+			MethodName: "DeleteMessages",
+			Handler:    _MessageService_DeleteMessages_Handler,
+		},
 		{
 			MethodName: "GetAll",
 			Handler:    _MessageService_GetAll_Handler,
 		},
+		{
+			MethodName: "GetThread",
+			Handler:    _MessageService_GetThread_Handler,
+		},
+		{
+			MethodName: "GetAllSince",
+			Handler:    _MessageService_GetAllSince_Handler,
+		},
+		{
+			MethodName: "MarkRead",
+			Handler:    _MessageService_MarkRead_Handler,
+		},
+		{
+			MethodName: "GetReadBy",
+			Handler:    _MessageService_GetReadBy_Handler,
+		},
+		{
+			MethodName: "AddReaction",
+			Handler:    _MessageService_AddReaction_Handler,
+		},
+		{
+			MethodName: "RemoveReaction",
+			Handler:    _MessageService_RemoveReaction_Handler,
+		},
+		{
+			MethodName: "Forward",
+			Handler:    _MessageService_Forward_Handler,
+		},
+		{
+			MethodName: "GetRevisions",
+			Handler:    _MessageService_GetRevisions_Handler,
+		},
+		{
+			MethodName: "Pin",
+			Handler:    _MessageService_Pin_Handler,
+		},
+		{
+			MethodName: "Unpin",
+			Handler:    _MessageService_Unpin_Handler,
+		},
+		{
+			MethodName: "GetScheduled",
+			Handler:    _MessageService_GetScheduled_Handler,
+		},
+		{
+			MethodName: "UpdateScheduled",
+			Handler:    _MessageService_UpdateScheduled_Handler,
+		},
+		{
+			MethodName: "CancelScheduled",
+			Handler:    _MessageService_CancelScheduled_Handler,
+		},
+		{
+			MethodName: "CreateAttachment",
+			Handler:    _MessageService_CreateAttachment_Handler,
+		},
+		{
+			MethodName: "GetMedia",
+			Handler:    _MessageService_GetMedia_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "chat_message_service.proto",
@@ -27,7 +27,7 @@ var file_user_service_proto_rawDesc = []byte{
 	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x67, 0x65, 0x6e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0a,
 	0x75, 0x73, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67,
 	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74,
-	0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0x9d, 0x03, 0x0a, 0x0b, 0x55, 0x73, 0x65, 0x72,
+	0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0xf0, 0x03, 0x0a, 0x0b, 0x55, 0x73, 0x65, 0x72,
 	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2a, 0x0a, 0x06, 0x43, 0x72, 0x65, 0x61, 0x74,
 	0x65, 0x12, 0x0e, 0x2e, 0x67, 0x65, 0x6e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x73, 0x65,
 	0x72, 0x1a, 0x0e, 0x2e, 0x67, 0x65, 0x6e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x73, 0x65,
@@ -53,19 +53,25 @@ var file_user_service_proto_rawDesc = []byte{
 	0x74, 0x42, 0x79, 0x45, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x1b, 0x2e, 0x67, 0x65, 0x6e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x79, 0x45, 0x6d, 0x61, 0x69, 0x6c, 0x52, 0x65,
 	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x67, 0x65, 0x6e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2e, 0x55, 0x73, 0x65, 0x72, 0x22, 0x00, 0x42, 0x17, 0x5a, 0x15, 0x67, 0x65, 0x6e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x68, 0x61, 0x74, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x2e, 0x55, 0x73, 0x65, 0x72, 0x22, 0x00, 0x12, 0x51, 0x0a, 0x15, 0x53, 0x65, 0x74, 0x4c, 0x61,
+	0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x56, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79,
+	0x12, 0x26, 0x2e, 0x67, 0x65, 0x6e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x65, 0x74, 0x4c,
+	0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x56, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x67, 0x65, 0x6e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x22, 0x00, 0x42, 0x17, 0x5a, 0x15, 0x67, 0x65,
+	0x6e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x68, 0x61, 0x74, 0x5f, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var file_user_service_proto_goTypes = []interface{}{
-	(*User)(nil),                // 0: genproto.User
-	(*GetUserRequest)(nil),      // 1: genproto.GetUserRequest
-	(*GetAllUsersRequest)(nil),  // 2: genproto.GetAllUsersRequest
-	(*SetUserImageRequest)(nil), // 3: genproto.SetUserImageRequest
-	(*GetByEmailRequest)(nil),   // 4: genproto.GetByEmailRequest
-	(*GetAllUsersResponse)(nil), // 5: genproto.GetAllUsersResponse
-	(*emptypb.Empty)(nil),       // 6: google.protobuf.Empty
+	(*User)(nil),                         // 0: genproto.User
+	(*GetUserRequest)(nil),               // 1: genproto.GetUserRequest
+	(*GetAllUsersRequest)(nil),           // 2: genproto.GetAllUsersRequest
+	(*SetUserImageRequest)(nil),          // 3: genproto.SetUserImageRequest
+	(*GetByEmailRequest)(nil),            // 4: genproto.GetByEmailRequest
+	(*SetLastSeenVisibilityRequest)(nil), // 5: genproto.SetLastSeenVisibilityRequest
+	(*GetAllUsersResponse)(nil),          // 6: genproto.GetAllUsersResponse
+	(*emptypb.Empty)(nil),                // 7: google.protobuf.Empty
 }
 var file_user_service_proto_depIdxs = []int32{
 	0, // 0: genproto.UserService.Create:input_type -> genproto.User
@@ -75,15 +81,17 @@ var file_user_service_proto_depIdxs = []int32{
 	1, // 4: genproto.UserService.Delete:input_type -> genproto.GetUserRequest
 	3, // 5: genproto.UserService.SetUserImage:input_type -> genproto.SetUserImageRequest
 	4, // 6: genproto.UserService.GetByEmail:input_type -> genproto.GetByEmailRequest
-	0, // 7: genproto.UserService.Create:output_type -> genproto.User
-	0, // 8: genproto.UserService.Get:output_type -> genproto.User
-	5, // 9: genproto.UserService.GetAll:output_type -> genproto.GetAllUsersResponse
-	0, // 10: genproto.UserService.Update:output_type -> genproto.User
-	6, // 11: genproto.UserService.Delete:output_type -> google.protobuf.Empty
-	0, // 12: genproto.UserService.SetUserImage:output_type -> genproto.User
-	0, // 13: genproto.UserService.GetByEmail:output_type -> genproto.User
-	7, // [7:14] is the sub-list for method output_type
-	0, // [0:7] is the sub-list for method input_type
+	5, // 7: genproto.UserService.SetLastSeenVisibility:input_type -> genproto.SetLastSeenVisibilityRequest
+	0, // 8: genproto.UserService.Create:output_type -> genproto.User
+	0, // 9: genproto.UserService.Get:output_type -> genproto.User
+	6, // 10: genproto.UserService.GetAll:output_type -> genproto.GetAllUsersResponse
+	0, // 11: genproto.UserService.Update:output_type -> genproto.User
+	7, // 12: genproto.UserService.Delete:output_type -> google.protobuf.Empty
+	0, // 13: genproto.UserService.SetUserImage:output_type -> genproto.User
+	0, // 14: genproto.UserService.GetByEmail:output_type -> genproto.User
+	0, // 15: genproto.UserService.SetLastSeenVisibility:output_type -> genproto.User
+	8, // [8:16] is the sub-list for method output_type
+	0, // [0:8] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -157,6 +157,46 @@ func (mr *MockChatServiceClientMockRecorder) GetChatMembers(ctx, in interface{},
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMembers", reflect.TypeOf((*MockChatServiceClient)(nil).GetChatMembers), varargs...)
 }
 
+// GetChatPeers mocks base method.
+func (m *MockChatServiceClient) GetChatPeers(ctx context.Context, in *chat_service.GetChatPeersRequest, opts ...grpc.CallOption) (*chat_service.ChatPeers, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetChatPeers", varargs...)
+	ret0, _ := ret[0].(*chat_service.ChatPeers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatPeers indicates an expected call of GetChatPeers.
+func (mr *MockChatServiceClientMockRecorder) GetChatPeers(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatPeers", reflect.TypeOf((*MockChatServiceClient)(nil).GetChatPeers), varargs...)
+}
+
+// GetPinnedMessages mocks base method.
+func (m *MockChatServiceClient) GetPinnedMessages(ctx context.Context, in *chat_service.GetPinnedMessagesParams, opts ...grpc.CallOption) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetPinnedMessages", varargs...)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPinnedMessages indicates an expected call of GetPinnedMessages.
+func (mr *MockChatServiceClientMockRecorder) GetPinnedMessages(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPinnedMessages", reflect.TypeOf((*MockChatServiceClient)(nil).GetPinnedMessages), varargs...)
+}
+
 // RemoveMember mocks base method.
 func (m *MockChatServiceClient) RemoveMember(ctx context.Context, in *chat_service.RemoveMemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -197,6 +237,26 @@ func (mr *MockChatServiceClientMockRecorder) Update(ctx, in interface{}, opts ..
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockChatServiceClient)(nil).Update), varargs...)
 }
 
+// UpdateSettings mocks base method.
+func (m *MockChatServiceClient) UpdateSettings(ctx context.Context, in *chat_service.UpdateChatSettingsRequest, opts ...grpc.CallOption) (*chat_service.Chat, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateSettings", varargs...)
+	ret0, _ := ret[0].(*chat_service.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockChatServiceClientMockRecorder) UpdateSettings(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockChatServiceClient)(nil).UpdateSettings), varargs...)
+}
+
 // MockChatServiceServer is a mock of ChatServiceServer interface.
 type MockChatServiceServer struct {
 	ctrl     *gomock.Controller
@@ -310,6 +370,36 @@ func (mr *MockChatServiceServerMockRecorder) GetChatMembers(arg0, arg1 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMembers", reflect.TypeOf((*MockChatServiceServer)(nil).GetChatMembers), arg0, arg1)
 }
 
+// GetChatPeers mocks base method.
+func (m *MockChatServiceServer) GetChatPeers(arg0 context.Context, arg1 *chat_service.GetChatPeersRequest) (*chat_service.ChatPeers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatPeers", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.ChatPeers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatPeers indicates an expected call of GetChatPeers.
+func (mr *MockChatServiceServerMockRecorder) GetChatPeers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatPeers", reflect.TypeOf((*MockChatServiceServer)(nil).GetChatPeers), arg0, arg1)
+}
+
+// GetPinnedMessages mocks base method.
+func (m *MockChatServiceServer) GetPinnedMessages(arg0 context.Context, arg1 *chat_service.GetPinnedMessagesParams) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPinnedMessages", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPinnedMessages indicates an expected call of GetPinnedMessages.
+func (mr *MockChatServiceServerMockRecorder) GetPinnedMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPinnedMessages", reflect.TypeOf((*MockChatServiceServer)(nil).GetPinnedMessages), arg0, arg1)
+}
+
 // RemoveMember mocks base method.
 func (m *MockChatServiceServer) RemoveMember(arg0 context.Context, arg1 *chat_service.RemoveMemberRequest) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -340,6 +430,21 @@ func (mr *MockChatServiceServerMockRecorder) Update(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockChatServiceServer)(nil).Update), arg0, arg1)
 }
 
+// UpdateSettings mocks base method.
+func (m *MockChatServiceServer) UpdateSettings(arg0 context.Context, arg1 *chat_service.UpdateChatSettingsRequest) (*chat_service.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockChatServiceServerMockRecorder) UpdateSettings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockChatServiceServer)(nil).UpdateSettings), arg0, arg1)
+}
+
 // mustEmbedUnimplementedChatServiceServer mocks base method.
 func (m *MockChatServiceServer) mustEmbedUnimplementedChatServiceServer() {
 	m.ctrl.T.Helper()
@@ -37,6 +37,46 @@ func (m *MockMessageServiceClient) EXPECT() *MockMessageServiceClientMockRecorde
 	return m.recorder
 }
 
+// AddReaction mocks base method.
+func (m *MockMessageServiceClient) AddReaction(ctx context.Context, in *chat_service.ReactionRequest, opts ...grpc.CallOption) (*chat_service.MessageReactions, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddReaction", varargs...)
+	ret0, _ := ret[0].(*chat_service.MessageReactions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockMessageServiceClientMockRecorder) AddReaction(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockMessageServiceClient)(nil).AddReaction), varargs...)
+}
+
+// CancelScheduled mocks base method.
+func (m *MockMessageServiceClient) CancelScheduled(ctx context.Context, in *chat_service.ChatIdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CancelScheduled", varargs...)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduled indicates an expected call of CancelScheduled.
+func (mr *MockMessageServiceClientMockRecorder) CancelScheduled(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduled", reflect.TypeOf((*MockMessageServiceClient)(nil).CancelScheduled), varargs...)
+}
+
 // Create mocks base method.
 func (m *MockMessageServiceClient) Create(ctx context.Context, in *chat_service.ChatMessage, opts ...grpc.CallOption) (*chat_service.ChatMessage, error) {
 	m.ctrl.T.Helper()
@@ -57,6 +97,26 @@ func (mr *MockMessageServiceClientMockRecorder) Create(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessageServiceClient)(nil).Create), varargs...)
 }
 
+// CreateAttachment mocks base method.
+func (m *MockMessageServiceClient) CreateAttachment(ctx context.Context, in *chat_service.Attachment, opts ...grpc.CallOption) (*chat_service.Attachment, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateAttachment", varargs...)
+	ret0, _ := ret[0].(*chat_service.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockMessageServiceClientMockRecorder) CreateAttachment(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockMessageServiceClient)(nil).CreateAttachment), varargs...)
+}
+
 // Delete mocks base method.
 func (m *MockMessageServiceClient) Delete(ctx context.Context, in *chat_service.ChatIdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -77,6 +137,46 @@ func (mr *MockMessageServiceClientMockRecorder) Delete(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMessageServiceClient)(nil).Delete), varargs...)
 }
 
+// DeleteMessages mocks base method.
+func (m *MockMessageServiceClient) DeleteMessages(ctx context.Context, in *chat_service.DeleteMessagesRequest, opts ...grpc.CallOption) (*chat_service.DeletedMessages, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMessages", varargs...)
+	ret0, _ := ret[0].(*chat_service.DeletedMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMessages indicates an expected call of DeleteMessages.
+func (mr *MockMessageServiceClientMockRecorder) DeleteMessages(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessages", reflect.TypeOf((*MockMessageServiceClient)(nil).DeleteMessages), varargs...)
+}
+
+// Forward mocks base method.
+func (m *MockMessageServiceClient) Forward(ctx context.Context, in *chat_service.ForwardRequest, opts ...grpc.CallOption) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Forward", varargs...)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Forward indicates an expected call of Forward.
+func (mr *MockMessageServiceClientMockRecorder) Forward(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forward", reflect.TypeOf((*MockMessageServiceClient)(nil).Forward), varargs...)
+}
+
 // GetAll mocks base method.
 func (m *MockMessageServiceClient) GetAll(ctx context.Context, in *chat_service.GetAllMessagesParams, opts ...grpc.CallOption) (*chat_service.GetAllMessages, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +197,206 @@ func (mr *MockMessageServiceClientMockRecorder) GetAll(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockMessageServiceClient)(nil).GetAll), varargs...)
 }
 
+// GetAllSince mocks base method.
+func (m *MockMessageServiceClient) GetAllSince(ctx context.Context, in *chat_service.GetMessagesSinceParams, opts ...grpc.CallOption) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAllSince", varargs...)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSince indicates an expected call of GetAllSince.
+func (mr *MockMessageServiceClientMockRecorder) GetAllSince(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSince", reflect.TypeOf((*MockMessageServiceClient)(nil).GetAllSince), varargs...)
+}
+
+// GetMedia mocks base method.
+func (m *MockMessageServiceClient) GetMedia(ctx context.Context, in *chat_service.GetMediaRequest, opts ...grpc.CallOption) (*chat_service.Attachment, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetMedia", varargs...)
+	ret0, _ := ret[0].(*chat_service.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMedia indicates an expected call of GetMedia.
+func (mr *MockMessageServiceClientMockRecorder) GetMedia(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedia", reflect.TypeOf((*MockMessageServiceClient)(nil).GetMedia), varargs...)
+}
+
+// GetReadBy mocks base method.
+func (m *MockMessageServiceClient) GetReadBy(ctx context.Context, in *chat_service.GetReadByParams, opts ...grpc.CallOption) (*chat_service.GetAllUsersResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetReadBy", varargs...)
+	ret0, _ := ret[0].(*chat_service.GetAllUsersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReadBy indicates an expected call of GetReadBy.
+func (mr *MockMessageServiceClientMockRecorder) GetReadBy(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReadBy", reflect.TypeOf((*MockMessageServiceClient)(nil).GetReadBy), varargs...)
+}
+
+// GetRevisions mocks base method.
+func (m *MockMessageServiceClient) GetRevisions(ctx context.Context, in *chat_service.GetRevisionsParams, opts ...grpc.CallOption) (*chat_service.MessageRevisions, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetRevisions", varargs...)
+	ret0, _ := ret[0].(*chat_service.MessageRevisions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockMessageServiceClientMockRecorder) GetRevisions(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockMessageServiceClient)(nil).GetRevisions), varargs...)
+}
+
+// GetScheduled mocks base method.
+func (m *MockMessageServiceClient) GetScheduled(ctx context.Context, in *chat_service.GetScheduledMessagesParams, opts ...grpc.CallOption) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetScheduled", varargs...)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduled indicates an expected call of GetScheduled.
+func (mr *MockMessageServiceClientMockRecorder) GetScheduled(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduled", reflect.TypeOf((*MockMessageServiceClient)(nil).GetScheduled), varargs...)
+}
+
+// GetThread mocks base method.
+func (m *MockMessageServiceClient) GetThread(ctx context.Context, in *chat_service.GetThreadParams, opts ...grpc.CallOption) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetThread", varargs...)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockMessageServiceClientMockRecorder) GetThread(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockMessageServiceClient)(nil).GetThread), varargs...)
+}
+
+// MarkRead mocks base method.
+func (m *MockMessageServiceClient) MarkRead(ctx context.Context, in *chat_service.MarkReadRequest, opts ...grpc.CallOption) (*chat_service.ReadCursor, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "MarkRead", varargs...)
+	ret0, _ := ret[0].(*chat_service.ReadCursor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockMessageServiceClientMockRecorder) MarkRead(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockMessageServiceClient)(nil).MarkRead), varargs...)
+}
+
+// Pin mocks base method.
+func (m *MockMessageServiceClient) Pin(ctx context.Context, in *chat_service.PinRequest, opts ...grpc.CallOption) (*chat_service.PinResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Pin", varargs...)
+	ret0, _ := ret[0].(*chat_service.PinResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pin indicates an expected call of Pin.
+func (mr *MockMessageServiceClientMockRecorder) Pin(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pin", reflect.TypeOf((*MockMessageServiceClient)(nil).Pin), varargs...)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockMessageServiceClient) RemoveReaction(ctx context.Context, in *chat_service.ReactionRequest, opts ...grpc.CallOption) (*chat_service.MessageReactions, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RemoveReaction", varargs...)
+	ret0, _ := ret[0].(*chat_service.MessageReactions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockMessageServiceClientMockRecorder) RemoveReaction(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockMessageServiceClient)(nil).RemoveReaction), varargs...)
+}
+
+// Unpin mocks base method.
+func (m *MockMessageServiceClient) Unpin(ctx context.Context, in *chat_service.PinRequest, opts ...grpc.CallOption) (*chat_service.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Unpin", varargs...)
+	ret0, _ := ret[0].(*chat_service.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unpin indicates an expected call of Unpin.
+func (mr *MockMessageServiceClientMockRecorder) Unpin(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unpin", reflect.TypeOf((*MockMessageServiceClient)(nil).Unpin), varargs...)
+}
+
 // Update mocks base method.
 func (m *MockMessageServiceClient) Update(ctx context.Context, in *chat_service.ChatMessage, opts ...grpc.CallOption) (*chat_service.ChatMessage, error) {
 	m.ctrl.T.Helper()
@@ -117,6 +417,26 @@ func (mr *MockMessageServiceClientMockRecorder) Update(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMessageServiceClient)(nil).Update), varargs...)
 }
 
+// UpdateScheduled mocks base method.
+func (m *MockMessageServiceClient) UpdateScheduled(ctx context.Context, in *chat_service.ChatMessage, opts ...grpc.CallOption) (*chat_service.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateScheduled", varargs...)
+	ret0, _ := ret[0].(*chat_service.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduled indicates an expected call of UpdateScheduled.
+func (mr *MockMessageServiceClientMockRecorder) UpdateScheduled(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduled", reflect.TypeOf((*MockMessageServiceClient)(nil).UpdateScheduled), varargs...)
+}
+
 // MockMessageServiceServer is a mock of MessageServiceServer interface.
 type MockMessageServiceServer struct {
 	ctrl     *gomock.Controller
@@ -140,6 +460,36 @@ func (m *MockMessageServiceServer) EXPECT() *MockMessageServiceServerMockRecorde
 	return m.recorder
 }
 
+// AddReaction mocks base method.
+func (m *MockMessageServiceServer) AddReaction(arg0 context.Context, arg1 *chat_service.ReactionRequest) (*chat_service.MessageReactions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.MessageReactions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockMessageServiceServerMockRecorder) AddReaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockMessageServiceServer)(nil).AddReaction), arg0, arg1)
+}
+
+// CancelScheduled mocks base method.
+func (m *MockMessageServiceServer) CancelScheduled(arg0 context.Context, arg1 *chat_service.ChatIdRequest) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduled", arg0, arg1)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduled indicates an expected call of CancelScheduled.
+func (mr *MockMessageServiceServerMockRecorder) CancelScheduled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduled", reflect.TypeOf((*MockMessageServiceServer)(nil).CancelScheduled), arg0, arg1)
+}
+
 // Create mocks base method.
 func (m *MockMessageServiceServer) Create(arg0 context.Context, arg1 *chat_service.ChatMessage) (*chat_service.ChatMessage, error) {
 	m.ctrl.T.Helper()
@@ -155,6 +505,21 @@ func (mr *MockMessageServiceServerMockRecorder) Create(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessageServiceServer)(nil).Create), arg0, arg1)
 }
 
+// CreateAttachment mocks base method.
+func (m *MockMessageServiceServer) CreateAttachment(arg0 context.Context, arg1 *chat_service.Attachment) (*chat_service.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttachment", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockMessageServiceServerMockRecorder) CreateAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockMessageServiceServer)(nil).CreateAttachment), arg0, arg1)
+}
+
 // Delete mocks base method.
 func (m *MockMessageServiceServer) Delete(arg0 context.Context, arg1 *chat_service.ChatIdRequest) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -170,6 +535,36 @@ func (mr *MockMessageServiceServerMockRecorder) Delete(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMessageServiceServer)(nil).Delete), arg0, arg1)
 }
 
+// DeleteMessages mocks base method.
+func (m *MockMessageServiceServer) DeleteMessages(arg0 context.Context, arg1 *chat_service.DeleteMessagesRequest) (*chat_service.DeletedMessages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessages", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.DeletedMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMessages indicates an expected call of DeleteMessages.
+func (mr *MockMessageServiceServerMockRecorder) DeleteMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessages", reflect.TypeOf((*MockMessageServiceServer)(nil).DeleteMessages), arg0, arg1)
+}
+
+// Forward mocks base method.
+func (m *MockMessageServiceServer) Forward(arg0 context.Context, arg1 *chat_service.ForwardRequest) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forward", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Forward indicates an expected call of Forward.
+func (mr *MockMessageServiceServerMockRecorder) Forward(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forward", reflect.TypeOf((*MockMessageServiceServer)(nil).Forward), arg0, arg1)
+}
+
 // GetAll mocks base method.
 func (m *MockMessageServiceServer) GetAll(arg0 context.Context, arg1 *chat_service.GetAllMessagesParams) (*chat_service.GetAllMessages, error) {
 	m.ctrl.T.Helper()
@@ -185,6 +580,156 @@ func (mr *MockMessageServiceServerMockRecorder) GetAll(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockMessageServiceServer)(nil).GetAll), arg0, arg1)
 }
 
+// GetAllSince mocks base method.
+func (m *MockMessageServiceServer) GetAllSince(arg0 context.Context, arg1 *chat_service.GetMessagesSinceParams) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllSince", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSince indicates an expected call of GetAllSince.
+func (mr *MockMessageServiceServerMockRecorder) GetAllSince(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSince", reflect.TypeOf((*MockMessageServiceServer)(nil).GetAllSince), arg0, arg1)
+}
+
+// GetMedia mocks base method.
+func (m *MockMessageServiceServer) GetMedia(arg0 context.Context, arg1 *chat_service.GetMediaRequest) (*chat_service.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMedia", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMedia indicates an expected call of GetMedia.
+func (mr *MockMessageServiceServerMockRecorder) GetMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedia", reflect.TypeOf((*MockMessageServiceServer)(nil).GetMedia), arg0, arg1)
+}
+
+// GetReadBy mocks base method.
+func (m *MockMessageServiceServer) GetReadBy(arg0 context.Context, arg1 *chat_service.GetReadByParams) (*chat_service.GetAllUsersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReadBy", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.GetAllUsersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReadBy indicates an expected call of GetReadBy.
+func (mr *MockMessageServiceServerMockRecorder) GetReadBy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReadBy", reflect.TypeOf((*MockMessageServiceServer)(nil).GetReadBy), arg0, arg1)
+}
+
+// GetRevisions mocks base method.
+func (m *MockMessageServiceServer) GetRevisions(arg0 context.Context, arg1 *chat_service.GetRevisionsParams) (*chat_service.MessageRevisions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.MessageRevisions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockMessageServiceServerMockRecorder) GetRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockMessageServiceServer)(nil).GetRevisions), arg0, arg1)
+}
+
+// GetScheduled mocks base method.
+func (m *MockMessageServiceServer) GetScheduled(arg0 context.Context, arg1 *chat_service.GetScheduledMessagesParams) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduled", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduled indicates an expected call of GetScheduled.
+func (mr *MockMessageServiceServerMockRecorder) GetScheduled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduled", reflect.TypeOf((*MockMessageServiceServer)(nil).GetScheduled), arg0, arg1)
+}
+
+// GetThread mocks base method.
+func (m *MockMessageServiceServer) GetThread(arg0 context.Context, arg1 *chat_service.GetThreadParams) (*chat_service.GetAllMessages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThread", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.GetAllMessages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockMessageServiceServerMockRecorder) GetThread(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockMessageServiceServer)(nil).GetThread), arg0, arg1)
+}
+
+// MarkRead mocks base method.
+func (m *MockMessageServiceServer) MarkRead(arg0 context.Context, arg1 *chat_service.MarkReadRequest) (*chat_service.ReadCursor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.ReadCursor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockMessageServiceServerMockRecorder) MarkRead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockMessageServiceServer)(nil).MarkRead), arg0, arg1)
+}
+
+// Pin mocks base method.
+func (m *MockMessageServiceServer) Pin(arg0 context.Context, arg1 *chat_service.PinRequest) (*chat_service.PinResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pin", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.PinResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pin indicates an expected call of Pin.
+func (mr *MockMessageServiceServerMockRecorder) Pin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pin", reflect.TypeOf((*MockMessageServiceServer)(nil).Pin), arg0, arg1)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockMessageServiceServer) RemoveReaction(arg0 context.Context, arg1 *chat_service.ReactionRequest) (*chat_service.MessageReactions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.MessageReactions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockMessageServiceServerMockRecorder) RemoveReaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockMessageServiceServer)(nil).RemoveReaction), arg0, arg1)
+}
+
+// Unpin mocks base method.
+func (m *MockMessageServiceServer) Unpin(arg0 context.Context, arg1 *chat_service.PinRequest) (*chat_service.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unpin", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unpin indicates an expected call of Unpin.
+func (mr *MockMessageServiceServerMockRecorder) Unpin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unpin", reflect.TypeOf((*MockMessageServiceServer)(nil).Unpin), arg0, arg1)
+}
+
 // Update mocks base method.
 func (m *MockMessageServiceServer) Update(arg0 context.Context, arg1 *chat_service.ChatMessage) (*chat_service.ChatMessage, error) {
 	m.ctrl.T.Helper()
@@ -200,6 +745,21 @@ func (mr *MockMessageServiceServerMockRecorder) Update(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMessageServiceServer)(nil).Update), arg0, arg1)
 }
 
+// UpdateScheduled mocks base method.
+func (m *MockMessageServiceServer) UpdateScheduled(arg0 context.Context, arg1 *chat_service.ChatMessage) (*chat_service.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduled", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduled indicates an expected call of UpdateScheduled.
+func (mr *MockMessageServiceServerMockRecorder) UpdateScheduled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduled", reflect.TypeOf((*MockMessageServiceServer)(nil).UpdateScheduled), arg0, arg1)
+}
+
 // mustEmbedUnimplementedMessageServiceServer mocks base method.
 func (m *MockMessageServiceServer) mustEmbedUnimplementedMessageServiceServer() {
 	m.ctrl.T.Helper()
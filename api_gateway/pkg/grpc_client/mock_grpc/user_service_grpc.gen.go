@@ -137,6 +137,26 @@ func (mr *MockUserServiceClientMockRecorder) GetByEmail(ctx, in interface{}, opt
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserServiceClient)(nil).GetByEmail), varargs...)
 }
 
+// SetLastSeenVisibility mocks base method.
+func (m *MockUserServiceClient) SetLastSeenVisibility(ctx context.Context, in *chat_service.SetLastSeenVisibilityRequest, opts ...grpc.CallOption) (*chat_service.User, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SetLastSeenVisibility", varargs...)
+	ret0, _ := ret[0].(*chat_service.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetLastSeenVisibility indicates an expected call of SetLastSeenVisibility.
+func (mr *MockUserServiceClientMockRecorder) SetLastSeenVisibility(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLastSeenVisibility", reflect.TypeOf((*MockUserServiceClient)(nil).SetLastSeenVisibility), varargs...)
+}
+
 // SetUserImage mocks base method.
 func (m *MockUserServiceClient) SetUserImage(ctx context.Context, in *chat_service.SetUserImageRequest, opts ...grpc.CallOption) (*chat_service.User, error) {
 	m.ctrl.T.Helper()
@@ -275,6 +295,21 @@ func (mr *MockUserServiceServerMockRecorder) GetByEmail(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserServiceServer)(nil).GetByEmail), arg0, arg1)
 }
 
+// SetLastSeenVisibility mocks base method.
+func (m *MockUserServiceServer) SetLastSeenVisibility(arg0 context.Context, arg1 *chat_service.SetLastSeenVisibilityRequest) (*chat_service.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLastSeenVisibility", arg0, arg1)
+	ret0, _ := ret[0].(*chat_service.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetLastSeenVisibility indicates an expected call of SetLastSeenVisibility.
+func (mr *MockUserServiceServerMockRecorder) SetLastSeenVisibility(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLastSeenVisibility", reflect.TypeOf((*MockUserServiceServer)(nil).SetLastSeenVisibility), arg0, arg1)
+}
+
 // SetUserImage mocks base method.
 func (m *MockUserServiceServer) SetUserImage(arg0 context.Context, arg1 *chat_service.SetUserImageRequest) (*chat_service.User, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,71 @@
+package imaging
+
+import "encoding/binary"
+
+// gifPixels adds up the pixels of the frames of a gif by walking its blocks,
+// without decoding them. Each frame is decoded in memory of its own, so a
+// small file with many frames takes as much as a huge image.
+func gifPixels(data []byte) (int, error) {
+	// Header and logical screen descriptor
+	if len(data) < 13 {
+		return 0, ErrNotImage
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	pixels := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // Extension: label and sub-blocks
+			var err error
+			if i, err = skipSubBlocks(data, i+2); err != nil {
+				return 0, err
+			}
+		case 0x2C: // Image descriptor, color table and compressed pixels
+			if i+10 > len(data) {
+				return 0, ErrNotImage
+			}
+			width := int(binary.LittleEndian.Uint16(data[i+5:]))
+			height := int(binary.LittleEndian.Uint16(data[i+7:]))
+			pixels += width * height
+			if pixels > MaxPixels {
+				return 0, ErrTooManyPixels
+			}
+
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			var err error
+			// LZW minimum code size
+			if i, err = skipSubBlocks(data, i+1); err != nil {
+				return 0, err
+			}
+		case 0x3B: // Trailer
+			return pixels, nil
+		default:
+			return 0, ErrNotImage
+		}
+	}
+
+	return pixels, nil
+}
+
+// skipSubBlocks returns the offset after the sub-blocks starting at i, each
+// is its size and the data, an empty one ends them.
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, ErrNotImage
+		}
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, nil
+		}
+		i += size
+	}
+}
@@ -0,0 +1,239 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Larger images take too much memory to decode, 100 MB at 4 bytes a pixel.
+const MaxPixels = 25_000_000
+
+var (
+	ErrNotImage      = errors.New("only jpeg, png and gif images are accepted")
+	ErrTooManyPixels = fmt.Errorf("images up to %d megapixels are accepted", MaxPixels/1_000_000)
+)
+
+// Image is an uploaded image made ready to be stored: turned upright, its
+// metadata (EXIF with the location of a photo, comments, ...) dropped by
+// encoding it again, and with its thumbnails.
+type Image struct {
+	// image/jpeg, image/png or image/gif, the format it was uploaded in
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+	Data     []byte
+
+	Thumbnails []*Thumbnail
+}
+
+// Thumbnail is the image scaled down to fit a square of Size pixels, in the
+// format of the image. An image smaller than that is kept as it is, so every
+// size is there. Thumbnails of animated gifs are of their first frame.
+type Thumbnail struct {
+	Size   int
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Process reads a jpeg, png or gif image, recognized by its content rather
+// than its name, and makes a thumbnail of each size.
+func Process(data []byte, sizes []int) (*Image, error) {
+	format := http.DetectContentType(data)
+	switch format {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrNotImage
+	}
+
+	// The header tells the size before the pixels are decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	if format == "image/gif" {
+		return processGIF(data, sizes)
+	}
+
+	var decoded image.Image
+	switch format {
+	case "image/jpeg":
+		decoded, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		decoded, err = png.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	img := toRGBA(decoded)
+	if format == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	result := &Image{
+		MimeType: "image/png",
+		Ext:      ".png",
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+	}
+	if format == "image/jpeg" {
+		result.MimeType, result.Ext = "image/jpeg", ".jpg"
+	}
+
+	if result.Data, err = encode(img, result.MimeType); err != nil {
+		return nil, err
+	}
+
+	if err := addThumbnails(result, img, sizes); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// processGIF keeps every frame of a gif, with their delays, encoding them
+// again drops its comments and application data but the loop count. The
+// frames count together against MaxPixels.
+func processGIF(data []byte, sizes []int) (*Image, error) {
+	if _, err := gifPixels(data); err != nil {
+		return nil, err
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	result := &Image{
+		MimeType: "image/gif",
+		Ext:      ".gif",
+		Width:    g.Config.Width,
+		Height:   g.Config.Height,
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+	result.Data = buf.Bytes()
+
+	// A frame may cover a part of the image only
+	first := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(first, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Src)
+
+	if err := addThumbnails(result, first, sizes); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// addThumbnails scales the image down to each size, in the format of the
+// result.
+func addThumbnails(result *Image, img *image.RGBA, sizes []int) error {
+	for _, size := range sizes {
+		scaled := Fit(img, size)
+		data, err := encode(scaled, result.MimeType)
+		if err != nil {
+			return err
+		}
+		result.Thumbnails = append(result.Thumbnails, &Thumbnail{
+			Size:   size,
+			Width:  scaled.Bounds().Dx(),
+			Height: scaled.Bounds().Dy(),
+			Data:   data,
+		})
+	}
+
+	return nil
+}
+
+func encode(img image.Image, mimeType string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var err error
+	switch mimeType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	case "image/gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+
+	return buf.Bytes(), err
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+
+	return rgba
+}
+
+// Fit scales the image down to fit a square of size pixels, keeping its
+// aspect ratio. Every pixel of the result is the average of the pixels it
+// covers, which keeps thin lines and text readable.
+func Fit(src *image.RGBA, size int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= size && sh <= size {
+		return src
+	}
+
+	dw, dh := size, sh*size/sw
+	if sh > sw {
+		dw, dh = sw*size/sh, size
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, (y+1)*sh/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, (x+1)*sw/dw
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					a += int(row[i+3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,184 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/api_gateway/pkg/imaging"
+)
+
+// halves returns an image red on its left half and blue on its right one.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// withOrientation adds an EXIF segment with the orientation to a jpeg.
+func withOrientation(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	// Orientation, SHORT, one value
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+
+	return out.Bytes()
+}
+
+func TestProcessPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, halves(400, 200)))
+
+	img, err := imaging.Process(buf.Bytes(), []int{100, 1000})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", img.MimeType)
+	assert.Equal(t, ".png", img.Ext)
+	assert.Equal(t, 400, img.Width)
+	assert.Equal(t, 200, img.Height)
+
+	require.Len(t, img.Thumbnails, 2)
+	assert.Equal(t, 100, img.Thumbnails[0].Width)
+	assert.Equal(t, 50, img.Thumbnails[0].Height)
+
+	thumbnail, err := png.Decode(bytes.NewReader(img.Thumbnails[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), thumbnail.Bounds())
+	r, _, b, _ := thumbnail.At(10, 25).RGBA()
+	assert.Greater(t, r, b)
+
+	// Smaller images aren't scaled up
+	assert.Equal(t, 1000, img.Thumbnails[1].Size)
+	assert.Equal(t, 400, img.Thumbnails[1].Width)
+}
+
+func TestProcessTurnsJPEGUpright(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, halves(40, 20), nil))
+	// Stored turned left, the viewer turns it right
+	data := withOrientation(buf.Bytes(), 6)
+
+	img, err := imaging.Process(data, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", img.MimeType)
+	assert.Equal(t, 20, img.Width)
+	assert.Equal(t, 40, img.Height)
+	assert.NotContains(t, string(img.Data), "Exif")
+
+	upright, err := jpeg.Decode(bytes.NewReader(img.Data))
+	require.NoError(t, err)
+	// The left half is on top now
+	r, _, b, _ := upright.At(10, 5).RGBA()
+	assert.Greater(t, r, b)
+	r, _, b, _ = upright.At(10, 35).RGBA()
+	assert.Greater(t, b, r)
+}
+
+func TestProcessGIF(t *testing.T) {
+	frame := func(c color.Color) *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 30, 30), color.Palette{color.Black, c})
+		for i := range img.Pix {
+			img.Pix[i] = 1
+		}
+		return img
+	}
+	animation := &gif.GIF{
+		Image: []*image.Paletted{frame(color.RGBA{R: 255, A: 255}), frame(color.RGBA{B: 255, A: 255})},
+		Delay: []int{10, 20},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, animation))
+	// A comment extension, it isn't kept
+	data := append([]byte{}, buf.Bytes()[:len(buf.Bytes())-1]...)
+	data = append(data, 0x21, 0xFE, 6, 's', 'e', 'c', 'r', 'e', 't', 0, 0x3B)
+
+	img, err := imaging.Process(data, []int{10})
+	require.NoError(t, err)
+	assert.Equal(t, "image/gif", img.MimeType)
+	assert.Equal(t, ".gif", img.Ext)
+	assert.Equal(t, 30, img.Width)
+	assert.NotContains(t, string(img.Data), "secret")
+
+	// Still animated
+	stored, err := gif.DecodeAll(bytes.NewReader(img.Data))
+	require.NoError(t, err)
+	require.Len(t, stored.Image, 2)
+	assert.Equal(t, []int{10, 20}, stored.Delay)
+
+	// The thumbnail is of the first frame
+	assert.Equal(t, 10, img.Thumbnails[0].Width)
+	thumbnail, err := gif.Decode(bytes.NewReader(img.Thumbnails[0].Data))
+	require.NoError(t, err)
+	r, _, b, _ := thumbnail.At(5, 5).RGBA()
+	assert.Greater(t, r, b)
+}
+
+func TestProcessRejectsLongAnimations(t *testing.T) {
+	// Small as a file, but every frame is decoded on its own
+	frame := image.NewPaletted(image.Rect(0, 0, 100, 100), color.Palette{color.Black, color.White})
+	animation := &gif.GIF{}
+	for i := 0; i <= imaging.MaxPixels/(100*100); i++ {
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 1)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, animation))
+	require.Less(t, buf.Len(), 1<<20)
+
+	_, err := imaging.Process(buf.Bytes(), nil)
+	assert.ErrorIs(t, err, imaging.ErrTooManyPixels)
+
+	// One frame fewer fits
+	animation.Image = animation.Image[1:]
+	animation.Delay = animation.Delay[1:]
+	buf.Reset()
+	require.NoError(t, gif.EncodeAll(&buf, animation))
+	_, err = imaging.Process(buf.Bytes(), nil)
+	assert.NoError(t, err)
+}
+
+func TestProcessRejects(t *testing.T) {
+	_, err := imaging.Process([]byte("%PDF-1.4 not an image"), nil)
+	assert.ErrorIs(t, err, imaging.ErrNotImage)
+
+	// Looks like a png, isn't one
+	_, err = imaging.Process([]byte("\x89PNG\r\n\x1a\n broken"), nil)
+	assert.ErrorIs(t, err, imaging.ErrNotImage)
+
+	// A small file can claim a huge image
+	var buf bytes.Buffer
+	require.NoError(t, gif.Encode(&buf, halves(2, 2), nil))
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint16(data[6:], 6000)
+	binary.LittleEndian.PutUint16(data[8:], 6000)
+	_, err = imaging.Process(data, nil)
+	assert.ErrorIs(t, err, imaging.ErrTooManyPixels)
+}
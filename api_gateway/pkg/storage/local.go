@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type local struct {
+	dir string
+
+	// Replace compares and moves the file under mu, the replace is atomic
+	// for the requests of this process only.
+	mu sync.Mutex
+}
+
+// NewLocal returns a storage keeping the objects as files under dir. It is
+// meant for a single replica, a directory shared by the replicas also works
+// except for Replace racing between them.
+func NewLocal(dir string) Storage {
+	return &local{
+		dir: dir,
+	}
+}
+
+func (l *local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(key))
+}
+
+func (l *local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return l.write(key, r, os.Rename)
+}
+
+func (l *local) Create(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	err := l.write(key, r, os.Link)
+	if os.IsExist(err) {
+		return ErrExists
+	}
+
+	return err
+}
+
+func (l *local) Replace(ctx context.Context, key string, r io.Reader, size int64, contentType, etag string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stat, err := os.Stat(l.path(key))
+	if os.IsNotExist(err) {
+		return ErrChanged
+	}
+	if err != nil {
+		return err
+	}
+	if fileETag(stat) != etag {
+		return ErrChanged
+	}
+
+	// The etag is made of the size and the modification time, which the
+	// file system may keep too coarse to tell quick writes apart
+	return l.write(key, r, func(oldpath, newpath string) error {
+		modTime := time.Now()
+		if !modTime.After(stat.ModTime()) {
+			modTime = stat.ModTime().Add(time.Nanosecond)
+		}
+		if err := os.Chtimes(oldpath, modTime, modTime); err != nil {
+			return err
+		}
+		return os.Rename(oldpath, newpath)
+	})
+}
+
+// write stores the content in a file next to the object and moves it in
+// place with move, a reader never sees half of it.
+func (l *local) write(key string, r io.Reader, move func(oldpath, newpath string) error) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return move(f.Name(), path)
+}
+
+func (l *local) Open(ctx context.Context, key string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	if !validKey(key) {
+		return nil, nil, ErrNotFound
+	}
+
+	f, err := os.Open(l.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if stat.IsDir() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+
+	return f, &ObjectInfo{
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+		ETag:        fileETag(stat),
+	}, nil
+}
+
+func fileETag(stat fs.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, stat.ModTime().UnixNano(), stat.Size())
+}
+
+func (l *local) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Files being written by Put
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return keys, err
+}
+
+func (l *local) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	// Not in the middle of a Replace, which would bring the file back
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := os.Remove(l.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	s := NewLocal(t.TempDir())
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "photos/a.jpg", strings.NewReader("first"), 5, "image/jpeg"))
+	require.NoError(t, s.Put(ctx, "photos/a.jpg", strings.NewReader("second"), 6, "image/jpeg"))
+
+	r, info, err := s.Open(ctx, "photos/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), info.Size)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+	assert.NotEmpty(t, info.ETag)
+
+	_, err = r.Seek(3, io.SeekStart)
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "ond", string(content))
+
+	// Only created when missing
+	assert.ErrorIs(t, s.Create(ctx, "photos/a.jpg", strings.NewReader("third"), 5, "image/jpeg"), ErrExists)
+
+	// Only replaced while unchanged
+	require.NoError(t, s.Replace(ctx, "photos/a.jpg", strings.NewReader("fourth"), 6, "image/jpeg", info.ETag))
+	assert.ErrorIs(t, s.Replace(ctx, "photos/a.jpg", strings.NewReader("fifth"), 5, "image/jpeg", info.ETag), ErrChanged)
+	assert.ErrorIs(t, s.Replace(ctx, "photos/z.jpg", strings.NewReader("fifth"), 5, "image/jpeg", info.ETag), ErrChanged)
+
+	require.NoError(t, s.Delete(ctx, "photos/a.jpg"))
+	require.NoError(t, s.Delete(ctx, "photos/a.jpg"))
+	require.NoError(t, s.Create(ctx, "photos/a.jpg", strings.NewReader("third"), 5, "image/jpeg"))
+	require.NoError(t, s.Delete(ctx, "photos/a.jpg"))
+	_, _, err = s.Open(ctx, "photos/a.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Directories aren't objects
+	require.NoError(t, s.Put(ctx, "photos/b.jpg", strings.NewReader("x"), 1, "image/jpeg"))
+	_, _, err = s.Open(ctx, "photos")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Put(ctx, "photos/c.jpg", strings.NewReader("x"), 1, "image/jpeg"))
+	require.NoError(t, s.Put(ctx, "videos/a.mp4", strings.NewReader("x"), 1, "video/mp4"))
+	keys, err := s.List(ctx, "photos/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"photos/b.jpg", "photos/c.jpg"}, keys)
+}
+
+func TestLocalKeysStayInside(t *testing.T) {
+	s := NewLocal(t.TempDir())
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", `a\b`} {
+		err := s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain")
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+
+		_, _, err = s.Open(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound, key)
+	}
+}
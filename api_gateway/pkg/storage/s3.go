@@ -0,0 +1,458 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Payload hash of requests whose body isn't hashed, uploads are streamed
+// rather than read twice.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+type S3Options struct {
+	// Address of the service, e.g. https://s3.eu-central-1.amazonaws.com or
+	// http://localhost:9000 for MinIO
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Client sends the requests, http.DefaultClient when it is nil
+	Client *http.Client
+}
+
+type s3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 returns a storage keeping the objects in a bucket of an S3
+// compatible service. Requests are path style, which every implementation
+// supports, and signed with AWS signature version 4.
+func NewS3(opts *S3Options) (Storage, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(opts.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %v", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q must be an absolute url", opts.Endpoint)
+	}
+	if opts.Bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+
+	s := &s3{
+		endpoint:  endpoint,
+		region:    opts.Region,
+		bucket:    opts.Bucket,
+		accessKey: opts.AccessKey,
+		secretKey: opts.SecretKey,
+		client:    opts.Client,
+		now:       time.Now,
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+
+	return s, nil
+}
+
+func (s *s3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.bucket + "/" + key
+	return &u
+}
+
+func (s *s3) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	return s.send(ctx, method, s.objectURL(key), body, size, header)
+}
+
+func (s *s3) send(ctx context.Context, method string, u *url.URL, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	s.sign(req, unsignedPayload)
+
+	return s.client.Do(req)
+}
+
+// responseError reads the error the service answered with.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s: %s %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, body)
+}
+
+func (s *s3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return s.put(ctx, key, r, size, contentType, make(http.Header), nil)
+}
+
+// Create relies on conditional writes, which S3 and MinIO support.
+func (s *s3) Create(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	header := make(http.Header)
+	header.Set("If-None-Match", "*")
+
+	return s.put(ctx, key, r, size, contentType, header, ErrExists)
+}
+
+func (s *s3) Replace(ctx context.Context, key string, r io.Reader, size int64, contentType, etag string) error {
+	header := make(http.Header)
+	header.Set("If-Match", etag)
+
+	return s.put(ctx, key, r, size, contentType, header, ErrChanged)
+}
+
+// put fails with failed when the condition of a conditional write doesn't
+// hold.
+func (s *s3) put(ctx context.Context, key string, r io.Reader, size int64, contentType string, header http.Header, failed error) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	// Conflict when another conditional write of the key is in progress
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		if failed != nil {
+			return failed
+		}
+	// If-Match on a missing object
+	case http.StatusNotFound:
+		if header.Get("If-Match") != "" {
+			return failed
+		}
+	}
+
+	return responseError(resp)
+}
+
+func (s *s3) Open(ctx context.Context, key string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	if !validKey(key) {
+		return nil, nil, ErrNotFound
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil, ErrNotFound
+	default:
+		return nil, nil, responseError(resp)
+	}
+
+	info := &ObjectInfo{
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+	}
+	info.ModTime, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &s3Object{
+		s:    s,
+		ctx:  ctx,
+		key:  key,
+		size: info.Size,
+	}, info, nil
+}
+
+// s3Object reads an object from the offset it is at with a ranged GET, which
+// is sent on the first read after a seek.
+type s3Object struct {
+	s      *s3
+	ctx    context.Context
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		header := make(http.Header)
+		header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+
+		resp, err := o.s.do(o.ctx, http.MethodGet, o.key, nil, 0, header)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusNotFound {
+				return 0, ErrNotFound
+			}
+			return 0, responseError(resp)
+		}
+		o.body = resp.Body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.New("s3: negative position")
+	}
+
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = offset
+
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+
+	err := o.body.Close()
+	o.body = nil
+
+	return err
+}
+
+func (s *s3) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// listResult is the answer to a ListObjectsV2 request, a page of up to 1000
+// keys.
+type listResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s *s3) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u := *s.endpoint
+		u.Path = u.Path + "/" + s.bucket
+		// Encoded the way it is signed, url.Values encodes spaces as +
+		u.RawQuery = query.Encode()
+		u.RawQuery = canonicalQuery(&u)
+
+		resp, err := s.send(ctx, http.MethodGet, &u, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, responseError(resp)
+		}
+
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3 list %s: %v", prefix, err)
+		}
+
+		for _, c := range result.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// sign adds the AWS signature version 4 of the request to it. The host,
+// range and x-amz-* headers are signed.
+func (s *s3) sign(req *http.Request, payloadHash string) {
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if k == "range" || strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalURI is the path with every segment escaped once, as S3 expects.
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			unescaped = segment
+		}
+		segments[i] = awsEscape(unescaped)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters of
+// RFC 3986.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
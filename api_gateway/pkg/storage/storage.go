@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gitlab.com/telegram_clone/api_gateway/config"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+	ErrExists     = errors.New("object already exists")
+	ErrChanged    = errors.New("object changed")
+)
+
+// Storage keeps the uploaded files where every gateway replica can reach
+// them. Objects are named by keys, relative slash separated paths, and the
+// database keeps the keys rather than the addresses of the files. Clients
+// download the files through the gateway.
+type Storage interface {
+	// Put stores the content under the key, an object already stored under
+	// it is replaced.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Create stores the content under the key unless an object is stored
+	// under it already, ErrExists then. Of the replicas creating the same
+	// key at once only one succeeds.
+	Create(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Replace stores the content under the key while the object stored
+	// under it has the etag, ErrChanged when it changed or is gone. Of the
+	// replicas replacing the same object at once only one succeeds.
+	Replace(ctx context.Context, key string, r io.Reader, size int64, contentType, etag string) error
+	// Open returns the content of the object, the caller closes it. Seeking
+	// reads a part of it without fetching the rest.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, *ObjectInfo, error)
+	// Delete removes the object, removing a missing object isn't an error.
+	Delete(ctx context.Context, key string) error
+	// List returns the keys of the objects whose key starts with the
+	// prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+type ObjectInfo struct {
+	Size    int64
+	ModTime time.Time
+	// Empty when the storage doesn't know it
+	ContentType string
+	// Changes whenever the content does, quoted
+	ETag string
+}
+
+// New returns the storage driver chosen by MEDIA_STORAGE.
+func New(cfg config.Config) (Storage, error) {
+	switch cfg.MediaStorage {
+	case "local":
+		return NewLocal(cfg.MediaLocalDir), nil
+	case "s3":
+		return NewS3(&S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	}
+
+	return nil, fmt.Errorf("unknown media storage %q", cfg.MediaStorage)
+}
+
+// validKey tells whether the key stays inside the storage it is used with.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// Algorithms are the checksum algorithms accepted, as the Tus-Checksum-Algorithm
+// header lists them.
+const Algorithms = "sha1,sha256,md5"
+
+var (
+	ErrChecksumMismatch = errors.New("checksum doesn't match the content")
+	ErrInvalidChecksum  = errors.New("checksum must be an algorithm and a base64 digest, e.g. sha256 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+	ErrUnknownAlgorithm = fmt.Errorf("checksum algorithm must be one of %s", Algorithms)
+)
+
+// Checksum is the digest the client computed of a chunk or of the whole
+// file, given as "<algorithm> <base64 digest>".
+type Checksum struct {
+	Algorithm string
+	Sum       []byte
+}
+
+func ParseChecksum(header string) (*Checksum, error) {
+	algorithm, digest, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, ErrInvalidChecksum
+	}
+
+	sum, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return nil, ErrInvalidChecksum
+	}
+
+	c := &Checksum{Algorithm: strings.ToLower(algorithm), Sum: sum}
+	h := c.hash()
+	if h == nil {
+		return nil, ErrUnknownAlgorithm
+	}
+	if len(sum) != h.Size() {
+		return nil, ErrInvalidChecksum
+	}
+
+	return c, nil
+}
+
+func (c *Checksum) hash() hash.Hash {
+	switch c.Algorithm {
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "md5":
+		return md5.New()
+	}
+	return nil
+}
+
+// verify returns a reader hashing what it reads, it fails with
+// ErrChecksumMismatch instead of ending when the digest is wrong.
+func (c *Checksum) verify(r io.Reader) io.Reader {
+	return &verifier{r: r, h: c.hash(), sum: c.Sum}
+}
+
+type verifier struct {
+	r   io.Reader
+	h   hash.Hash
+	sum []byte
+}
+
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.h.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(v.h.Sum(nil), v.sum) {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
@@ -0,0 +1,542 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+)
+
+// Uploads are kept in the media storage under this prefix, so any replica
+// can take the next chunk of an upload.
+const prefix = "uploads/"
+
+// A request writing an upload holds its lock and renews it every lockTTL/3,
+// a lock not renewed for lockTTL is of a replica which stopped holding it.
+const lockTTL = 30 * time.Second
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrTooLarge       = errors.New("upload is larger than allowed")
+	ErrOffsetMismatch = errors.New("offset doesn't match the uploaded size")
+	ErrExceedsLength  = errors.New("chunk goes past the length of the upload")
+	ErrIncomplete     = errors.New("upload isn't complete")
+	ErrShortChunk     = errors.New("chunk is shorter than its content length")
+	ErrLocked         = errors.New("upload is being written by another request")
+)
+
+// Upload is a file sent in chunks, each chunk is stored as it arrives and
+// the file is put together once all of it is there.
+type Upload struct {
+	ID       string `json:"id"`
+	UserID   int64  `json:"user_id"`
+	Length   int64  `json:"length"`
+	Offset   int64  `json:"offset"`
+	FileName string `json:"file_name"`
+	// Offsets the stored chunks start at, in order
+	Chunks    []int64   `json:"chunks"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Complete tells whether all of the file has been uploaded.
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+type Store struct {
+	storage storage.Storage
+	maxSize int64
+	expiry  time.Duration
+	now     func() time.Time
+}
+
+// New returns a store of uploads up to maxSize bytes. Uploads which didn't
+// get a chunk for expiry are abandoned, they aren't found anymore and are
+// removed by Collect.
+func New(s storage.Storage, maxSize int64, expiry time.Duration) *Store {
+	return &Store{
+		storage: s,
+		maxSize: maxSize,
+		expiry:  expiry,
+		now:     time.Now,
+	}
+}
+
+func (s *Store) MaxSize() int64 {
+	return s.maxSize
+}
+
+// ExpiresAt returns when the upload is abandoned unless it gets a chunk.
+func (s *Store) ExpiresAt(u *Upload) time.Time {
+	return u.UpdatedAt.Add(s.expiry)
+}
+
+func infoKey(id string) string {
+	return prefix + id + "/info"
+}
+
+func chunkKey(id string, offset int64) string {
+	return prefix + id + "/" + strconv.FormatInt(offset, 10)
+}
+
+func lockKey(id string) string {
+	return prefix + id + "/lock"
+}
+
+func (s *Store) Create(ctx context.Context, userID, length int64, fileName string) (*Upload, error) {
+	if length < 0 {
+		return nil, fmt.Errorf("upload length must be positive")
+	}
+	if length > s.maxSize {
+		return nil, ErrTooLarge
+	}
+
+	if fileName != "" {
+		fileName = path.Base(fileName)
+	}
+
+	now := s.now().UTC()
+	u := &Upload{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Length:    length,
+		FileName:  fileName,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.save(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Get returns an upload of the user, uploads of other users and abandoned
+// ones aren't found.
+func (s *Store) Get(ctx context.Context, id string, userID int64) (*Upload, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrNotFound
+	}
+
+	u, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.UserID != userID || s.now().After(s.ExpiresAt(u)) {
+		return nil, ErrNotFound
+	}
+
+	return u, nil
+}
+
+func (s *Store) load(ctx context.Context, id string) (*Upload, error) {
+	r, _, err := s.storage.Open(ctx, infoKey(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var u Upload
+	if err := json.NewDecoder(r).Decode(&u); err != nil {
+		return nil, fmt.Errorf("upload %s: %v", id, err)
+	}
+
+	return &u, nil
+}
+
+func (s *Store) save(ctx context.Context, u *Upload) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return s.storage.Put(ctx, infoKey(u.ID), bytes.NewReader(data), int64(len(data)), "application/json")
+}
+
+// lease is the content of the lock of an upload. The token tells the
+// holders apart, a released lock has none and stays in place so taking it
+// is always a conditional write of the version read.
+type lease struct {
+	Token     string    `json:"token,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Lock keeps other requests, on any replica, from writing the upload until
+// unlock is called. It fails with ErrLocked while another request holds
+// it, the lock of a replica which stopped is taken over once it expired.
+func (s *Store) Lock(ctx context.Context, id string) (unlock func(), err error) {
+	token := uuid.New().String()
+	etag, err := s.acquire(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				next, err := s.writeLease(context.Background(), id, token, lease{Token: token, ExpiresAt: s.now().Add(lockTTL)}, etag)
+				if err != nil {
+					// Taken over or gone, neither renewed nor released anymore
+					logrus.WithError(err).WithField("upload", id).Warn("failed to renew upload lock")
+					etag = ""
+					return
+				}
+				etag = next
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+		if etag != "" {
+			s.writeLease(context.Background(), id, "", lease{}, etag)
+		}
+	}, nil
+}
+
+// acquire creates the lock of the upload holding token, or takes it over
+// when it is released or expired, and returns its etag.
+func (s *Store) acquire(ctx context.Context, id, token string) (string, error) {
+	held := lease{Token: token, ExpiresAt: s.now().Add(lockTTL)}
+	data, err := json.Marshal(held)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.storage.Create(ctx, lockKey(id), bytes.NewReader(data), int64(len(data)), "application/json")
+	if err == nil {
+		return s.leaseETag(ctx, id, token)
+	}
+	if !errors.Is(err, storage.ErrExists) {
+		return "", err
+	}
+
+	current, etag, err := s.readLease(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Released by deleting the upload meanwhile
+		return "", ErrLocked
+	}
+	if err != nil {
+		return "", err
+	}
+	if current.Token != "" && s.now().Before(current.ExpiresAt) {
+		return "", ErrLocked
+	}
+
+	// Of the requests taking over the same version only one succeeds
+	return s.writeLease(ctx, id, token, held, etag)
+}
+
+// writeLease replaces the version etag of the lock with l and returns the
+// etag of the new version, which holds token. It fails with ErrLocked when
+// the lock changed since.
+func (s *Store) writeLease(ctx context.Context, id, token string, l lease, etag string) (string, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.storage.Replace(ctx, lockKey(id), bytes.NewReader(data), int64(len(data)), "application/json", etag)
+	if errors.Is(err, storage.ErrChanged) {
+		return "", ErrLocked
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return s.leaseETag(ctx, id, token)
+}
+
+// leaseETag returns the etag of the lock while it holds token.
+func (s *Store) leaseETag(ctx context.Context, id, token string) (string, error) {
+	current, etag, err := s.readLease(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrLocked
+	}
+	if err != nil {
+		return "", err
+	}
+	if current.Token != token {
+		return "", ErrLocked
+	}
+
+	return etag, nil
+}
+
+func (s *Store) readLease(ctx context.Context, id string) (*lease, string, error) {
+	r, info, err := s.storage.Open(ctx, lockKey(id))
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	var l lease
+	err = json.NewDecoder(r).Decode(&l)
+	if err == io.EOF {
+		// Locks written before leases had tokens are empty and renewed
+		// by rewriting them
+		l = lease{Token: "-", ExpiresAt: info.ModTime.Add(lockTTL)}
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	return &l, info.ETag, nil
+}
+
+// Append stores a chunk of size bytes starting at offset, which must be
+// where the upload is at. A chunk cut short, e.g. by a dropped connection,
+// or whose checksum doesn't match isn't kept and the client sends it again
+// from the offset the upload is at. It fails with ErrLocked while another
+// request writes the upload.
+func (s *Store) Append(ctx context.Context, u *Upload, offset int64, r io.Reader, size int64, checksum *Checksum) error {
+	unlock, err := s.Lock(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another request may have stored a chunk since u was read
+	current, err := s.load(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	*u = *current
+
+	if offset != u.Offset {
+		return ErrOffsetMismatch
+	}
+	if size < 0 || offset+size > u.Length {
+		return ErrExceedsLength
+	}
+	if size == 0 {
+		return nil
+	}
+
+	body := &shortReader{r: io.LimitReader(r, size), size: size}
+	var source io.Reader = body
+	if checksum != nil {
+		source = checksum.verify(body)
+	}
+
+	key := chunkKey(u.ID, offset)
+	if err := s.storage.Put(ctx, key, source, size, "application/octet-stream"); err != nil {
+		s.storage.Delete(ctx, key)
+		switch {
+		case body.read < size:
+			return ErrShortChunk
+		case errors.Is(err, ErrChecksumMismatch):
+			return ErrChecksumMismatch
+		}
+		return err
+	}
+
+	u.Offset += size
+	u.Chunks = append(u.Chunks, offset)
+	u.UpdatedAt = s.now().UTC()
+	if err := s.save(ctx, u); err != nil {
+		s.storage.Delete(ctx, key)
+		u.Offset -= size
+		u.Chunks = u.Chunks[:len(u.Chunks)-1]
+		return err
+	}
+
+	return nil
+}
+
+// Open returns the uploaded file, put together from its chunks. With a
+// checksum the reader fails with ErrChecksumMismatch at the end of a file
+// which doesn't match it.
+func (s *Store) Open(ctx context.Context, u *Upload, checksum *Checksum) (io.ReadCloser, error) {
+	if !u.Complete() {
+		return nil, ErrIncomplete
+	}
+
+	f := &file{ctx: ctx, storage: s.storage, upload: u}
+	if checksum == nil {
+		return f, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{checksum.verify(f), f}, nil
+}
+
+// Delete removes the upload with its chunks.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	keys, err := s.storage.List(ctx, prefix+id+"/")
+	if err != nil {
+		return err
+	}
+
+	// The info and the lock go last, the upload is found until its chunks
+	// are gone and can't be locked until it isn't found anymore
+	for _, key := range keys {
+		if key == infoKey(id) || key == lockKey(id) {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	if err := s.storage.Delete(ctx, infoKey(id)); err != nil {
+		return err
+	}
+
+	return s.storage.Delete(ctx, lockKey(id))
+}
+
+// Collect removes the abandoned uploads and returns how many there were.
+// Replicas collecting at the same time only remove the same files twice.
+func (s *Store) Collect(ctx context.Context) (int, error) {
+	keys, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make(map[string]bool)
+	for _, key := range keys {
+		id, _, _ := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+		ids[id] = true
+	}
+
+	collected := 0
+	for id := range ids {
+		abandoned, err := s.abandoned(ctx, id)
+		if err != nil {
+			return collected, err
+		}
+		if !abandoned {
+			continue
+		}
+		if err := s.Delete(ctx, id); err != nil {
+			return collected, err
+		}
+		collected++
+	}
+
+	return collected, nil
+}
+
+func (s *Store) abandoned(ctx context.Context, id string) (bool, error) {
+	r, info, err := s.storage.Open(ctx, infoKey(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		// Chunks left behind by a removal which didn't finish
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	var u Upload
+	if err := json.NewDecoder(r).Decode(&u); err != nil {
+		// Broken info, it is as old as the last time it was written
+		return s.now().After(info.ModTime.Add(s.expiry)), nil
+	}
+
+	return s.now().After(s.ExpiresAt(&u)), nil
+}
+
+// RunCollector collects the abandoned uploads every interval.
+func (s *Store) RunCollector(interval time.Duration, logger *logrus.Logger) {
+	for range time.Tick(interval) {
+		n, err := s.Collect(context.Background())
+		if err != nil {
+			logger.WithError(err).Error("failed to collect abandoned uploads")
+			continue
+		}
+		if n > 0 {
+			logger.Infof("collected %d abandoned uploads", n)
+		}
+	}
+}
+
+// file reads the chunks of an upload one after the other, opening each
+// when the previous one is done.
+type file struct {
+	ctx     context.Context
+	storage storage.Storage
+	upload  *Upload
+	next    int
+	chunk   io.ReadCloser
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	for {
+		if f.chunk == nil {
+			if f.next == len(f.upload.Chunks) {
+				return 0, io.EOF
+			}
+			chunk, _, err := f.storage.Open(f.ctx, chunkKey(f.upload.ID, f.upload.Chunks[f.next]))
+			if err != nil {
+				return 0, err
+			}
+			f.chunk = chunk
+			f.next++
+		}
+
+		n, err := f.chunk.Read(p)
+		if err == io.EOF {
+			f.chunk.Close()
+			f.chunk = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (f *file) Close() error {
+	if f.chunk == nil {
+		return nil
+	}
+	err := f.chunk.Close()
+	f.chunk = nil
+	return err
+}
+
+// shortReader fails when its reader ends before size bytes, a storage
+// would keep what was read otherwise.
+type shortReader struct {
+	r    io.Reader
+	size int64
+	read int64
+}
+
+func (s *shortReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.read += int64(n)
+	if err == io.EOF && s.read < s.size {
+		return n, ErrShortChunk
+	}
+	return n, err
+}
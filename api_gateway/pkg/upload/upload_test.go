@@ -0,0 +1,201 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/api_gateway/pkg/storage"
+)
+
+func sha256Checksum(content string) *Checksum {
+	sum := sha256.Sum256([]byte(content))
+	c, err := ParseChecksum("sha256 " + base64.StdEncoding.EncodeToString(sum[:]))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func TestUpload(t *testing.T) {
+	media := storage.NewLocal(t.TempDir())
+	s := New(media, 100, time.Hour)
+	ctx := context.Background()
+
+	u, err := s.Create(ctx, 1, 11, "../videos/cat.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "cat.mp4", u.FileName)
+
+	// Only the user who started it finds it
+	_, err = s.Get(ctx, u.ID, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Get(ctx, "../../etc", 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Append(ctx, u, 0, strings.NewReader("hello "), 6, sha256Checksum("hello ")))
+
+	// The next chunk continues from the stored offset on any replica
+	u, err = s.Get(ctx, u.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), u.Offset)
+	assert.False(t, u.Complete())
+
+	_, err = s.Open(ctx, u, nil)
+	assert.ErrorIs(t, err, ErrIncomplete)
+
+	assert.ErrorIs(t, s.Append(ctx, u, 0, strings.NewReader("hello"), 5, nil), ErrOffsetMismatch)
+	assert.ErrorIs(t, s.Append(ctx, u, 6, strings.NewReader("world!"), 6, nil), ErrExceedsLength)
+
+	// Chunks which don't arrive whole aren't kept
+	assert.ErrorIs(t, s.Append(ctx, u, 6, strings.NewReader("wor"), 5, nil), ErrShortChunk)
+	assert.ErrorIs(t, s.Append(ctx, u, 6, strings.NewReader("w0rld"), 5, sha256Checksum("world")), ErrChecksumMismatch)
+	assert.Equal(t, int64(6), u.Offset)
+
+	require.NoError(t, s.Append(ctx, u, 6, strings.NewReader("world"), 5, nil))
+	assert.True(t, u.Complete())
+
+	f, err := s.Open(ctx, u, sha256Checksum("hello world"))
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+
+	f, err = s.Open(ctx, u, sha256Checksum("hello there"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(f)
+	f.Close()
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	require.NoError(t, s.Delete(ctx, u.ID))
+	_, err = s.Get(ctx, u.ID, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	keys, err := media.List(ctx, "uploads/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestUploadLock(t *testing.T) {
+	media := storage.NewLocal(t.TempDir())
+	s := New(media, 100, time.Hour)
+	ctx := context.Background()
+
+	u, err := s.Create(ctx, 1, 10, "a.mp4")
+	require.NoError(t, err)
+
+	unlock, err := s.Lock(ctx, u.ID)
+	require.NoError(t, err)
+
+	// Requests racing the holder are turned away
+	_, err = s.Lock(ctx, u.ID)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.ErrorIs(t, s.Append(ctx, u, 0, strings.NewReader("abc"), 3, nil), ErrLocked)
+
+	unlock()
+	stale, err := s.Get(ctx, u.ID, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.Append(ctx, u, 0, strings.NewReader("abc"), 3, nil))
+
+	// A copy read before the last chunk continues from the stored offset
+	assert.ErrorIs(t, s.Append(ctx, stale, 0, strings.NewReader("abc"), 3, nil), ErrOffsetMismatch)
+	assert.Equal(t, int64(3), stale.Offset)
+
+	// The lock of a replica which stopped expires, only one of the
+	// requests racing for it takes it over
+	stopped, err := s.Lock(ctx, u.ID)
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time { return now.Add(lockTTL + time.Second) }
+
+	var wg sync.WaitGroup
+	unlocks := make(chan func(), 10)
+	for i := 0; i < cap(unlocks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := s.Lock(ctx, u.ID)
+			if err == nil {
+				unlocks <- unlock
+			} else {
+				assert.ErrorIs(t, err, ErrLocked)
+			}
+		}()
+	}
+	wg.Wait()
+	close(unlocks)
+	require.Len(t, unlocks, 1)
+	unlock = <-unlocks
+
+	// The replica coming back doesn't release the lock it lost
+	stopped()
+	_, err = s.Lock(ctx, u.ID)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	unlock()
+	unlock, err = s.Lock(ctx, u.ID)
+	require.NoError(t, err)
+	unlock()
+
+	require.NoError(t, s.Delete(ctx, u.ID))
+	keys, err := media.List(ctx, "uploads/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestUploadMaxSize(t *testing.T) {
+	s := New(storage.NewLocal(t.TempDir()), 100, time.Hour)
+
+	_, err := s.Create(context.Background(), 1, 101, "big.mp4")
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestCollect(t *testing.T) {
+	media := storage.NewLocal(t.TempDir())
+	s := New(media, 100, time.Hour)
+	ctx := context.Background()
+
+	abandoned, err := s.Create(ctx, 1, 10, "a.mp4")
+	require.NoError(t, err)
+	require.NoError(t, s.Append(ctx, abandoned, 0, strings.NewReader("abc"), 3, nil))
+
+	now := time.Now()
+	s.now = func() time.Time { return now.Add(50 * time.Minute) }
+	active, err := s.Create(ctx, 1, 10, "b.mp4")
+	require.NoError(t, err)
+
+	s.now = func() time.Time { return now.Add(90 * time.Minute) }
+	_, err = s.Get(ctx, abandoned.ID, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	collected, err := s.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, collected)
+
+	keys, err := media.List(ctx, "uploads/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uploads/" + active.ID + "/info"}, keys)
+
+	_, err = s.Get(ctx, active.ID, 1)
+	assert.NoError(t, err)
+}
+
+func TestParseChecksum(t *testing.T) {
+	c, err := ParseChecksum("sha1 Kq5sNclPz7QV2+lfQIuc6R7oRu0=")
+	require.NoError(t, err)
+	assert.Equal(t, "sha1", c.Algorithm)
+
+	_, err = ParseChecksum("crc32 AAAAAA==")
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+
+	for _, header := range []string{"", "sha256", "sha256 not-base64!", "sha256 AAAA"} {
+		_, err = ParseChecksum(header)
+		assert.ErrorIs(t, err, ErrInvalidChecksum, header)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"gitlab.com/telegram_clone/chat_service/storage"
 
 	"gitlab.com/telegram_clone/chat_service/pkg/cronjob"
+	"gitlab.com/telegram_clone/chat_service/pkg/events"
 	grpcPkg "gitlab.com/telegram_clone/chat_service/pkg/grpc_client"
 	"gitlab.com/telegram_clone/chat_service/pkg/logger"
 )
@@ -43,6 +44,7 @@ func main() {
 
 	strg := storage.NewStoragePg(psqlConn)
 	inMemory := storage.NewInMemoryStorage(rdb)
+	publisher := events.NewRedisPublisher(rdb, cfg.Redis.EventsChannel)
 
 	grpcConn, err := grpcPkg.New(cfg)
 	if err != nil {
@@ -50,14 +52,14 @@ func main() {
 	}
 	logrus := logger.New()
 
-	// Registering jobs
-	cron := cronjob.NewCronjob(strg, grpcConn, &cfg, logrus)
-	cron.RegisterTasks()
-
 	userService := service.NewUserService(strg, inMemory, logrus)
 	authService := service.NewAuthService(strg, inMemory, grpcConn, &cfg, logrus)
-	chatService := service.NewChatService(strg, logrus)
-	messageService := service.NewMessageService(strg, logrus)
+	chatService := service.NewChatService(strg, publisher, logrus)
+	messageService := service.NewMessageService(strg, publisher, logrus)
+
+	// Registering jobs
+	cron := cronjob.NewCronjob(strg, grpcConn, messageService, &cfg, logrus)
+	cron.RegisterTasks()
 
 	lis, err := net.Listen("tcp", cfg.GrpcPort)
 	if err != nil {
@@ -13,6 +13,9 @@ type Config struct {
 
 	NotificationServiceGrpcPort string
 	NotificationServiceHost     string
+
+	// Cron spec of the job sending the scheduled messages which are due
+	ScheduledMessagesSpec string
 }
 
 type PostgresConfig struct {
@@ -25,6 +28,8 @@ type PostgresConfig struct {
 
 type Redis struct {
 	Addr string
+	// Channel the events for other services are published to
+	EventsChannel string
 }
 
 func Load(path string) Config {
@@ -33,6 +38,9 @@ func Load(path string) Config {
 	conf := viper.New()
 	conf.AutomaticEnv()
 
+	conf.SetDefault("REDIS_EVENTS_CHANNEL", "chat_service_events")
+	conf.SetDefault("SCHEDULED_MESSAGES_SPEC", "@every 10s")
+
 	cfg := Config{
 		GrpcPort: conf.GetString("GRPC_PORT"),
 		Postgres: PostgresConfig{
@@ -43,11 +51,13 @@ func Load(path string) Config {
 			Database: conf.GetString("POSTGRES_DATABASE"),
 		},
 		Redis: Redis{
-			Addr: conf.GetString("REDIS_ADDR"),
+			Addr:          conf.GetString("REDIS_ADDR"),
+			EventsChannel: conf.GetString("REDIS_EVENTS_CHANNEL"),
 		},
 		AuthSecretKey:               conf.GetString("AUTH_SECRET_KEY"),
 		NotificationServiceHost:     conf.GetString("NOTIFICATION_SERVICE_HOST"),
 		NotificationServiceGrpcPort: conf.GetString("NOTIFICATION_SERVICE_GRPC_PORT"),
+		ScheduledMessagesSpec:       conf.GetString("SCHEDULED_MESSAGES_SPEC"),
 	}
 
 	return cfg
@@ -24,10 +24,15 @@ type ChatServiceClient interface {
 	Update(ctx context.Context, in *Chat, opts ...grpc.CallOption) (*Chat, error)
 	Delete(ctx context.Context, in *ChatIdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetAll(ctx context.Context, in *GetAllChatsParams, opts ...grpc.CallOption) (*GetAllChatsRes, error)
+	// Replaces the settings of the chat, owner only
+	UpdateSettings(ctx context.Context, in *UpdateChatSettingsRequest, opts ...grpc.CallOption) (*Chat, error)
+	// Pinned messages of the chat, most recently pinned first
+	GetPinnedMessages(ctx context.Context, in *GetPinnedMessagesParams, opts ...grpc.CallOption) (*GetAllMessages, error)
 	// Group methods
 	AddMember(ctx context.Context, in *AddMemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	RemoveMember(ctx context.Context, in *RemoveMemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetChatMembers(ctx context.Context, in *GetChatMembersParams, opts ...grpc.CallOption) (*GetAllUsersResponse, error)
+	GetChatPeers(ctx context.Context, in *GetChatPeersRequest, opts ...grpc.CallOption) (*ChatPeers, error)
 }
 
 type chatServiceClient struct {
@@ -83,6 +88,24 @@ func (c *chatServiceClient) GetAll(ctx context.Context, in *GetAllChatsParams, o
 	return out, nil
 }
 
+func (c *chatServiceClient) UpdateSettings(ctx context.Context, in *UpdateChatSettingsRequest, opts ...grpc.CallOption) (*Chat, error) {
+	out := new(Chat)
+	err := c.cc.Invoke(ctx, "/genproto.ChatService/UpdateSettings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) GetPinnedMessages(ctx context.Context, in *GetPinnedMessagesParams, opts ...grpc.CallOption) (*GetAllMessages, error) {
+	out := new(GetAllMessages)
+	err := c.cc.Invoke(ctx, "/genproto.ChatService/GetPinnedMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chatServiceClient) AddMember(ctx context.Context, in *AddMemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/genproto.ChatService/AddMember", in, out, opts...)
@@ -110,6 +133,15 @@ func (c *chatServiceClient) GetChatMembers(ctx context.Context, in *GetChatMembe
 	return out, nil
 }
 
+func (c *chatServiceClient) GetChatPeers(ctx context.Context, in *GetChatPeersRequest, opts ...grpc.CallOption) (*ChatPeers, error) {
+	out := new(ChatPeers)
+	err := c.cc.Invoke(ctx, "/genproto.ChatService/GetChatPeers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChatServiceServer is the server API for ChatService service.
 // All implementations must embed UnimplementedChatServiceServer
 // for forward compatibility
@@ -119,10 +151,15 @@ type ChatServiceServer interface {
 	Update(context.Context, *Chat) (*Chat, error)
 	Delete(context.Context, *ChatIdRequest) (*emptypb.Empty, error)
 	GetAll(context.Context, *GetAllChatsParams) (*GetAllChatsRes, error)
+	// Replaces the settings of the chat, owner only
+	UpdateSettings(context.Context, *UpdateChatSettingsRequest) (*Chat, error)
+	// Pinned messages of the chat, most recently pinned first
+	GetPinnedMessages(context.Context, *GetPinnedMessagesParams) (*GetAllMessages, error)
 	// Group methods
 	AddMember(context.Context, *AddMemberRequest) (*emptypb.Empty, error)
 	RemoveMember(context.Context, *RemoveMemberRequest) (*emptypb.Empty, error)
 	GetChatMembers(context.Context, *GetChatMembersParams) (*GetAllUsersResponse, error)
+	GetChatPeers(context.Context, *GetChatPeersRequest) (*ChatPeers, error)
 	mustEmbedUnimplementedChatServiceServer()
 }
 
@@ -145,6 +182,12 @@ func (UnimplementedChatServiceServer) Delete(context.Context, *ChatIdRequest) (*
 func (UnimplementedChatServiceServer) GetAll(context.Context, *GetAllChatsParams) (*GetAllChatsRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
 }
+func (UnimplementedChatServiceServer) UpdateSettings(context.Context, *UpdateChatSettingsRequest) (*Chat, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSettings not implemented")
+}
+func (UnimplementedChatServiceServer) GetPinnedMessages(context.Context, *GetPinnedMessagesParams) (*GetAllMessages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPinnedMessages not implemented")
+}
 func (UnimplementedChatServiceServer) AddMember(context.Context, *AddMemberRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddMember not implemented")
 }
@@ -154,6 +197,9 @@ func (UnimplementedChatServiceServer) RemoveMember(context.Context, *RemoveMembe
 func (UnimplementedChatServiceServer) GetChatMembers(context.Context, *GetChatMembersParams) (*GetAllUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetChatMembers not implemented")
 }
+func (UnimplementedChatServiceServer) GetChatPeers(context.Context, *GetChatPeersRequest) (*ChatPeers, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChatPeers not implemented")
+}
 func (UnimplementedChatServiceServer) mustEmbedUnimplementedChatServiceServer() {}
 
 // UnsafeChatServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -257,6 +303,42 @@ func _ChatService_GetAll_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChatService_UpdateSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateChatSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).UpdateSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.ChatService/UpdateSettings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).UpdateSettings(ctx, req.(*UpdateChatSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_GetPinnedMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPinnedMessagesParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).GetPinnedMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.ChatService/GetPinnedMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).GetPinnedMessages(ctx, req.(*GetPinnedMessagesParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ChatService_AddMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AddMemberRequest)
 	if err := dec(in); err != nil {
@@ -311,6 +393,24 @@ func _ChatService_GetChatMembers_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChatService_GetChatPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChatPeersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).GetChatPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.ChatService/GetChatPeers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).GetChatPeers(ctx, req.(*GetChatPeersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChatService_ServiceDesc is the grpc.ServiceDesc for ChatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -338,6 +438,14 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAll",
 			Handler:    _ChatService_GetAll_Handler,
 		},
+		{
+			MethodName: "UpdateSettings",
+			Handler:    _ChatService_UpdateSettings_Handler,
+		},
+		{
+			MethodName: "GetPinnedMessages",
+			Handler:    _ChatService_GetPinnedMessages_Handler,
+		},
 		{
 			MethodName: "AddMember",
 			Handler:    _ChatService_AddMember_Handler,
@@ -350,6 +458,10 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetChatMembers",
 			Handler:    _ChatService_GetChatMembers_Handler,
 		},
+		{
+			MethodName: "GetChatPeers",
+			Handler:    _ChatService_GetChatPeers_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "chat_service.proto",
@@ -26,6 +26,7 @@ type UserServiceClient interface {
 	Delete(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	SetUserImage(ctx context.Context, in *SetUserImageRequest, opts ...grpc.CallOption) (*User, error)
 	GetByEmail(ctx context.Context, in *GetByEmailRequest, opts ...grpc.CallOption) (*User, error)
+	SetLastSeenVisibility(ctx context.Context, in *SetLastSeenVisibilityRequest, opts ...grpc.CallOption) (*User, error)
 }
 
 type userServiceClient struct {
@@ -99,6 +100,15 @@ func (c *userServiceClient) GetByEmail(ctx context.Context, in *GetByEmailReques
 	return out, nil
 }
 
+func (c *userServiceClient) SetLastSeenVisibility(ctx context.Context, in *SetLastSeenVisibilityRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/genproto.UserService/SetLastSeenVisibility", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility
@@ -110,6 +120,7 @@ type UserServiceServer interface {
 	Delete(context.Context, *GetUserRequest) (*emptypb.Empty, error)
 	SetUserImage(context.Context, *SetUserImageRequest) (*User, error)
 	GetByEmail(context.Context, *GetByEmailRequest) (*User, error)
+	SetLastSeenVisibility(context.Context, *SetLastSeenVisibilityRequest) (*User, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -138,6 +149,9 @@ func (UnimplementedUserServiceServer) SetUserImage(context.Context, *SetUserImag
 func (UnimplementedUserServiceServer) GetByEmail(context.Context, *GetByEmailRequest) (*User, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetByEmail not implemented")
 }
+func (UnimplementedUserServiceServer) SetLastSeenVisibility(context.Context, *SetLastSeenVisibilityRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLastSeenVisibility not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 
 // UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -277,6 +291,24 @@ func _UserService_GetByEmail_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_SetLastSeenVisibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLastSeenVisibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetLastSeenVisibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/genproto.UserService/SetLastSeenVisibility",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetLastSeenVisibility(ctx, req.(*SetLastSeenVisibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -312,6 +344,10 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetByEmail",
 			Handler:    _UserService_GetByEmail_Handler,
 		},
+		{
+			MethodName: "SetLastSeenVisibility",
+			Handler:    _UserService_SetLastSeenVisibility_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user_service.proto",
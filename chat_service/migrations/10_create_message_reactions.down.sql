@@ -0,0 +1,3 @@
+ALTER TABLE "chats" DROP COLUMN IF EXISTS "allowed_reactions";
+
+DROP TABLE IF EXISTS "message_reactions";
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "message_reactions" (
+    "message_id" INT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "user_id" INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "emoji" VARCHAR(32) NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY("message_id", "user_id", "emoji")
+);
+
+-- Any emoji is allowed when empty
+ALTER TABLE "chats" ADD COLUMN IF NOT EXISTS "allowed_reactions" TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+ALTER TABLE "chats" DROP COLUMN IF EXISTS "disallow_forwarding";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "forwarded_from_created_at";
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "forwarded_from_user_id";
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "forwarded_from_chat_id";
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "forwarded_from_message_id";
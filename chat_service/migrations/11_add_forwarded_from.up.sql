@@ -0,0 +1,8 @@
+-- Original of a forwarded message, no foreign keys so a forwarded message
+-- outlives its original and the chat it was sent in
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "forwarded_from_message_id" INT;
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "forwarded_from_chat_id" INT;
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "forwarded_from_user_id" INT;
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "forwarded_from_created_at" TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE "chats" ADD COLUMN IF NOT EXISTS "disallow_forwarding" BOOLEAN NOT NULL DEFAULT false;
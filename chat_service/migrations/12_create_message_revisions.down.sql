@@ -0,0 +1,5 @@
+ALTER TABLE "chats" DROP COLUMN IF EXISTS "edit_window_seconds";
+
+DROP TABLE IF EXISTS "message_revisions";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "edited_at";
@@ -0,0 +1,14 @@
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "edited_at" TIMESTAMP WITH TIME ZONE;
+
+-- Previous texts of edited messages, created_at is when the text was written
+CREATE TABLE IF NOT EXISTS "message_revisions" (
+    "id" SERIAL PRIMARY KEY,
+    "message_id" INT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "message" TEXT NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "message_revisions_message_id_idx" ON "message_revisions"("message_id", "id");
+
+-- Messages can be edited forever when 0
+ALTER TABLE "chats" ADD COLUMN IF NOT EXISTS "edit_window_seconds" INT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE "chats" DROP COLUMN IF EXISTS "delete_window_seconds";
+
+DROP TABLE IF EXISTS "hidden_messages";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,14 @@
+-- Set when the message was deleted for everyone, its text is cleared and
+-- only the tombstone is left
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP WITH TIME ZONE;
+
+-- Messages deleted only for a user, they are left out of what the user gets
+CREATE TABLE IF NOT EXISTS "hidden_messages" (
+    "message_id" INT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "user_id" INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("user_id", "message_id")
+);
+
+-- Authors can delete their messages for everyone forever when 0
+ALTER TABLE "chats" ADD COLUMN IF NOT EXISTS "delete_window_seconds" INT NOT NULL DEFAULT 0;
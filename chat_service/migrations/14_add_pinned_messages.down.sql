@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "pinned_messages";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "type";
@@ -0,0 +1,13 @@
+-- System messages are written by the service, e.g. when a message is pinned
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "type" VARCHAR(20) NOT NULL DEFAULT 'text'
+    CONSTRAINT "chat_messages_type_check" CHECK ("type" IN ('text', 'system'));
+
+CREATE TABLE IF NOT EXISTS "pinned_messages" (
+    "message_id" INT PRIMARY KEY REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "chat_id" INT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    -- User who pinned the message
+    "user_id" INT REFERENCES users(id) ON DELETE SET NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "pinned_messages_chat_id_idx" ON "pinned_messages"("chat_id", "created_at");
@@ -0,0 +1,17 @@
+-- Messages created by the dispatcher at send_at, only their author sees them
+-- until then
+CREATE TABLE IF NOT EXISTS "scheduled_messages" (
+    "id" SERIAL PRIMARY KEY,
+    "message" TEXT NOT NULL,
+    "user_id" INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "chat_id" INT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    "client_message_id" VARCHAR(64),
+    "reply_to_message_id" INT REFERENCES chat_messages(id) ON DELETE SET NULL,
+    "thread_root_id" INT REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "send_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE("user_id", "client_message_id")
+);
+
+CREATE INDEX IF NOT EXISTS "scheduled_messages_send_at_idx" ON "scheduled_messages"("send_at");
+CREATE INDEX IF NOT EXISTS "scheduled_messages_user_id_idx" ON "scheduled_messages"("user_id", "send_at");
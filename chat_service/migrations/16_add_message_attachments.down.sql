@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "attachments";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "content";
+
+-- Media messages are kept as text, with their caption
+UPDATE "chat_messages" SET "type" = 'text' WHERE "type" NOT IN ('text', 'system');
+ALTER TABLE "chat_messages" DROP CONSTRAINT IF EXISTS "chat_messages_type_check";
+ALTER TABLE "chat_messages" ADD CONSTRAINT "chat_messages_type_check" CHECK ("type" IN ('text', 'system'));
@@ -0,0 +1,26 @@
+ALTER TABLE "chat_messages" DROP CONSTRAINT IF EXISTS "chat_messages_type_check";
+ALTER TABLE "chat_messages" ADD CONSTRAINT "chat_messages_type_check" CHECK ("type" IN (
+    'text', 'system', 'photo', 'video', 'file', 'voice', 'sticker', 'location', 'contact'
+));
+
+-- Location or contact shared by the message
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "content" JSONB;
+
+-- Uploaded media, attached to a message once it is sent
+CREATE TABLE IF NOT EXISTS "attachments" (
+    "id" SERIAL PRIMARY KEY,
+    -- Uploader, only they can send it
+    "user_id" INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "message_id" INT REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "position" INT NOT NULL DEFAULT 0,
+    "url" TEXT NOT NULL,
+    "file_name" VARCHAR(255) NOT NULL DEFAULT '',
+    "mime_type" VARCHAR(255) NOT NULL,
+    "size" BIGINT NOT NULL,
+    "width" INT,
+    "height" INT,
+    "duration" INT,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "attachments_message_id_idx" ON "attachments"("message_id", "position");
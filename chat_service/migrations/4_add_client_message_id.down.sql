@@ -0,0 +1,3 @@
+ALTER TABLE "chat_messages" DROP CONSTRAINT IF EXISTS "chat_messages_user_id_client_message_id_key";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "client_message_id";
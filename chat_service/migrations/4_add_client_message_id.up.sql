@@ -0,0 +1,3 @@
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "client_message_id" VARCHAR(64);
+
+ALTER TABLE "chat_messages" ADD CONSTRAINT "chat_messages_user_id_client_message_id_key" UNIQUE("user_id", "client_message_id");
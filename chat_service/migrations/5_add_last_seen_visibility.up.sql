@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "last_seen_visibility" VARCHAR(20) NOT NULL DEFAULT 'everybody'
+    CHECK ("last_seen_visibility" IN ('everybody', 'chats', 'nobody'));
@@ -0,0 +1,2 @@
+ALTER TABLE "chat_members" DROP COLUMN IF EXISTS "read_at";
+ALTER TABLE "chat_members" DROP COLUMN IF EXISTS "last_read_message_id";
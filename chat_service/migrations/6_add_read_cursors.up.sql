@@ -0,0 +1,2 @@
+ALTER TABLE "chat_members" ADD COLUMN IF NOT EXISTS "last_read_message_id" INT NOT NULL DEFAULT 0;
+ALTER TABLE "chat_members" ADD COLUMN IF NOT EXISTS "read_at" TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "chat_messages_chat_id_id_idx";
+
+DROP TABLE IF EXISTS "chat_message_mentions";
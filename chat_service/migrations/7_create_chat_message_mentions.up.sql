@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "chat_message_mentions" (
+    "message_id" INT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    "user_id" INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY("message_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS "chat_message_mentions_user_id_idx" ON "chat_message_mentions"("user_id", "message_id");
+
+-- Last message and unread counts of the chat list
+CREATE INDEX IF NOT EXISTS "chat_messages_chat_id_id_idx" ON "chat_messages"("chat_id", "id");
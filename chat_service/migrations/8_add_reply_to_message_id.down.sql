@@ -0,0 +1 @@
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "reply_to_message_id";
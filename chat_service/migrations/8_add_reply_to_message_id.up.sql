@@ -0,0 +1,2 @@
+-- No foreign key, a reply keeps pointing to a deleted message to show it as such
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "reply_to_message_id" INT;
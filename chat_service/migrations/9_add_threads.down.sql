@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "chat_messages_thread_root_id_idx";
+
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "thread_last_reply_at";
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "thread_reply_count";
+ALTER TABLE "chat_messages" DROP COLUMN IF EXISTS "thread_root_id";
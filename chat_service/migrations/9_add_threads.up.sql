@@ -0,0 +1,8 @@
+-- Deleting the root of a thread deletes its replies
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "thread_root_id" INT REFERENCES chat_messages(id) ON DELETE CASCADE;
+
+-- Kept on the root so the timeline doesn't count the replies of every message
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "thread_reply_count" INT NOT NULL DEFAULT 0;
+ALTER TABLE "chat_messages" ADD COLUMN IF NOT EXISTS "thread_last_reply_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS "chat_messages_thread_root_id_idx" ON "chat_messages"("thread_root_id", "id");
@@ -8,32 +8,39 @@ import (
 	"gitlab.com/telegram_clone/chat_service/config"
 	"gitlab.com/telegram_clone/chat_service/genproto/notification_service"
 	grpcPkg "gitlab.com/telegram_clone/chat_service/pkg/grpc_client"
+	"gitlab.com/telegram_clone/chat_service/service"
 	"gitlab.com/telegram_clone/chat_service/storage"
 	"gitlab.com/telegram_clone/chat_service/storage/repo"
 )
 
 type Cronjob struct {
-	storage    storage.StorageI
-	grpcClient grpcPkg.GrpcClientI
-	cfg        *config.Config
-	logger     *logrus.Logger
-	cron       *cron.Cron
+	storage        storage.StorageI
+	grpcClient     grpcPkg.GrpcClientI
+	messageService *service.MessageService
+	cfg            *config.Config
+	logger         *logrus.Logger
+	cron           *cron.Cron
 }
 
-func NewCronjob(strg storage.StorageI, grpcConn grpcPkg.GrpcClientI, cfg *config.Config, logger *logrus.Logger) *Cronjob {
+func NewCronjob(strg storage.StorageI, grpcConn grpcPkg.GrpcClientI, messageService *service.MessageService, cfg *config.Config, logger *logrus.Logger) *Cronjob {
 	c := cron.New()
 
 	return &Cronjob{
-		storage:    strg,
-		grpcClient: grpcConn,
-		cfg:        cfg,
-		logger:     logger,
-		cron:       c,
+		storage:        strg,
+		grpcClient:     grpcConn,
+		messageService: messageService,
+		cfg:            cfg,
+		logger:         logger,
+		cron:           c,
 	}
 }
 
 func (cr *Cronjob) RegisterTasks() {
 	cr.cron.AddFunc("@daily", cr.SendEmails)
+	// Every replica runs it, the messages are locked while they are sent
+	if err := cr.cron.AddFunc(cr.cfg.ScheduledMessagesSpec, cr.messageService.SendScheduled); err != nil {
+		cr.logger.Fatalf("invalid SCHEDULED_MESSAGES_SPEC: %v", err)
+	}
 
 	cr.cron.Start()
 }
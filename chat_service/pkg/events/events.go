@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v9"
+)
+
+// Types of the events published by the chat service.
+const (
+	ChatMemberAdded   = "chat.member_added"
+	ChatMemberRemoved = "chat.member_removed"
+	ChatDeleted       = "chat.deleted"
+	MessageRead       = "message.read"
+	MessageReaction   = "message.reaction"
+	MessageUpdated    = "message.updated"
+	MessageDeleted    = "message.deleted"
+
+	// Only published for the messages the chat service writes itself, e.g.
+	// system messages and scheduled messages once they are sent
+	MessageCreated = "message.created"
+)
+
+// Event notifies other services, e.g. the websocket service, about a change
+// they can't observe themselves.
+type Event struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id"`
+	UserID int64  `json:"user_id,omitempty"`
+
+	MessageID int64 `json:"message_id,omitempty"`
+	// Users the event is addressed to when not all members of the chat
+	UserIDs []int64 `json:"user_ids,omitempty"`
+
+	// Set on message.deleted, the messages of the chat deleted at once.
+	// With OnlyMe they were hidden for the user of the event only.
+	MessageIDs []int64 `json:"message_ids,omitempty"`
+	OnlyMe     bool    `json:"only_me,omitempty"`
+
+	// Set on message.reaction
+	Reaction *ReactionChange `json:"reaction,omitempty"`
+	// Message as returned by the MessageService in its protobuf JSON
+	// encoding, set on message.updated
+	Message json.RawMessage `json:"message,omitempty"`
+}
+
+// ReactionChange is a reaction added or removed by the user of the event,
+// with the resulting counts of the message.
+type ReactionChange struct {
+	Emoji     string          `json:"emoji"`
+	Added     bool            `json:"added"`
+	Reactions []ReactionCount `json:"reactions"`
+}
+
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+}
+
+type PublisherI interface {
+	Publish(e *Event) error
+}
+
+type redisPublisher struct {
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisPublisher(rdb *redis.Client, channel string) PublisherI {
+	return &redisPublisher{
+		client:  rdb,
+		channel: channel,
+	}
+}
+
+func (p *redisPublisher) Publish(e *Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return p.client.Publish(context.Background(), p.channel, data).Err()
+}
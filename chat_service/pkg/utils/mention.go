@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@(\w{1,30})`)
+
+// ParseMentions returns the usernames mentioned in a message as @username,
+// lower cased and without duplicates.
+func ParseMentions(message string) []string {
+	usernames := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, match := range mentionRegexp.FindAllStringSubmatch(message, -1) {
+		username := strings.ToLower(match[1])
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+
+	return usernames
+}
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMentions(t *testing.T) {
+	require.Equal(t, []string{"alice", "bob"}, ParseMentions("@alice and @Bob, ask @alice"))
+	require.Equal(t, []string{"bob"}, ParseMentions("mail me at alice@example.com or ping @bob."))
+	require.Empty(t, ParseMentions("no mentions here"))
+}
@@ -20,6 +20,14 @@ func NullFloat64(s float64) (ns sql.NullFloat64) {
 	return ns
 }
 
+func NullInt64(v int64) (ni sql.NullInt64) {
+	if v != 0 {
+		ni.Int64 = v
+		ni.Valid = true
+	}
+	return ni
+}
+
 func NullInt32(v int32) (ni sql.NullInt32) {
 	if v != 0 {
 		ni.Int32 = v
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	pb "gitlab.com/telegram_clone/chat_service/genproto/chat_service"
+	"gitlab.com/telegram_clone/chat_service/pkg/events"
 	"gitlab.com/telegram_clone/chat_service/storage/repo"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -18,14 +20,24 @@ import (
 
 type ChatService struct {
 	pb.UnimplementedChatServiceServer
-	storage storage.StorageI
-	logger  *logrus.Logger
+	storage   storage.StorageI
+	publisher events.PublisherI
+	logger    *logrus.Logger
 }
 
-func NewChatService(strg storage.StorageI, logger *logrus.Logger) *ChatService {
+func NewChatService(strg storage.StorageI, publisher events.PublisherI, logger *logrus.Logger) *ChatService {
 	return &ChatService{
-		storage: strg,
-		logger:  logger,
+		storage:   strg,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// publish notifies other services about a change, a failure is only logged
+// as the change itself is already stored.
+func (s *ChatService) publish(e *events.Event) {
+	if err := s.publisher.Publish(e); err != nil {
+		s.logger.WithError(err).Errorf("failed to publish %s event", e.Type)
 	}
 }
 
@@ -47,7 +59,7 @@ func (s *ChatService) Create(ctx context.Context, req *pb.CreateChatReq) (*pb.Ch
 }
 
 func parseChatModel(res *repo.Chat) *pb.Chat {
-	return &pb.Chat{
+	chat := &pb.Chat{
 		Id:     res.ID,
 		Name:   res.Name,
 		UserId: res.UserID,
@@ -61,7 +73,39 @@ func parseChatModel(res *repo.Chat) *pb.Chat {
 		},
 		ChatType: res.ChatType,
 		ImageUrl: res.ImageUrl,
+
+		UnreadCount:        res.UnreadCount,
+		UnreadMentionCount: res.UnreadMentionCount,
+
+		Settings: &pb.ChatSettings{
+			AllowedReactions:    res.Settings.AllowedReactions,
+			DisallowForwarding:  res.Settings.DisallowForwarding,
+			EditWindowSeconds:   res.Settings.EditWindowSeconds,
+			DeleteWindowSeconds: res.Settings.DeleteWindowSeconds,
+		},
 	}
+	if !res.LastActivityAt.IsZero() {
+		chat.LastActivityAt = res.LastActivityAt.Format(time.RFC3339)
+	}
+	if res.LastMessage != nil {
+		chat.LastMessage = &pb.LastMessage{
+			Id:      res.LastMessage.ID,
+			Message: res.LastMessage.Message,
+			Type:    res.LastMessage.Type,
+			UserId:  res.LastMessage.UserID,
+			UserInfo: &pb.GetUserInfo{
+				FirstName: res.LastMessage.UserInfo.FirstName,
+				LastName:  res.LastMessage.UserInfo.LastName,
+				Email:     res.LastMessage.UserInfo.Email,
+				Username:  res.LastMessage.UserInfo.UserName,
+				ImageUrl:  res.LastMessage.UserInfo.ImageUrl,
+				CreatedAt: res.LastMessage.UserInfo.CreatedAt.Format(time.RFC3339),
+			},
+			CreatedAt: res.LastMessage.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return chat
 }
 
 func (s *ChatService) Get(ctx context.Context, req *pb.IdRequest) (*pb.Chat, error) {
@@ -105,6 +149,11 @@ func (s *ChatService) Delete(ctx context.Context, req *pb.ChatIdRequest) (*empty
 		return nil, status.Errorf(codes.Internal, "failed to delete: %v", err)
 	}
 
+	s.publish(&events.Event{
+		Type:   events.ChatDeleted,
+		ChatID: req.Id,
+	})
+
 	return &emptypb.Empty{}, nil
 }
 
@@ -135,6 +184,66 @@ func (s *ChatService) GetAll(ctx context.Context, req *pb.GetAllChatsParams) (*p
 	return &response, nil
 }
 
+func (s *ChatService) UpdateSettings(ctx context.Context, req *pb.UpdateChatSettingsRequest) (*pb.Chat, error) {
+	settings := repo.ChatSettings{
+		AllowedReactions:    make([]string, 0),
+		DisallowForwarding:  req.GetSettings().GetDisallowForwarding(),
+		EditWindowSeconds:   req.GetSettings().GetEditWindowSeconds(),
+		DeleteWindowSeconds: req.GetSettings().GetDeleteWindowSeconds(),
+	}
+	if settings.EditWindowSeconds < 0 || settings.DeleteWindowSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "edit and delete windows must not be negative")
+	}
+	for _, emoji := range req.GetSettings().GetAllowedReactions() {
+		emoji = strings.TrimSpace(emoji)
+		if emoji == "" || len(emoji) > maxReactionLength {
+			return nil, status.Errorf(codes.InvalidArgument, "allowed reactions must be between 1 and %d bytes", maxReactionLength)
+		}
+		settings.AllowedReactions = append(settings.AllowedReactions, emoji)
+	}
+
+	err := s.storage.Chat().UpdateSettings(&repo.UpdateChatSettingsReq{
+		ChatID:   req.ChatId,
+		UserID:   req.UserId,
+		Settings: settings,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to update chat settings")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "chat not found in the chats owned by the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update settings: %v", err)
+	}
+
+	return s.Get(ctx, &pb.IdRequest{Id: req.ChatId})
+}
+
+func (s *ChatService) GetPinnedMessages(ctx context.Context, req *pb.GetPinnedMessagesParams) (*pb.GetAllMessages, error) {
+	messages, err := s.storage.ChatMessage().GetPinned(&repo.GetPinnedParams{
+		ChatID: req.ChatId,
+		UserID: req.UserId,
+		Limit:  req.Limit,
+		Page:   req.Page,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get pinned messages")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "chat not found in the chats of the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get pinned messages: %v", err)
+	}
+
+	response := pb.GetAllMessages{
+		Messages: make([]*pb.ChatMessage, 0, len(messages.Messages)),
+		Count:    messages.Count,
+	}
+	for _, v := range messages.Messages {
+		response.Messages = append(response.Messages, parseMessageModel(v))
+	}
+
+	return &response, nil
+}
+
 // Group chat methods
 func (s *ChatService) AddMember(ctx context.Context, req *pb.AddMemberRequest) (*emptypb.Empty, error) {
 	s.logger.Info("Add members")
@@ -147,6 +256,12 @@ func (s *ChatService) AddMember(ctx context.Context, req *pb.AddMemberRequest) (
 		return nil, status.Errorf(codes.Internal, "failed to addmember: %v", err)
 	}
 
+	s.publish(&events.Event{
+		Type:   events.ChatMemberAdded,
+		ChatID: req.ChatId,
+		UserID: req.UserId,
+	})
+
 	return &emptypb.Empty{}, nil
 }
 
@@ -160,7 +275,14 @@ func (s *ChatService) RemoveMember(ctx context.Context, req *pb.RemoveMemberRequ
 		s.logger.WithError(err).Error("failed to remove member")
 		return nil, status.Errorf(codes.Internal, "failed to remove member: %v", err)
 	}
-	return nil, nil
+
+	s.publish(&events.Event{
+		Type:   events.ChatMemberRemoved,
+		ChatID: req.ChatId,
+		UserID: req.UserId,
+	})
+
+	return &emptypb.Empty{}, nil
 }
 
 func (s *ChatService) GetChatMembers(ctx context.Context, req *pb.GetChatMembersParams) (*pb.GetAllUsersResponse, error) {
@@ -189,3 +311,13 @@ func (s *ChatService) GetChatMembers(ctx context.Context, req *pb.GetChatMembers
 
 	return &response, nil
 }
+
+func (s *ChatService) GetChatPeers(ctx context.Context, req *pb.GetChatPeersRequest) (*pb.ChatPeers, error) {
+	userIDs, err := s.storage.Chat().GetChatPeers(req.UserId)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get chat peers")
+		return nil, status.Errorf(codes.Internal, "failed to get chat peers: %v", err)
+	}
+
+	return &pb.ChatPeers{UserIds: userIDs}, nil
+}
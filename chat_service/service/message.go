@@ -4,74 +4,329 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	pb "gitlab.com/telegram_clone/chat_service/genproto/chat_service"
 	"gitlab.com/telegram_clone/chat_service/storage/repo"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/sirupsen/logrus"
+	"gitlab.com/telegram_clone/chat_service/pkg/events"
+	"gitlab.com/telegram_clone/chat_service/pkg/utils"
 	"gitlab.com/telegram_clone/chat_service/storage"
 )
 
+// Longest emoji accepted as a reaction, in bytes. Emojis with modifiers take
+// several code points.
+const maxReactionLength = 32
+
+// Most messages forwarded at once.
+const maxForwardedMessages = 100
+
+// Most messages deleted at once.
+const maxDeletedMessages = 100
+
+// Furthest in the future a message can be scheduled.
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// Most attachments sent with one message.
+const maxAttachments = 10
+
 type MessageService struct {
 	pb.UnimplementedMessageServiceServer
-	storage storage.StorageI
-	logger  *logrus.Logger
+	storage   storage.StorageI
+	publisher events.PublisherI
+	logger    *logrus.Logger
 }
 
-func NewMessageService(strg storage.StorageI, logger *logrus.Logger) *MessageService {
+func NewMessageService(strg storage.StorageI, publisher events.PublisherI, logger *logrus.Logger) *MessageService {
 	return &MessageService{
-		storage: strg,
-		logger:  logger,
+		storage:   strg,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// publish notifies other services about a change, a failure is only logged
+// as the change itself is already stored.
+func (s *MessageService) publish(e *events.Event) {
+	if err := s.publisher.Publish(e); err != nil {
+		s.logger.WithError(err).Errorf("failed to publish %s event", e.Type)
+	}
+}
+
+// publishMessage publishes an event carrying the message.
+func (s *MessageService) publishMessage(eventType string, message *pb.ChatMessage) {
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		s.logger.WithError(err).Errorf("failed to marshal %s message", eventType)
+		return
 	}
+
+	s.publish(&events.Event{
+		Type:      eventType,
+		ChatID:    message.ChatId,
+		UserID:    message.UserId,
+		MessageID: message.Id,
+		Message:   data,
+	})
 }
 
+// Create sends the message, or schedules it when send_at is in the future.
 func (s *MessageService) Create(ctx context.Context, req *pb.ChatMessage) (*pb.ChatMessage, error) {
 	s.logger.Info("create message")
+	if err := validateContent(req); err != nil {
+		return nil, err
+	}
+
+	if req.SendAt != "" {
+		sendAt, err := parseSendAt(req.SendAt)
+		if err != nil {
+			return nil, err
+		}
+		if sendAt.After(time.Now()) {
+			if req.Type != "" && req.Type != repo.MessageTypeText {
+				return nil, status.Errorf(codes.InvalidArgument, "only text messages can be scheduled")
+			}
+			return s.schedule(req, sendAt)
+		}
+	}
+
 	chat, err := s.storage.ChatMessage().Create(&repo.ChatMessage{
-		Message: req.Message,
-		UserId:  req.UserId,
-		ChatId:  req.ChatId,
+		Message:          req.Message,
+		UserId:           req.UserId,
+		ChatId:           req.ChatId,
+		ClientMessageID:  req.ClientMessageId,
+		Mentions:         utils.ParseMentions(req.Message),
+		ReplyToMessageID: req.ReplyToMessageId,
+		ThreadRootID:     req.ThreadRootId,
+		Type:             req.Type,
+		AttachmentIDs:    req.AttachmentIds,
+		Location:         parseLocation(req.Location),
+		Contact:          parseContact(req.Contact),
 	})
 	if err != nil {
 		s.logger.WithError(err).Error("failed to create message")
+		if errors.Is(err, repo.ErrReplyNotInChat) || errors.Is(err, repo.ErrInvalidThreadRoot) ||
+			errors.Is(err, repo.ErrInvalidAttachment) || errors.Is(err, repo.ErrClientMessageIDReused) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to create: %v", err)
 	}
 
 	return parseMessageModel(chat), nil
 }
 
+// validateContent checks that the message carries what its type needs and
+// nothing else.
+func validateContent(req *pb.ChatMessage) error {
+	withAttachments := false
+	switch req.Type {
+	case "", repo.MessageTypeText:
+		if req.Message == "" {
+			return status.Errorf(codes.InvalidArgument, "message is required for text messages")
+		}
+	case repo.MessageTypePhoto, repo.MessageTypeVideo, repo.MessageTypeFile:
+		if n := len(req.AttachmentIds); n == 0 || n > maxAttachments {
+			return status.Errorf(codes.InvalidArgument, "%s messages need between 1 and %d attachments", req.Type, maxAttachments)
+		}
+		withAttachments = true
+	case repo.MessageTypeVoice, repo.MessageTypeSticker:
+		if len(req.AttachmentIds) != 1 {
+			return status.Errorf(codes.InvalidArgument, "%s messages need exactly one attachment", req.Type)
+		}
+		withAttachments = true
+	case repo.MessageTypeLocation:
+		l := req.Location
+		if l == nil || l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180 {
+			return status.Errorf(codes.InvalidArgument, "location messages need a latitude between -90 and 90 and a longitude between -180 and 180")
+		}
+	case repo.MessageTypeContact:
+		if req.Contact == nil || req.Contact.PhoneNumber == "" || req.Contact.FirstName == "" {
+			return status.Errorf(codes.InvalidArgument, "contact messages need a phone number and a first name")
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown message type %q", req.Type)
+	}
+
+	if !withAttachments && len(req.AttachmentIds) > 0 {
+		return status.Errorf(codes.InvalidArgument, "%s messages have no attachments", req.Type)
+	}
+	if req.Type != repo.MessageTypeLocation && req.Location != nil {
+		return status.Errorf(codes.InvalidArgument, "location is only set for location messages")
+	}
+	if req.Type != repo.MessageTypeContact && req.Contact != nil {
+		return status.Errorf(codes.InvalidArgument, "contact is only set for contact messages")
+	}
+
+	return nil
+}
+
+func (s *MessageService) CreateAttachment(ctx context.Context, req *pb.Attachment) (*pb.Attachment, error) {
+	if req.UserId == 0 || req.Url == "" || req.MimeType == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id, url and mime_type are required")
+	}
+
+	attachment, err := s.storage.Attachment().Create(&repo.Attachment{
+		UserID:   req.UserId,
+		URL:      req.Url,
+		FileName: req.FileName,
+		MimeType: req.MimeType,
+		Size:     req.Size,
+		Width:    req.Width,
+		Height:   req.Height,
+		Duration: req.Duration,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to create attachment")
+		return nil, status.Errorf(codes.Internal, "failed to create attachment: %v", err)
+	}
+
+	return parseAttachmentModel(attachment), nil
+}
+
+func (s *MessageService) GetMedia(ctx context.Context, req *pb.GetMediaRequest) (*pb.Attachment, error) {
+	if req.Url == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "url is required")
+	}
+
+	attachment, err := s.storage.Attachment().GetMedia(req.Url, req.UserId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "media not found")
+		}
+		s.logger.WithError(err).Error("failed to get media")
+		return nil, status.Errorf(codes.Internal, "failed to get media: %v", err)
+	}
+
+	return parseAttachmentModel(attachment), nil
+}
+
 func (s *MessageService) Update(ctx context.Context, req *pb.ChatMessage) (*pb.ChatMessage, error) {
 	chat, err := s.storage.ChatMessage().Update(&repo.ChatMessage{
-		ID: req.Id,
-		Message: req.Message,
-		UserId: req.UserId,
+		ID:       req.Id,
+		Message:  req.Message,
+		UserId:   req.UserId,
+		Mentions: utils.ParseMentions(req.Message),
 	})
 	if err != nil {
 		s.logger.WithError(err).Error("failed to update message")
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, status.Errorf(codes.NotFound, err.Error())
 		}
+		if errors.Is(err, repo.ErrEditWindowExpired) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to update: %v", err)
 	}
 
-	return parseMessageModel(chat), nil
+	message := parseMessageModel(chat)
+	s.publishMessage(events.MessageUpdated, message)
+
+	return message, nil
+}
+
+func (s *MessageService) GetRevisions(ctx context.Context, req *pb.GetRevisionsParams) (*pb.MessageRevisions, error) {
+	revisions, err := s.storage.ChatMessage().GetRevisions(&repo.GetRevisionsParams{
+		MessageID: req.MessageId,
+		UserID:    req.UserId,
+		Limit:     req.Limit,
+		Page:      req.Page,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get message revisions")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "message not found among the messages of the user and the chats owned by the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get revisions: %v", err)
+	}
+
+	response := pb.MessageRevisions{
+		Revisions: make([]*pb.MessageRevision, 0, len(revisions.Revisions)),
+		Count:     revisions.Count,
+	}
+	for _, r := range revisions.Revisions {
+		response.Revisions = append(response.Revisions, &pb.MessageRevision{
+			Message:   r.Message,
+			CreatedAt: r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return &response, nil
 }
 
 func (s *MessageService) Delete(ctx context.Context, req *pb.ChatIdRequest) (*emptypb.Empty, error) {
-	err := s.storage.ChatMessage().Delete(req.Id, req.UserId)
+	_, err := s.DeleteMessages(ctx, &pb.DeleteMessagesRequest{
+		MessageIds: []int64{req.Id},
+		UserId:     req.UserId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *MessageService) DeleteMessages(ctx context.Context, req *pb.DeleteMessagesRequest) (*pb.DeletedMessages, error) {
+	ids := make([]int64, 0, len(req.MessageIds))
+	seen := make(map[int64]bool, len(req.MessageIds))
+	for _, id := range req.MessageIds {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > maxDeletedMessages {
+		return nil, status.Errorf(codes.InvalidArgument, "between 1 and %d messages can be deleted at once", maxDeletedMessages)
+	}
+
+	deleted, err := s.storage.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: ids,
+		UserID:     req.UserId,
+		OnlyMe:     req.OnlyMe,
+	})
 	if err != nil {
-		s.logger.WithError(err).Error("failed to delete message")
+		s.logger.WithError(err).Error("failed to delete messages")
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, status.Errorf(codes.NotFound, err.Error())
+			return nil, status.Errorf(codes.NotFound, "messages not found among the ones the user can delete")
+		}
+		if errors.Is(err, repo.ErrDeleteWindowExpired) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
 		}
 		return nil, status.Errorf(codes.Internal, "failed to delete: %v", err)
 	}
 
-	return &emptypb.Empty{}, nil
+	response := pb.DeletedMessages{
+		Messages: make([]*pb.DeletedMessage, 0, len(deleted)),
+		OnlyMe:   req.OnlyMe,
+	}
+	byChat := make(map[int64][]int64)
+	var chats []int64
+	for _, m := range deleted {
+		response.Messages = append(response.Messages, &pb.DeletedMessage{
+			Id:     m.ID,
+			ChatId: m.ChatID,
+		})
+		if _, ok := byChat[m.ChatID]; !ok {
+			chats = append(chats, m.ChatID)
+		}
+		byChat[m.ChatID] = append(byChat[m.ChatID], m.ID)
+	}
+	for _, chatID := range chats {
+		s.publish(&events.Event{
+			Type:       events.MessageDeleted,
+			ChatID:     chatID,
+			UserID:     req.UserId,
+			MessageIDs: byChat[chatID],
+			OnlyMe:     req.OnlyMe,
+		})
+	}
+
+	return &response, nil
 }
 
 func (s *MessageService) GetAll(ctx context.Context, req *pb.GetAllMessagesParams) (*pb.GetAllMessages, error) {
@@ -79,6 +334,7 @@ func (s *MessageService) GetAll(ctx context.Context, req *pb.GetAllMessagesParam
 		Limit:  req.Limit,
 		Page:   req.Page,
 		ChatId: req.ChatId,
+		UserID: req.UserId,
 	})
 
 	if err != nil {
@@ -101,20 +357,572 @@ func (s *MessageService) GetAll(ctx context.Context, req *pb.GetAllMessagesParam
 	return &response, nil
 }
 
+func (s *MessageService) GetThread(ctx context.Context, req *pb.GetThreadParams) (*pb.GetAllMessages, error) {
+	messages, err := s.storage.ChatMessage().GetThread(&repo.GetThreadParams{
+		RootID: req.RootId,
+		UserID: req.UserId,
+		Limit:  req.Limit,
+		Page:   req.Page,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get thread")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "thread not found in the chats of the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get thread: %v", err)
+	}
+
+	response := pb.GetAllMessages{
+		Messages: make([]*pb.ChatMessage, 0),
+		Count:    messages.Count,
+	}
+	for _, v := range messages.Messages {
+		response.Messages = append(response.Messages, parseMessageModel(v))
+	}
+
+	return &response, nil
+}
+
+func (s *MessageService) GetAllSince(ctx context.Context, req *pb.GetMessagesSinceParams) (*pb.GetAllMessages, error) {
+	messages, err := s.storage.ChatMessage().GetAllSince(&repo.GetMessagesSinceParams{
+		UserID:        req.UserId,
+		LastMessageID: req.LastMessageId,
+		Limit:         req.Limit,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get messages since")
+		return nil, status.Errorf(codes.Internal, "failed to get all since: %v", err)
+	}
+
+	response := pb.GetAllMessages{
+		Messages: make([]*pb.ChatMessage, 0),
+		Count:    messages.Count,
+	}
+	for _, v := range messages.Messages {
+		response.Messages = append(response.Messages, parseMessageModel(v))
+	}
+
+	return &response, nil
+}
+
+func (s *MessageService) MarkRead(ctx context.Context, req *pb.MarkReadRequest) (*pb.ReadCursor, error) {
+	cursor, err := s.storage.ChatMessage().MarkRead(&repo.MarkReadParams{
+		ChatID:    req.ChatId,
+		UserID:    req.UserId,
+		MessageID: req.MessageId,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to mark read")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "message not found in the chats of the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to mark read: %v", err)
+	}
+
+	if len(cursor.SenderIDs) > 0 {
+		s.publish(&events.Event{
+			Type:      events.MessageRead,
+			ChatID:    cursor.ChatID,
+			UserID:    cursor.UserID,
+			MessageID: cursor.LastReadMessageID,
+			UserIDs:   cursor.SenderIDs,
+		})
+	}
+
+	return &pb.ReadCursor{
+		ChatId:            cursor.ChatID,
+		UserId:            cursor.UserID,
+		LastReadMessageId: cursor.LastReadMessageID,
+		SenderIds:         cursor.SenderIDs,
+	}, nil
+}
+
+func (s *MessageService) GetReadBy(ctx context.Context, req *pb.GetReadByParams) (*pb.GetAllUsersResponse, error) {
+	result, err := s.storage.ChatMessage().GetReadBy(&repo.GetReadByParams{
+		MessageID: req.MessageId,
+		UserID:    req.UserId,
+		Limit:     req.Limit,
+		Page:      req.Page,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get read by")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "message not found in the chats of the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get read by: %v", err)
+	}
+
+	response := pb.GetAllUsersResponse{
+		Count: result.Count,
+		Users: make([]*pb.User, 0),
+	}
+	for _, user := range result.Users {
+		response.Users = append(response.Users, parseUserModel(user))
+	}
+
+	return &response, nil
+}
+
+func (s *MessageService) AddReaction(ctx context.Context, req *pb.ReactionRequest) (*pb.MessageReactions, error) {
+	return s.react(req, true)
+}
+
+func (s *MessageService) RemoveReaction(ctx context.Context, req *pb.ReactionRequest) (*pb.MessageReactions, error) {
+	return s.react(req, false)
+}
+
+// react adds or removes the reaction and tells the members of the chat
+// about the new counts.
+func (s *MessageService) react(req *pb.ReactionRequest, add bool) (*pb.MessageReactions, error) {
+	emoji := strings.TrimSpace(req.Emoji)
+	if emoji == "" || len(emoji) > maxReactionLength {
+		return nil, status.Errorf(codes.InvalidArgument, "emoji must be between 1 and %d bytes", maxReactionLength)
+	}
+
+	params := &repo.ReactionParams{
+		MessageID: req.MessageId,
+		UserID:    req.UserId,
+		Emoji:     emoji,
+	}
+
+	var (
+		reactions *repo.MessageReactions
+		err       error
+	)
+	if add {
+		reactions, err = s.storage.ChatMessage().AddReaction(params)
+	} else {
+		reactions, err = s.storage.ChatMessage().RemoveReaction(params)
+	}
+	if err != nil {
+		s.logger.WithError(err).Error("failed to react to message")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "message not found in the chats of the user")
+		}
+		if errors.Is(err, repo.ErrReactionNotAllowed) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to react: %v", err)
+	}
+
+	change := events.ReactionChange{
+		Emoji:     emoji,
+		Added:     add,
+		Reactions: make([]events.ReactionCount, 0, len(reactions.Reactions)),
+	}
+	for _, r := range reactions.Reactions {
+		change.Reactions = append(change.Reactions, events.ReactionCount{Emoji: r.Emoji, Count: r.Count})
+	}
+	s.publish(&events.Event{
+		Type:      events.MessageReaction,
+		ChatID:    reactions.ChatID,
+		UserID:    req.UserId,
+		MessageID: reactions.MessageID,
+		Reaction:  &change,
+	})
+
+	return &pb.MessageReactions{
+		MessageId: reactions.MessageID,
+		ChatId:    reactions.ChatID,
+		Reactions: parseReactionModels(reactions.Reactions),
+	}, nil
+}
+
+func (s *MessageService) Forward(ctx context.Context, req *pb.ForwardRequest) (*pb.GetAllMessages, error) {
+	ids := make([]int64, 0, len(req.MessageIds))
+	seen := make(map[int64]bool, len(req.MessageIds))
+	for _, id := range req.MessageIds {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > maxForwardedMessages {
+		return nil, status.Errorf(codes.InvalidArgument, "between 1 and %d messages can be forwarded at once", maxForwardedMessages)
+	}
+
+	messages, err := s.storage.ChatMessage().Forward(&repo.ForwardParams{
+		MessageIDs: ids,
+		ChatID:     req.ChatId,
+		UserID:     req.UserId,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to forward messages")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "messages or chat not found in the chats of the user")
+		}
+		if errors.Is(err, repo.ErrForwardingDisallowed) {
+			return nil, status.Errorf(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to forward: %v", err)
+	}
+
+	response := pb.GetAllMessages{
+		Messages: make([]*pb.ChatMessage, 0, len(messages)),
+		Count:    int64(len(messages)),
+	}
+	for _, v := range messages {
+		message := parseMessageModel(v)
+		response.Messages = append(response.Messages, message)
+		s.publishMessage(events.MessageCreated, message)
+	}
+
+	return &response, nil
+}
+
+func (s *MessageService) Pin(ctx context.Context, req *pb.PinRequest) (*pb.PinResult, error) {
+	result, err := s.storage.ChatMessage().Pin(&repo.PinParams{
+		MessageID: req.MessageId,
+		UserID:    req.UserId,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to pin message")
+		return nil, pinError(err)
+	}
+
+	response := pb.PinResult{
+		Message: parseMessageModel(result.Message),
+	}
+	s.publishMessage(events.MessageUpdated, response.Message)
+	if result.SystemMessage != nil {
+		response.SystemMessage = parseMessageModel(result.SystemMessage)
+		s.publishMessage(events.MessageCreated, response.SystemMessage)
+	}
+
+	return &response, nil
+}
+
+func (s *MessageService) Unpin(ctx context.Context, req *pb.PinRequest) (*pb.ChatMessage, error) {
+	result, err := s.storage.ChatMessage().Unpin(&repo.PinParams{
+		MessageID: req.MessageId,
+		UserID:    req.UserId,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to unpin message")
+		return nil, pinError(err)
+	}
+
+	message := parseMessageModel(result)
+	s.publishMessage(events.MessageUpdated, message)
+
+	return message, nil
+}
+
+func (s *MessageService) schedule(req *pb.ChatMessage, sendAt time.Time) (*pb.ChatMessage, error) {
+	scheduled, err := s.storage.ScheduledMessage().Create(&repo.ScheduledMessage{
+		Message:          req.Message,
+		UserID:           req.UserId,
+		ChatID:           req.ChatId,
+		ClientMessageID:  req.ClientMessageId,
+		ReplyToMessageID: req.ReplyToMessageId,
+		ThreadRootID:     req.ThreadRootId,
+		SendAt:           sendAt,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to schedule message")
+		if errors.Is(err, repo.ErrReplyNotInChat) || errors.Is(err, repo.ErrInvalidThreadRoot) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to schedule: %v", err)
+	}
+
+	return parseScheduledMessageModel(scheduled), nil
+}
+
+func (s *MessageService) GetScheduled(ctx context.Context, req *pb.GetScheduledMessagesParams) (*pb.GetAllMessages, error) {
+	scheduled, err := s.storage.ScheduledMessage().GetAll(&repo.GetScheduledMessagesParams{
+		UserID: req.UserId,
+		ChatID: req.ChatId,
+		Limit:  req.Limit,
+		Page:   req.Page,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to get scheduled messages")
+		return nil, status.Errorf(codes.Internal, "failed to get scheduled messages: %v", err)
+	}
+
+	response := pb.GetAllMessages{
+		Messages: make([]*pb.ChatMessage, 0, len(scheduled.Messages)),
+		Count:    scheduled.Count,
+	}
+	for _, m := range scheduled.Messages {
+		response.Messages = append(response.Messages, parseScheduledMessageModel(m))
+	}
+
+	return &response, nil
+}
+
+func (s *MessageService) UpdateScheduled(ctx context.Context, req *pb.ChatMessage) (*pb.ChatMessage, error) {
+	sendAt, err := parseSendAt(req.SendAt)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := s.storage.ScheduledMessage().Update(&repo.ScheduledMessage{
+		ID:      req.Id,
+		Message: req.Message,
+		UserID:  req.UserId,
+		SendAt:  sendAt,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to update scheduled message")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "scheduled message not found among the ones of the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update scheduled message: %v", err)
+	}
+
+	return parseScheduledMessageModel(scheduled), nil
+}
+
+func (s *MessageService) CancelScheduled(ctx context.Context, req *pb.ChatIdRequest) (*emptypb.Empty, error) {
+	err := s.storage.ScheduledMessage().Delete(req.Id, req.UserId)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to cancel scheduled message")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "scheduled message not found among the ones of the user")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to cancel scheduled message: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// SendScheduled sends the scheduled messages which are due and tells the
+// members of their chats. The cron job of every replica runs it, each
+// message is sent by one of them.
+func (s *MessageService) SendScheduled() {
+	var skip []int64
+	for {
+		sent, err := s.storage.ScheduledMessage().SendDue(skip)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to send scheduled message")
+			if sent == nil {
+				return
+			}
+			// Retried on the next run
+			skip = append(skip, sent.ScheduledID)
+			continue
+		}
+		if sent == nil {
+			return
+		}
+		if sent.Message == nil {
+			s.logger.Infof("scheduled message %d dropped, it can't be sent to its chat anymore", sent.ScheduledID)
+			continue
+		}
+
+		s.publishMessage(events.MessageCreated, parseMessageModel(sent.Message))
+	}
+}
+
+// parseSendAt parses the time a message is scheduled for.
+func parseSendAt(sendAt string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, sendAt)
+	if err != nil {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "send_at must be an RFC3339 time")
+	}
+	if t.After(time.Now().Add(maxScheduleAhead)) {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "messages can be scheduled up to a year ahead")
+	}
+	return t, nil
+}
+
+func pinError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Errorf(codes.NotFound, "message not found in the chats of the user")
+	}
+	if errors.Is(err, repo.ErrPinNotAllowed) {
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to pin: %v", err)
+}
+
+func parseReactionModels(res []*repo.Reaction) []*pb.Reaction {
+	reactions := make([]*pb.Reaction, 0, len(res))
+	for _, r := range res {
+		reactions = append(reactions, &pb.Reaction{
+			Emoji:   r.Emoji,
+			Count:   r.Count,
+			Reacted: r.Reacted,
+		})
+	}
+	return reactions
+}
+
 func parseMessageModel(res *repo.ChatMessage) *pb.ChatMessage {
 	return &pb.ChatMessage{
-		Id:      res.ID,
-		Message: res.Message,
-		UserId:  res.UserId,
-		UserInfo: &pb.GetUserInfo{
-			FirstName: res.UserInfo.FirstName,
-			LastName:  res.UserInfo.LastName,
-			Email:     res.UserInfo.Email,
-			Username:  res.UserInfo.UserName,
-			ImageUrl:  res.UserInfo.ImageUrl,
-			CreatedAt: res.UserInfo.CreatedAt.Format(time.RFC3339),
-		},
-		ChatId:    res.ChatId,
+		Id:               res.ID,
+		Message:          res.Message,
+		UserId:           res.UserId,
+		UserInfo:         parseUserInfoModel(res.UserInfo),
+		ChatId:           res.ChatId,
+		CreatedAt:        res.CreatedAt.Format(time.RFC3339),
+		ClientMessageId:  res.ClientMessageID,
+		ReplyToMessageId: res.ReplyToMessageID,
+		ReplyTo:          parseQuotedMessageModel(res.ReplyTo),
+		ThreadRootId:     res.ThreadRootID,
+		Thread:           parseThreadSummaryModel(res.Thread),
+		Reactions:        parseReactionModels(res.Reactions),
+		ForwardedFrom:    parseForwardedFromModel(res.ForwardedFrom),
+		EditedAt:         formatTime(res.EditedAt),
+		DeletedAt:        formatTime(res.DeletedAt),
+		Type:             res.Type,
+		Pinned:           res.Pinned,
+		Attachments:      parseAttachmentModels(res.Attachments),
+		Location:         parseLocationModel(res.Location),
+		Contact:          parseContactModel(res.Contact),
+		Resent:           res.Resent,
+	}
+}
+
+func parseAttachmentModels(res []*repo.Attachment) []*pb.Attachment {
+	result := make([]*pb.Attachment, 0, len(res))
+	for _, a := range res {
+		result = append(result, parseAttachmentModel(a))
+	}
+	return result
+}
+
+func parseAttachmentModel(res *repo.Attachment) *pb.Attachment {
+	return &pb.Attachment{
+		Id:        res.ID,
+		UserId:    res.UserID,
+		Url:       res.URL,
+		FileName:  res.FileName,
+		MimeType:  res.MimeType,
+		Size:      res.Size,
+		Width:     res.Width,
+		Height:    res.Height,
+		Duration:  res.Duration,
+		CreatedAt: res.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func parseLocationModel(res *repo.Location) *pb.Location {
+	if res == nil {
+		return nil
+	}
+	return &pb.Location{
+		Latitude:  res.Latitude,
+		Longitude: res.Longitude,
+	}
+}
+
+func parseLocation(req *pb.Location) *repo.Location {
+	if req == nil {
+		return nil
+	}
+	return &repo.Location{
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	}
+}
+
+func parseContactModel(res *repo.Contact) *pb.Contact {
+	if res == nil {
+		return nil
+	}
+	return &pb.Contact{
+		PhoneNumber: res.PhoneNumber,
+		FirstName:   res.FirstName,
+		LastName:    res.LastName,
+		UserId:      res.UserID,
+	}
+}
+
+func parseContact(req *pb.Contact) *repo.Contact {
+	if req == nil {
+		return nil
+	}
+	return &repo.Contact{
+		PhoneNumber: req.PhoneNumber,
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		UserID:      req.UserId,
+	}
+}
+
+func parseScheduledMessageModel(res *repo.ScheduledMessage) *pb.ChatMessage {
+	return &pb.ChatMessage{
+		Id:               res.ID,
+		Message:          res.Message,
+		UserId:           res.UserID,
+		UserInfo:         parseUserInfoModel(res.UserInfo),
+		ChatId:           res.ChatID,
+		CreatedAt:        res.CreatedAt.Format(time.RFC3339),
+		ClientMessageId:  res.ClientMessageID,
+		ReplyToMessageId: res.ReplyToMessageID,
+		ThreadRootId:     res.ThreadRootID,
+		Type:             repo.MessageTypeText,
+		SendAt:           res.SendAt.Format(time.RFC3339),
+	}
+}
+
+// formatTime formats an optional time, empty when it is not set.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseForwardedFromModel(res *repo.ForwardedFrom) *pb.ForwardedFrom {
+	if res == nil {
+		return nil
+	}
+
+	return &pb.ForwardedFrom{
+		MessageId: res.MessageID,
+		ChatId:    res.ChatID,
+		UserId:    res.UserID,
+		UserInfo:  parseUserInfoModel(res.UserInfo),
+		CreatedAt: res.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func parseThreadSummaryModel(res *repo.ThreadSummary) *pb.ThreadSummary {
+	if res == nil {
+		return nil
+	}
+
+	result := &pb.ThreadSummary{
+		RootId:     res.RootID,
+		ReplyCount: res.ReplyCount,
+	}
+	if !res.LastReplyAt.IsZero() {
+		result.LastReplyAt = res.LastReplyAt.Format(time.RFC3339)
+	}
+
+	return result
+}
+
+func parseQuotedMessageModel(res *repo.QuotedMessage) *pb.QuotedMessage {
+	if res == nil {
+		return nil
+	}
+
+	return &pb.QuotedMessage{
+		Id:       res.ID,
+		Message:  res.Message,
+		UserId:   res.UserID,
+		UserInfo: parseUserInfoModel(res.UserInfo),
+		Deleted:  res.Deleted,
+		Type:     res.Type,
+	}
+}
+
+func parseUserInfoModel(res *repo.GetUserInfo) *pb.GetUserInfo {
+	if res == nil {
+		return nil
+	}
+
+	return &pb.GetUserInfo{
+		FirstName: res.FirstName,
+		LastName:  res.LastName,
+		Email:     res.Email,
+		Username:  res.UserName,
+		ImageUrl:  res.ImageUrl,
 		CreatedAt: res.CreatedAt.Format(time.RFC3339),
 	}
 }
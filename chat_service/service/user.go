@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	pb "gitlab.com/telegram_clone/chat_service/genproto/chat_service"
@@ -16,6 +18,14 @@ import (
 	"gitlab.com/telegram_clone/chat_service/storage"
 )
 
+// Keys the websocket service keeps the presence of the users under. The
+// online key is a sorted set of the replicas the user is connected to, scored
+// by when their entry expires in unix milliseconds.
+const (
+	presenceOnlineKey   = "presence:online:%d"
+	presenceLastSeenKey = "presence:last_seen:%d"
+)
+
 type UserService struct {
 	pb.UnimplementedUserServiceServer
 	storage  storage.StorageI
@@ -60,7 +70,69 @@ func (s *UserService) Get(ctx context.Context, req *pb.GetUserRequest) (*pb.User
 		return nil, status.Errorf(codes.Internal, "failed to get: %v", err)
 	}
 
-	return parseUserModel(user), nil
+	result := parseUserModel(user)
+	if err := s.setPresence(result, user.LastSeenVisibility, req.RequesterId); err != nil {
+		s.logger.WithError(err).Error("failed to check last seen visibility")
+		return nil, status.Errorf(codes.Internal, "failed to get: %v", err)
+	}
+
+	return result, nil
+}
+
+// setPresence fills in whether the user is online and when it was last seen
+// if its privacy settings let the requester see it.
+func (s *UserService) setPresence(user *pb.User, visibility string, requesterID int64) error {
+	visible := false
+	switch {
+	case requesterID == user.Id:
+		visible = true
+		user.LastSeenVisibility = visibility
+	case visibility == repo.LastSeenEverybody:
+		visible = true
+	case visibility == repo.LastSeenChats && requesterID != 0:
+		common, err := s.storage.Chat().HaveCommonChat(user.Id, requesterID)
+		if err != nil {
+			return err
+		}
+		visible = common
+	}
+	if !visible {
+		return nil
+	}
+
+	// The user is online through the replicas whose entry hasn't expired,
+	// missing keys mean the user has never connected.
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if replicas, err := s.inMemory.Count(fmt.Sprintf(presenceOnlineKey, user.Id), "("+now, "+inf"); err == nil {
+		user.Online = replicas > 0
+	}
+	if lastSeen, err := s.inMemory.Get(fmt.Sprintf(presenceLastSeenKey, user.Id)); err == nil {
+		user.LastSeen = lastSeen
+	}
+
+	return nil
+}
+
+func (s *UserService) SetLastSeenVisibility(ctx context.Context, req *pb.SetLastSeenVisibilityRequest) (*pb.User, error) {
+	switch req.Visibility {
+	case repo.LastSeenEverybody, repo.LastSeenChats, repo.LastSeenNobody:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown last seen visibility %q", req.Visibility)
+	}
+
+	user, err := s.storage.User().SetLastSeenVisibility(req.UserId, req.Visibility)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to set last seen visibility")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to set last seen visibility: %v", err)
+	}
+
+	result := parseUserModel(user)
+	result.LastSeenVisibility = user.LastSeenVisibility
+
+	return result, nil
 }
 
 func (s *UserService) GetByEmail(ctx context.Context, req *pb.GetByEmailRequest) (*pb.User, error) {
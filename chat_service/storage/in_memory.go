@@ -10,6 +10,9 @@ import (
 type InMemoryStorageI interface {
 	Set(key, value string, exp time.Duration) error
 	Get(key string) (string, error)
+	// Count returns the number of members of the sorted set scored between
+	// min and max.
+	Count(key, min, max string) (int64, error)
 }
 
 type storageRedis struct {
@@ -37,3 +40,7 @@ func (r *storageRedis) Get(key string) (string, error) {
 	}
 	return val, nil
 }
+
+func (r *storageRedis) Count(key, min, max string) (int64, error) {
+	return r.client.ZCount(context.Background(), key, min, max).Result()
+}
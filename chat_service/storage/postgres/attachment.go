@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gitlab.com/telegram_clone/chat_service/pkg/utils"
+	"gitlab.com/telegram_clone/chat_service/storage/repo"
+)
+
+type attachmentRepo struct {
+	db *sqlx.DB
+}
+
+func NewAttachment(db *sqlx.DB) repo.AttachmentStorageI {
+	return &attachmentRepo{
+		db: db,
+	}
+}
+
+const attachmentColumns = `
+	id,
+	user_id,
+	message_id,
+	url,
+	file_name,
+	mime_type,
+	size,
+	width,
+	height,
+	duration,
+	created_at
+`
+
+// Create stores an uploaded file, it is attached to a message of the
+// uploader later.
+func (pr *attachmentRepo) Create(a *repo.Attachment) (*repo.Attachment, error) {
+	row := pr.db.QueryRow(`
+		INSERT INTO attachments (
+			user_id,
+			url,
+			file_name,
+			mime_type,
+			size,
+			width,
+			height,
+			duration
+		) VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+attachmentColumns,
+		a.UserID,
+		a.URL,
+		a.FileName,
+		a.MimeType,
+		a.Size,
+		utils.NullInt32(a.Width),
+		utils.NullInt32(a.Height),
+		utils.NullInt32(a.Duration),
+	)
+
+	return scanAttachment(row)
+}
+
+func (pr *attachmentRepo) GetMedia(url string, userID int64) (*repo.Attachment, error) {
+	// Files uploaded before the storage drivers are kept as /media/<key>
+	row := pr.db.QueryRow(`
+		SELECT `+attachmentColumns+`
+		FROM attachments a
+		WHERE url IN ($1, '/media/' || $1) AND (
+			$2 = 0 OR user_id = $2 OR EXISTS(
+				SELECT 1 FROM chat_messages m
+				INNER JOIN chat_members cm ON cm.chat_id=m.chat_id
+				WHERE m.id=a.message_id AND cm.user_id=$2
+			)
+		)
+		ORDER BY id
+		LIMIT 1
+	`, url, userID)
+
+	attachment, err := scanAttachment(row)
+	if err != sql.ErrNoRows || userID != 0 {
+		return attachment, err
+	}
+
+	var exists bool
+	err = pr.db.QueryRow(`
+		SELECT
+			EXISTS(SELECT 1 FROM users WHERE profile_image_url IN ($1, '/media/' || $1)) OR
+			EXISTS(SELECT 1 FROM chats WHERE image_url IN ($1, '/media/' || $1))
+	`, url).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	return &repo.Attachment{URL: url}, nil
+}
+
+// attachmentFits tells whether a file of the mime type can be sent with a
+// message of the type.
+func attachmentFits(messageType, mimeType string) bool {
+	switch messageType {
+	case repo.MessageTypePhoto:
+		return strings.HasPrefix(mimeType, "image/")
+	case repo.MessageTypeSticker:
+		return mimeType == "image/webp" || mimeType == "image/png"
+	case repo.MessageTypeVideo:
+		return strings.HasPrefix(mimeType, "video/")
+	case repo.MessageTypeVoice:
+		return strings.HasPrefix(mimeType, "audio/") || mimeType == "application/ogg"
+	case repo.MessageTypeFile:
+		return true
+	}
+	return false
+}
+
+// attachToMessage attaches the uploads named by the message to it, in the
+// order they are named. repo.ErrInvalidAttachment is returned when one of
+// them wasn't uploaded by the author, is already sent or doesn't fit the
+// type of the message.
+func attachToMessage(tx *sql.Tx, message *repo.ChatMessage) error {
+	if len(message.AttachmentIDs) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`
+		UPDATE attachments SET
+			message_id=$1,
+			position=array_position($3::int[], id)
+		WHERE id = ANY($3) AND user_id=$2 AND message_id IS NULL
+		RETURNING mime_type
+	`, message.ID, message.UserId, pq.Array(message.AttachmentIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	attached := 0
+	for rows.Next() {
+		var mimeType string
+		if err := rows.Scan(&mimeType); err != nil {
+			return err
+		}
+		if !attachmentFits(message.Type, mimeType) {
+			return repo.ErrInvalidAttachment
+		}
+		attached++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if attached != len(message.AttachmentIDs) {
+		return repo.ErrInvalidAttachment
+	}
+
+	return nil
+}
+
+// copyAttachments attaches the media of a message to its forwarded copy,
+// the files are shared.
+func copyAttachments(tx *sql.Tx, fromID, toID, userID int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO attachments (
+			user_id,
+			message_id,
+			position,
+			url,
+			file_name,
+			mime_type,
+			size,
+			width,
+			height,
+			duration
+		)
+		SELECT $3, $2, position, url, file_name, mime_type, size, width, height, duration
+		FROM attachments WHERE message_id=$1
+	`, fromID, toID, userID)
+	return err
+}
+
+// loadAttachments sets the attachments of the messages.
+func loadAttachments(db *sqlx.DB, messages []*repo.ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*repo.ChatMessage, len(messages))
+	ids := make([]int64, 0, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+		ids = append(ids, m.ID)
+	}
+
+	rows, err := db.Query(`
+		SELECT `+attachmentColumns+`
+		FROM attachments
+		WHERE message_id = ANY($1)
+		ORDER BY message_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return err
+		}
+		m := byID[a.MessageID]
+		m.Attachments = append(m.Attachments, a)
+	}
+
+	return rows.Err()
+}
+
+func scanAttachment(row interface{ Scan(dest ...any) error }) (*repo.Attachment, error) {
+	var (
+		a                       repo.Attachment
+		messageID               sql.NullInt64
+		width, height, duration sql.NullInt32
+	)
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&messageID,
+		&a.URL,
+		&a.FileName,
+		&a.MimeType,
+		&a.Size,
+		&width,
+		&height,
+		&duration,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.MessageID = messageID.Int64
+	a.Width = width.Int32
+	a.Height = height.Int32
+	a.Duration = duration.Int32
+
+	return &a, nil
+}
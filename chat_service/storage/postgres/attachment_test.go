@@ -0,0 +1,174 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/bxcodec/faker/v4"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/chat_service/storage/repo"
+)
+
+func createAttachment(t *testing.T, userID int64, mimeType string) *repo.Attachment {
+	a, err := strg.Attachment().Create(&repo.Attachment{
+		UserID:   userID,
+		URL:      "/media/" + faker.UUIDHyphenated(),
+		FileName: faker.Word(),
+		MimeType: mimeType,
+		Size:     2048,
+		Width:    640,
+		Height:   480,
+	})
+	require.NoError(t, err)
+	require.Zero(t, a.MessageID)
+
+	return a
+}
+
+func TestMessageWithAttachments(t *testing.T) {
+	chat := createChat(t)
+	first := createAttachment(t, chat.UserID, "image/jpeg")
+	second := createAttachment(t, chat.UserID, "image/png")
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:       "holidays",
+		UserId:        chat.UserID,
+		ChatId:        chat.ID,
+		Type:          repo.MessageTypePhoto,
+		AttachmentIDs: []int64{second.ID, first.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, repo.MessageTypePhoto, message.Type)
+	require.Len(t, message.Attachments, 2)
+	require.Equal(t, second.ID, message.Attachments[0].ID)
+	require.Equal(t, int32(640), message.Attachments[1].Width)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages.Messages[0].Attachments, 2)
+	require.Equal(t, "image/png", messages.Messages[0].Attachments[0].MimeType)
+
+	// An attachment is sent once
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		UserId:        chat.UserID,
+		ChatId:        chat.ID,
+		Type:          repo.MessageTypePhoto,
+		AttachmentIDs: []int64{first.ID},
+	})
+	require.ErrorIs(t, err, repo.ErrInvalidAttachment)
+
+	// by its uploader
+	member := createUser(t)
+	other := createChat(t, member.ID)
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		UserId:        member.ID,
+		ChatId:        other.ID,
+		Type:          repo.MessageTypeFile,
+		AttachmentIDs: []int64{createAttachment(t, other.UserID, "application/pdf").ID},
+	})
+	require.ErrorIs(t, err, repo.ErrInvalidAttachment)
+
+	// with a message of a fitting type
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		UserId:        chat.UserID,
+		ChatId:        chat.ID,
+		Type:          repo.MessageTypeVoice,
+		AttachmentIDs: []int64{createAttachment(t, chat.UserID, "image/jpeg").ID},
+	})
+	require.ErrorIs(t, err, repo.ErrInvalidAttachment)
+
+	forwarded, err := strg.ChatMessage().Forward(&repo.ForwardParams{
+		MessageIDs: []int64{message.ID},
+		ChatID:     chat.ID,
+		UserID:     chat.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, repo.MessageTypePhoto, forwarded[0].Type)
+	require.Len(t, forwarded[0].Attachments, 2)
+	require.Equal(t, message.Attachments[0].URL, forwarded[0].Attachments[0].URL)
+}
+
+func TestLocationMessage(t *testing.T) {
+	chat := createChat(t)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		UserId: chat.UserID,
+		ChatId: chat.ID,
+		Type:   repo.MessageTypeLocation,
+		Location: &repo.Location{
+			Latitude:  41.311081,
+			Longitude: 69.240562,
+		},
+	})
+	require.NoError(t, err)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, message.ID, messages.Messages[0].ID)
+	require.Equal(t, message.Location, messages.Messages[0].Location)
+	require.Nil(t, messages.Messages[0].Contact)
+}
+
+func TestGetMedia(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+	stranger := createUser(t)
+
+	attachment := createAttachment(t, chat.UserID, "image/jpeg")
+	key := strings.TrimPrefix(attachment.URL, "/media/")
+
+	// Until it is sent only its uploader finds it
+	found, err := strg.Attachment().GetMedia(key, chat.UserID)
+	require.NoError(t, err)
+	require.Equal(t, attachment.ID, found.ID)
+	_, err = strg.Attachment().GetMedia(key, member.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		UserId:        chat.UserID,
+		ChatId:        chat.ID,
+		Type:          repo.MessageTypePhoto,
+		AttachmentIDs: []int64{attachment.ID},
+	})
+	require.NoError(t, err)
+
+	found, err = strg.Attachment().GetMedia(key, member.ID)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", found.MimeType)
+	_, err = strg.Attachment().GetMedia(key, stranger.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = strg.Attachment().GetMedia(key, 0)
+	require.NoError(t, err)
+
+	// Deleting the message revokes the file
+	_, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{message.ID},
+		UserID:     chat.UserID,
+	})
+	require.NoError(t, err)
+	_, err = strg.Attachment().GetMedia(key, 0)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Profile images are found without a user only
+	avatar := faker.UUIDHyphenated() + ".png"
+	_, err = strg.User().SetUserImage(&repo.SetUserImageRequest{
+		UserId:   stranger.ID,
+		ImageUrl: avatar,
+	})
+	require.NoError(t, err)
+	found, err = strg.Attachment().GetMedia(avatar, 0)
+	require.NoError(t, err)
+	require.Equal(t, avatar, found.URL)
+	_, err = strg.Attachment().GetMedia(avatar, stranger.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"gitlab.com/telegram_clone/chat_service/pkg/utils"
 	"gitlab.com/telegram_clone/chat_service/storage/repo"
 )
@@ -96,7 +97,11 @@ func (pr *chatRepo) Get(id int64) (*repo.Chat, error) {
 			name,
 			user_id,
 			chat_type,
-			image_url
+			image_url,
+			allowed_reactions,
+			disallow_forwarding,
+			edit_window_seconds,
+			delete_window_seconds
 		FROM chats
 		WHERE id=$1
 	`
@@ -110,6 +115,10 @@ func (pr *chatRepo) Get(id int64) (*repo.Chat, error) {
 		&chat.UserID,
 		&chat.ChatType,
 		&imageUrl,
+		pq.Array(&chat.Settings.AllowedReactions),
+		&chat.Settings.DisallowForwarding,
+		&chat.Settings.EditWindowSeconds,
+		&chat.Settings.DeleteWindowSeconds,
 	)
 	if err != nil {
 		return nil, err
@@ -130,7 +139,11 @@ func (pr *chatRepo) Update(chat *repo.Chat) (*repo.Chat, error) {
 			image_url=$2
 		WHERE id=$3 and user_id = $4
 		RETURNING 
-			chat_type		
+			chat_type,
+			allowed_reactions,
+			disallow_forwarding,
+			edit_window_seconds,
+			delete_window_seconds
 	`
 
 	err := pr.db.QueryRow(
@@ -141,6 +154,10 @@ func (pr *chatRepo) Update(chat *repo.Chat) (*repo.Chat, error) {
 		chat.UserID,
 	).Scan(
 		&chat.ChatType,
+		pq.Array(&chat.Settings.AllowedReactions),
+		&chat.Settings.DisallowForwarding,
+		&chat.Settings.EditWindowSeconds,
+		&chat.Settings.DeleteWindowSeconds,
 	)
 	if err != nil {
 		return nil, err
@@ -167,39 +184,140 @@ func (pr *chatRepo) Delete(id, user_id int64) error {
 	return nil
 }
 
+// UpdateSettings replaces the settings of the chat. sql.ErrNoRows is returned
+// when the user doesn't own the chat.
+func (pr *chatRepo) UpdateSettings(req *repo.UpdateChatSettingsReq) error {
+	query := `
+		UPDATE chats SET
+			allowed_reactions=$1,
+			disallow_forwarding=$2,
+			edit_window_seconds=$3,
+			delete_window_seconds=$4
+		WHERE id=$5 and user_id = $6
+	`
+
+	result, err := pr.db.Exec(
+		query,
+		pq.Array(req.Settings.AllowedReactions),
+		req.Settings.DisallowForwarding,
+		req.Settings.EditWindowSeconds,
+		req.Settings.DeleteWindowSeconds,
+		req.ChatID,
+		req.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if res, _ := result.RowsAffected(); res == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAll returns the chats of the user, most recently active first, with
+// their last message and the unread counts of the user in one query. A chat
+// without messages is active since the user joined it. Deleted messages,
+// thread replies and the ones the user hid are neither the last message nor
+// counted as unread. Without a user every chat is returned, with nothing
+// unread.
 func (pr *chatRepo) GetAll(params *repo.GetAllChatsParams) (*repo.GetAllChats, error) {
-	var imageUrl sql.NullString
 	result := repo.GetAllChats{
 		Chats: make([]*repo.Chat, 0),
 	}
 
 	offset := (params.Page - 1) * params.Limit
 
-	limit := fmt.Sprintf(" LIMIT %d OFFSET %d ", params.Limit, offset)
-
+	// No member has the id 0, so cm is empty for every chat without a user
 	filter := ""
 	if params.UserID > 0 {
-		filter = fmt.Sprintf(" WHERE cm.user_id = %d ", params.UserID)
+		filter = " WHERE cm.user_id=$1 "
 	}
 
 	query := `
-		SELECT 
+		SELECT
 			c.id,
-			c.name ,
-			c.user_id ,
-			c.chat_type ,
-			c.image_url
-		FROM chats c INNER JOIN chat_members cm ON cm.chat_id = c.id
-	` + filter + limit
-
-	rows, err := pr.db.Query(query)
+			c.name,
+			c.user_id,
+			c.chat_type,
+			c.image_url,
+			c.allowed_reactions,
+			c.disallow_forwarding,
+			c.edit_window_seconds,
+			c.delete_window_seconds,
+			owner.first_name,
+			owner.last_name,
+			owner.email,
+			owner.username,
+			owner.profile_image_url,
+			owner.created_at,
+			lm.id,
+			lm.message,
+			lm.type,
+			lm.user_id,
+			lm.created_at,
+			sender.first_name,
+			sender.last_name,
+			sender.email,
+			sender.username,
+			sender.profile_image_url,
+			sender.created_at,
+			(
+				SELECT count(1) FROM chat_messages m
+				WHERE m.chat_id=c.id AND m.id>cm.last_read_message_id AND m.user_id<>cm.user_id
+					AND m.thread_root_id IS NULL AND m.deleted_at IS NULL
+					AND NOT EXISTS (
+						SELECT 1 FROM hidden_messages h WHERE h.message_id=m.id AND h.user_id=cm.user_id
+					)
+			) AS unread_count,
+			(
+				SELECT count(1) FROM chat_message_mentions mm
+				INNER JOIN chat_messages m ON m.id=mm.message_id
+				WHERE mm.user_id=cm.user_id AND m.chat_id=c.id AND m.id>cm.last_read_message_id
+					AND m.thread_root_id IS NULL AND m.deleted_at IS NULL
+					AND NOT EXISTS (
+						SELECT 1 FROM hidden_messages h WHERE h.message_id=m.id AND h.user_id=cm.user_id
+					)
+			) AS unread_mention_count,
+			COALESCE(lm.created_at, cm.created_at) AS last_activity_at
+		FROM chats c
+		LEFT JOIN chat_members cm ON cm.chat_id=c.id AND cm.user_id=$1
+		INNER JOIN users owner ON owner.id=c.user_id
+		LEFT JOIN LATERAL (
+			SELECT id, message, type, user_id, created_at FROM chat_messages m
+			WHERE chat_id=c.id AND thread_root_id IS NULL AND deleted_at IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM hidden_messages h WHERE h.message_id=m.id AND h.user_id=cm.user_id
+				)
+			ORDER BY id DESC
+			LIMIT 1
+		) lm ON true
+		LEFT JOIN users sender ON sender.id=lm.user_id
+	` + filter + `
+		ORDER BY last_activity_at DESC NULLS LAST, c.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := pr.db.Query(query, params.UserID, params.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var chat repo.Chat
+		var (
+			chat  repo.Chat
+			owner repo.GetUserInfo
+
+			imageUrl, ownerUsername, ownerImageUrl sql.NullString
+
+			lastMessageID, lastSenderID                  sql.NullInt64
+			lastMessage, senderFirstName, senderLastName sql.NullString
+			senderEmail, senderUsername, senderImageUrl  sql.NullString
+			lastMessageType                              sql.NullString
+			lastMessageCreatedAt, senderCreatedAt        sql.NullTime
+			lastActivityAt                               sql.NullTime
+		)
 
 		err := rows.Scan(
 			&chat.ID,
@@ -207,21 +325,67 @@ func (pr *chatRepo) GetAll(params *repo.GetAllChatsParams) (*repo.GetAllChats, e
 			&chat.UserID,
 			&chat.ChatType,
 			&imageUrl,
+			pq.Array(&chat.Settings.AllowedReactions),
+			&chat.Settings.DisallowForwarding,
+			&chat.Settings.EditWindowSeconds,
+			&chat.Settings.DeleteWindowSeconds,
+			&owner.FirstName,
+			&owner.LastName,
+			&owner.Email,
+			&ownerUsername,
+			&ownerImageUrl,
+			&owner.CreatedAt,
+			&lastMessageID,
+			&lastMessage,
+			&lastMessageType,
+			&lastSenderID,
+			&lastMessageCreatedAt,
+			&senderFirstName,
+			&senderLastName,
+			&senderEmail,
+			&senderUsername,
+			&senderImageUrl,
+			&senderCreatedAt,
+			&chat.UnreadCount,
+			&chat.UnreadMentionCount,
+			&lastActivityAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		chat.ImageUrl = imageUrl.String
-		chat.UserInfo, err = getUserInfo(pr.db, chat.UserID)
-		if err != nil {
-			return nil, err
+		chat.LastActivityAt = lastActivityAt.Time
+		owner.UserName = ownerUsername.String
+		owner.ImageUrl = ownerImageUrl.String
+		chat.UserInfo = &owner
+
+		if lastMessageID.Valid {
+			chat.LastMessage = &repo.LastMessage{
+				ID:        lastMessageID.Int64,
+				Message:   lastMessage.String,
+				Type:      lastMessageType.String,
+				UserID:    lastSenderID.Int64,
+				CreatedAt: lastMessageCreatedAt.Time,
+				UserInfo: &repo.GetUserInfo{
+					FirstName: senderFirstName.String,
+					LastName:  senderLastName.String,
+					Email:     senderEmail.String,
+					UserName:  senderUsername.String,
+					ImageUrl:  senderImageUrl.String,
+					CreatedAt: senderCreatedAt.Time,
+				},
+			}
 		}
+
 		result.Chats = append(result.Chats, &chat)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	queryCount := ` SELECT count(1) FROM chats c INNER JOIN chat_members cm ON cm.chat_id = c.id ` + filter
-	err = pr.db.QueryRow(queryCount).Scan(&result.Count)
+	queryCount := "SELECT count(1) FROM chats c LEFT JOIN chat_members cm ON cm.chat_id=c.id AND cm.user_id=$1" + filter
+	err = pr.db.QueryRow(queryCount, params.UserID).Scan(&result.Count)
 	if err != nil {
 		return nil, err
 	}
@@ -304,6 +468,7 @@ func (ur *chatRepo) GetChatMembers(params *repo.GetChatMembersParams) (*repo.Get
 
 	limit := fmt.Sprintf(" LIMIT %d OFFSET %d ", params.Limit, offset)
 
+	// The id orders namesakes, pages don't skip or repeat members
 	query := `
 		SELECT
 			u.id,
@@ -316,7 +481,7 @@ func (ur *chatRepo) GetChatMembers(params *repo.GetChatMembersParams) (*repo.Get
 		FROM users u
 		INNER JOIN chat_members cm ON cm.user_id=u.id
 		WHERE cm.chat_id=$1
-		ORDER BY u.first_name ASC, u.last_name ASC
+		ORDER BY u.first_name ASC, u.last_name ASC, u.id ASC
 		` + limit
 
 	rows, err := ur.db.Query(query, params.ChatID)
@@ -362,3 +527,47 @@ func (ur *chatRepo) GetChatMembers(params *repo.GetChatMembersParams) (*repo.Get
 
 	return &result, nil
 }
+
+func (cr *chatRepo) GetChatPeers(userID int64) ([]int64, error) {
+	query := `
+		SELECT DISTINCT peer.user_id FROM chat_members cm
+		INNER JOIN chat_members peer ON peer.chat_id=cm.chat_id
+		WHERE cm.user_id=$1 AND peer.user_id<>$1
+	`
+
+	rows, err := cr.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		result = append(result, id)
+	}
+
+	return result, rows.Err()
+}
+
+func (cr *chatRepo) HaveCommonChat(userID, otherUserID int64) (bool, error) {
+	var exists bool
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM chat_members cm
+			INNER JOIN chat_members other ON other.chat_id=cm.chat_id
+			WHERE cm.user_id=$1 AND other.user_id=$2
+		)
+	`
+
+	err := cr.db.QueryRow(query, userID, otherUserID).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
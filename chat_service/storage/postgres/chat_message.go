@@ -2,12 +2,24 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gitlab.com/telegram_clone/chat_service/pkg/utils"
 	"gitlab.com/telegram_clone/chat_service/storage/repo"
 )
 
+// Number of characters of a replied message shown in the preview.
+const quotePreviewLength = 100
+
+// Text of the system message appended to a chat when a message is pinned,
+// it replies to the pinned message.
+const pinnedSystemMessage = "pinned a message"
+
 type chatMessageRepo struct {
 	db *sqlx.DB
 }
@@ -18,47 +30,262 @@ func NewChatMessage(db *sqlx.DB) repo.ChatMessageStrogeI {
 	}
 }
 
+// Create inserts the message. A message resent with the same client message
+// id by the same user is not inserted again, the persisted one is returned
+// with Resent set. ErrClientMessageIDReused is returned when the persisted
+// one is in another chat.
 func (pr *chatMessageRepo) Create(message *repo.ChatMessage) (*repo.ChatMessage, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := createMessage(tx, message); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	message.UserInfo, err = getUserInfo(pr.db, message.UserId)
+	if err != nil {
+		return nil, err
+	}
+	message.ReplyTo, err = getQuotedMessage(pr.db, message.ReplyToMessageID)
+	if err != nil {
+		return nil, err
+	}
+	message.Thread, err = getThreadSummary(pr.db, message.ThreadRootID)
+	if err != nil {
+		return nil, err
+	}
+	message.Attachments = nil
+	if err := loadAttachments(pr.db, []*repo.ChatMessage{message}); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// createMessage inserts the message within the transaction, see Create.
+func createMessage(tx *sql.Tx, message *repo.ChatMessage) error {
+	if err := checkReferences(tx, message); err != nil {
+		return err
+	}
+
+	if message.Type == "" {
+		message.Type = repo.MessageTypeText
+	}
+	content, err := encodeContent(message)
+	if err != nil {
+		return err
+	}
+
+	// xmax is 0 for an inserted row, it is set when a resent message
+	// conflicts with the persisted one.
 	query := `
 		INSERT INTO chat_messages (
 			message,
 			user_id,
-			chat_id
-		) VALUES($1, $2, $3)
-		RETURNING id, created_at
+			chat_id,
+			client_message_id,
+			reply_to_message_id,
+			thread_root_id,
+			type,
+			content
+		) VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, client_message_id) DO UPDATE SET
+			client_message_id = EXCLUDED.client_message_id
+		RETURNING
+			id,
+			message,
+			chat_id,
+			created_at,
+			reply_to_message_id,
+			thread_root_id,
+			type,
+			content,
+			EXISTS (SELECT 1 FROM pinned_messages pm WHERE pm.message_id=chat_messages.id),
+			xmax = 0
 	`
 
-	err := pr.db.QueryRow(
+	var (
+		replyToMessageID, threadRootID sql.NullInt64
+		inserted                       bool
+		chatID                         = message.ChatId
+	)
+	err = tx.QueryRow(
 		query,
 		message.Message,
 		message.UserId,
 		message.ChatId,
+		utils.NullString(message.ClientMessageID),
+		utils.NullInt64(message.ReplyToMessageID),
+		utils.NullInt64(message.ThreadRootID),
+		message.Type,
+		utils.NullString(string(content)),
 	).Scan(
 		&message.ID,
+		&message.Message,
+		&message.ChatId,
 		&message.CreatedAt,
+		&replyToMessageID,
+		&threadRootID,
+		&message.Type,
+		&content,
+		&message.Pinned,
+		&inserted,
 	)
+	if err != nil {
+		return err
+	}
+	if !inserted && message.ChatId != chatID {
+		return repo.ErrClientMessageIDReused
+	}
+	message.ReplyToMessageID = replyToMessageID.Int64
+	message.ThreadRootID = threadRootID.Int64
+	if err := decodeContent(content, message); err != nil {
+		return err
+	}
+
+	if !inserted {
+		message.Resent = true
+		return nil
+	}
+
+	if err := attachToMessage(tx, message); err != nil {
+		return err
+	}
+
+	if message.ThreadRootID > 0 {
+		_, err := tx.Exec(`
+			UPDATE chat_messages SET
+				thread_reply_count = thread_reply_count + 1,
+				thread_last_reply_at = $2
+			WHERE id=$1
+		`, message.ThreadRootID, message.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertMentions(tx, message)
+}
+
+// checkReferences makes sure the message replies to, or is posted in the
+// thread of, a message of its chat.
+func checkReferences(tx *sql.Tx, message *repo.ChatMessage) error {
+	if message.ReplyToMessageID > 0 {
+		var exists bool
+		err := tx.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM chat_messages WHERE id=$1 AND chat_id=$2 AND deleted_at IS NULL)",
+			message.ReplyToMessageID,
+			message.ChatId,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return repo.ErrReplyNotInChat
+		}
+	}
+
+	if message.ThreadRootID > 0 {
+		// The root is locked until the reply is counted, concurrent replies
+		// would lose updates otherwise.
+		var rootThreadID sql.NullInt64
+		err := tx.QueryRow(
+			"SELECT thread_root_id FROM chat_messages WHERE id=$1 AND chat_id=$2 AND deleted_at IS NULL FOR UPDATE",
+			message.ThreadRootID,
+			message.ChatId,
+		).Scan(&rootThreadID)
+		if errors.Is(err, sql.ErrNoRows) || rootThreadID.Valid {
+			return repo.ErrInvalidThreadRoot
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update replaces the text of the message, the previous one is kept as a
+// revision. ErrEditWindowExpired is returned once the edit window of the
+// chat is over.
+func (pr *chatMessageRepo) Update(message *repo.ChatMessage) (*repo.ChatMessage, error) {
+	tx, err := pr.db.Begin()
 	if err != nil {
 		return nil, err
 	}
-	message.UserInfo, err = getUserInfo(pr.db, message.UserId)
+	defer tx.Rollback()
+
+	var (
+		previous          string
+		previousWrittenAt time.Time
+		expired           bool
+	)
+	err = tx.QueryRow(`
+		SELECT
+			m.message,
+			COALESCE(m.edited_at, m.created_at),
+			c.edit_window_seconds > 0 AND m.created_at < now() - make_interval(secs => c.edit_window_seconds)
+		FROM chat_messages m
+		INNER JOIN chats c ON c.id=m.chat_id
+		WHERE m.id=$1 AND m.user_id=$2 AND m.deleted_at IS NULL AND m.type<>'system'
+		FOR UPDATE OF m
+	`, message.ID, message.UserId).Scan(&previous, &previousWrittenAt, &expired)
 	if err != nil {
 		return nil, err
 	}
+	if expired {
+		return nil, repo.ErrEditWindowExpired
+	}
 
-	return message, nil
-}
+	// Saving the same text again is not an edit.
+	if previous != message.Message {
+		_, err := tx.Exec(
+			"INSERT INTO message_revisions (message_id, message, created_at) VALUES($1, $2, $3)",
+			message.ID,
+			previous,
+			previousWrittenAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-func (pr *chatMessageRepo) Update(message *repo.ChatMessage) (*repo.ChatMessage, error) {
 	query := `
 		UPDATE chat_messages SET
-			message = $1
+			message = $1,
+			edited_at = CASE WHEN message = $1 THEN edited_at ELSE CURRENT_TIMESTAMP END
 		WHERE id=$2 and user_id=$3
 		RETURNING
 			chat_id,
-			created_at
+			created_at,
+			client_message_id,
+			reply_to_message_id,
+			thread_root_id,
+			thread_reply_count,
+			thread_last_reply_at,
+			forwarded_from_message_id,
+			forwarded_from_chat_id,
+			forwarded_from_user_id,
+			forwarded_from_created_at,
+			edited_at,
+			type,
+			EXISTS (SELECT 1 FROM pinned_messages pm WHERE pm.message_id=chat_messages.id)
 	`
 
-	err := pr.db.QueryRow(
+	var (
+		clientMessageID                sql.NullString
+		replyToMessageID, threadRootID sql.NullInt64
+		threadReplyCount               int64
+		threadLastReplyAt, editedAt    sql.NullTime
+		forwarded                      forwardedColumns
+	)
+	err = tx.QueryRow(
 		query,
 		message.Message,
 		message.ID,
@@ -66,88 +293,297 @@ func (pr *chatMessageRepo) Update(message *repo.ChatMessage) (*repo.ChatMessage,
 	).Scan(
 		&message.ChatId,
 		&message.CreatedAt,
+		&clientMessageID,
+		&replyToMessageID,
+		&threadRootID,
+		&threadReplyCount,
+		&threadLastReplyAt,
+		&forwarded.messageID,
+		&forwarded.chatID,
+		&forwarded.userID,
+		&forwarded.createdAt,
+		&editedAt,
+		&message.Type,
+		&message.Pinned,
 	)
 	if err != nil {
 		return nil, err
 	}
+	message.ClientMessageID = clientMessageID.String
+	message.EditedAt = nullTime(editedAt)
+	message.ReplyToMessageID = replyToMessageID.Int64
+	message.ThreadRootID = threadRootID.Int64
+	message.Thread = threadSummary(message.ID, threadReplyCount, threadLastReplyAt)
+
+	_, err = tx.Exec("DELETE FROM chat_message_mentions WHERE message_id=$1", message.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertMentions(tx, message); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	message.UserInfo, err = getUserInfo(pr.db, message.UserId)
 	if err != nil {
 		return nil, err
 	}
+	message.ReplyTo, err = getQuotedMessage(pr.db, message.ReplyToMessageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.ThreadRootID > 0 {
+		message.Thread, err = getThreadSummary(pr.db, message.ThreadRootID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := pr.loadReactions([]*repo.ChatMessage{message}, message.UserId); err != nil {
+		return nil, err
+	}
+	message.ForwardedFrom, err = forwarded.load(pr.db)
+	if err != nil {
+		return nil, err
+	}
 
 	return message, nil
 }
 
-func (pr *chatMessageRepo) Delete(id, user_id int64) error {
-	query := " DELETE FROM chat_messages WHERE id=$1 and user_id = $2 "
+// Delete deletes the messages for everyone or hides them for the user, all
+// or none of them. A message deleted for everyone is kept as a tombstone
+// without its text, mentions, reactions and revisions, and the counters of
+// its thread root are recomputed. sql.ErrNoRows is returned when the user
+// can't delete one of the messages. Messages already deleted are left out of
+// the result.
+func (pr *chatMessageRepo) Delete(params *repo.DeleteMessagesParams) ([]*repo.DeletedMessage, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	result, err := pr.db.Exec(query, id, user_id)
+	var deleted []*repo.DeletedMessage
+	if params.OnlyMe {
+		deleted, err = hideMessages(tx, params)
+	} else {
+		deleted, err = tombstoneMessages(tx, params)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if res, _ := result.RowsAffected(); res == 0 {
-		return sql.ErrNoRows
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return deleted, nil
 }
 
-func (pr *chatMessageRepo) GetAll(params *repo.GetAllMessagesParams) (*repo.GetAllMessages, error) {
-	result := repo.GetAllMessages{
-		Messages: make([]*repo.ChatMessage, 0),
+// hideMessages hides the messages of the chats of the user for the user.
+func hideMessages(tx *sql.Tx, params *repo.DeleteMessagesParams) ([]*repo.DeletedMessage, error) {
+	var member int
+	err := tx.QueryRow(`
+		SELECT count(1) FROM chat_messages m
+		INNER JOIN chat_members cm ON cm.chat_id=m.chat_id AND cm.user_id=$2
+		WHERE m.id = ANY($1)
+	`, pq.Array(params.MessageIDs), params.UserID).Scan(&member)
+	if err != nil {
+		return nil, err
+	}
+	if member != len(params.MessageIDs) {
+		return nil, sql.ErrNoRows
 	}
 
-	offset := (params.Page - 1) * params.Limit
+	rows, err := tx.Query(`
+		WITH hidden AS (
+			INSERT INTO hidden_messages (message_id, user_id)
+			SELECT id, $2::int FROM unnest($1::int[]) id
+			ON CONFLICT DO NOTHING
+			RETURNING message_id
+		)
+		SELECT m.id, m.chat_id FROM chat_messages m
+		INNER JOIN hidden h ON h.message_id=m.id
+		ORDER BY m.id
+	`, pq.Array(params.MessageIDs), params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	limit := fmt.Sprintf(" LIMIT %d OFFSET %d ", params.Limit, offset)
-	filter := ""
-	if params.ChatId > 0 {
-		filter = fmt.Sprintf(" WHERE chat_id = %d ", params.ChatId)
+	var deleted []*repo.DeletedMessage
+	for rows.Next() {
+		var m repo.DeletedMessage
+		if err := rows.Scan(&m.ID, &m.ChatID); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, &m)
 	}
 
-	query := `
-		SELECT
-			id,
-			message,
-			user_id,
-			chat_id,
-			created_at
-		FROM chat_messages
-	` + filter + `
-		ORDER BY created_at DESC
-	` + limit
+	return deleted, rows.Err()
+}
 
-	rows, err := pr.db.Query(query)
+// tombstoneMessages deletes the messages for everyone. The author can do it
+// within the delete window of the chat, the owner of the chat anytime.
+func tombstoneMessages(tx *sql.Tx, params *repo.DeleteMessagesParams) ([]*repo.DeletedMessage, error) {
+	rows, err := tx.Query(`
+		SELECT
+			m.id,
+			m.chat_id,
+			m.thread_root_id,
+			m.deleted_at IS NOT NULL,
+			c.user_id<>$2 AND c.delete_window_seconds > 0
+				AND m.created_at < now() - make_interval(secs => c.delete_window_seconds)
+		FROM chat_messages m
+		INNER JOIN chats c ON c.id=m.chat_id
+		WHERE m.id = ANY($1) AND (m.user_id=$2 OR c.user_id=$2)
+		ORDER BY m.id
+		FOR UPDATE OF m
+	`, pq.Array(params.MessageIDs), params.UserID)
 	if err != nil {
 		return nil, err
 	}
-
 	defer rows.Close()
 
+	var (
+		deleted     []*repo.DeletedMessage
+		ids         []int64
+		threadRoots []int64
+		found       int
+	)
 	for rows.Next() {
-		var message repo.ChatMessage
+		var (
+			message                 repo.DeletedMessage
+			threadRootID            sql.NullInt64
+			alreadyDeleted, expired bool
+		)
+		err := rows.Scan(&message.ID, &message.ChatID, &threadRootID, &alreadyDeleted, &expired)
+		if err != nil {
+			return nil, err
+		}
+		found++
 
-		err := rows.Scan(
-			&message.ID,
-			&message.Message,
-			&message.UserId,
-			&message.ChatId,
-			&message.CreatedAt,
+		if alreadyDeleted {
+			continue
+		}
+		if expired {
+			return nil, repo.ErrDeleteWindowExpired
+		}
+		deleted = append(deleted, &message)
+		ids = append(ids, message.ID)
+		if threadRootID.Valid {
+			threadRoots = append(threadRoots, threadRootID.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if found != len(params.MessageIDs) {
+		return nil, sql.ErrNoRows
+	}
+	if len(ids) == 0 {
+		return deleted, nil
+	}
+
+	queries := []string{
+		"UPDATE chat_messages SET message='', content=NULL, deleted_at=CURRENT_TIMESTAMP WHERE id = ANY($1)",
+		"DELETE FROM attachments WHERE message_id = ANY($1)",
+		"DELETE FROM chat_message_mentions WHERE message_id = ANY($1)",
+		"DELETE FROM message_reactions WHERE message_id = ANY($1)",
+		"DELETE FROM message_revisions WHERE message_id = ANY($1)",
+		"DELETE FROM pinned_messages WHERE message_id = ANY($1)",
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, pq.Array(ids)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(threadRoots) > 0 {
+		// Locked before counting so the count sees the replies committed
+		// by the creates that held the lock meanwhile.
+		_, err := tx.Exec(
+			"SELECT 1 FROM chat_messages WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+			pq.Array(threadRoots),
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		message.UserInfo, err = getUserInfo(pr.db, message.UserId)
+		_, err = tx.Exec(`
+			UPDATE chat_messages r SET
+				thread_reply_count = (
+					SELECT count(1) FROM chat_messages
+					WHERE thread_root_id=r.id AND deleted_at IS NULL
+				),
+				thread_last_reply_at = (
+					SELECT max(created_at) FROM chat_messages
+					WHERE thread_root_id=r.id AND deleted_at IS NULL
+				)
+			WHERE id = ANY($1)
+		`, pq.Array(threadRoots))
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	return deleted, nil
+}
+
+// GetAll returns the messages of a chat, newest first. With a user only the
+// chats the user is a member of are listed, sql.ErrNoRows is returned for a
+// chat the user isn't a member of.
+func (pr *chatMessageRepo) GetAll(params *repo.GetAllMessagesParams) (*repo.GetAllMessages, error) {
+	result := repo.GetAllMessages{
+		Messages: make([]*repo.ChatMessage, 0),
+	}
+
+	offset := (params.Page - 1) * params.Limit
+
+	limit := fmt.Sprintf(" LIMIT %d OFFSET %d ", params.Limit, offset)
+	// Thread replies are fetched with GetThread
+	filter := " WHERE m.thread_root_id IS NULL "
+	if params.ChatId > 0 {
+		filter += fmt.Sprintf(" AND m.chat_id = %d ", params.ChatId)
+	}
+	if params.UserID > 0 {
+		if params.ChatId > 0 {
+			var exists bool
+			err := pr.db.QueryRow(
+				`SELECT true FROM chat_members WHERE chat_id=$1 AND user_id=$2`,
+				params.ChatId, params.UserID,
+			).Scan(&exists)
+			if err != nil {
+				return nil, err
+			}
+		}
+		filter += fmt.Sprintf(" AND m.chat_id IN (SELECT chat_id FROM chat_members WHERE user_id=%d) ", params.UserID)
+		filter += notHiddenFor(fmt.Sprint(params.UserID))
+	}
 
-		result.Messages = append(result.Messages, &message)
+	query := `
+		SELECT ` + messageColumns + `
+		FROM chat_messages m ` + quoteJoin + filter + `
+		ORDER BY m.created_at DESC
+	` + limit
+
+	rows, err := pr.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Messages, err = pr.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := pr.loadReactions(result.Messages, params.UserID); err != nil {
+		return nil, err
 	}
 
-	queryCount := `SELECT count(1) FROM chat_messages` + filter
+	queryCount := `SELECT count(1) FROM chat_messages m` + filter
 	err = pr.db.QueryRow(queryCount).Scan(&result.Count)
 	if err != nil {
 		return nil, err
@@ -155,3 +591,1121 @@ func (pr *chatMessageRepo) GetAll(params *repo.GetAllMessagesParams) (*repo.GetA
 
 	return &result, nil
 }
+
+// GetThread returns the replies of a thread, newest first. sql.ErrNoRows is
+// returned when the root doesn't exist, is itself a reply or the user is
+// not a member of its chat.
+func (pr *chatMessageRepo) GetThread(params *repo.GetThreadParams) (*repo.GetAllMessages, error) {
+	result := repo.GetAllMessages{
+		Messages: make([]*repo.ChatMessage, 0),
+	}
+
+	var exists bool
+	err := pr.db.QueryRow(`
+		SELECT true FROM chat_messages m
+		INNER JOIN chat_members cm ON cm.chat_id=m.chat_id AND cm.user_id=$2
+		WHERE m.id=$1 AND m.thread_root_id IS NULL
+	`, params.RootID, params.UserID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	filter := " WHERE m.thread_root_id = $1 " + notHiddenFor("$2")
+
+	query := `
+		SELECT ` + messageColumns + `
+		FROM chat_messages m ` + quoteJoin + filter + `
+		ORDER BY m.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := pr.db.Query(query, params.RootID, params.UserID, params.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Messages, err = pr.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := pr.loadReactions(result.Messages, params.UserID); err != nil {
+		return nil, err
+	}
+
+	queryCount := `SELECT count(1) FROM chat_messages m` + filter
+	err = pr.db.QueryRow(queryCount, params.RootID, params.UserID).Scan(&result.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetAllSince returns the messages of every chat the user is a member of
+// which are newer than the last message id, oldest first. Count is the
+// number of such messages, it is greater than the page when more are left.
+func (pr *chatMessageRepo) GetAllSince(params *repo.GetMessagesSinceParams) (*repo.GetAllMessages, error) {
+	result := repo.GetAllMessages{
+		Messages: make([]*repo.ChatMessage, 0),
+	}
+
+	filter := `
+		WHERE m.id > $1 AND m.chat_id IN (
+			SELECT chat_id FROM chat_members WHERE user_id = $2
+		)
+	` + notHiddenFor("$2")
+
+	query := `
+		SELECT ` + messageColumns + `
+		FROM chat_messages m ` + quoteJoin + filter + `
+		ORDER BY m.id ASC
+		LIMIT $3
+	`
+
+	rows, err := pr.db.Query(query, params.LastMessageID, params.UserID, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Messages, err = pr.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := pr.loadReactions(result.Messages, params.UserID); err != nil {
+		return nil, err
+	}
+
+	queryCount := `SELECT count(1) FROM chat_messages m` + filter
+	err = pr.db.QueryRow(queryCount, params.LastMessageID, params.UserID).Scan(&result.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// MarkRead moves the read cursor of the member up to the message, it never
+// moves back. sql.ErrNoRows is returned when the user is not a member of the
+// chat or the message is not in it.
+func (pr *chatMessageRepo) MarkRead(params *repo.MarkReadParams) (*repo.ReadCursor, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := repo.ReadCursor{
+		ChatID:    params.ChatID,
+		UserID:    params.UserID,
+		SenderIDs: make([]int64, 0),
+	}
+
+	err = tx.QueryRow(`
+		SELECT last_read_message_id FROM chat_members
+		WHERE chat_id=$1 AND user_id=$2
+		FOR UPDATE
+	`, params.ChatID, params.UserID).Scan(&result.LastReadMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM chat_messages WHERE id=$1 AND chat_id=$2)",
+		params.MessageID,
+		params.ChatID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	if params.MessageID <= result.LastReadMessageID {
+		return &result, nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE chat_members SET
+			last_read_message_id=$1,
+			read_at=CURRENT_TIMESTAMP
+		WHERE chat_id=$2 AND user_id=$3
+	`, params.MessageID, params.ChatID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT DISTINCT user_id FROM chat_messages
+		WHERE chat_id=$1 AND id>$2 AND id<=$3 AND user_id<>$4
+	`, params.ChatID, result.LastReadMessageID, params.MessageID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var senderID int64
+		if err := rows.Scan(&senderID); err != nil {
+			return nil, err
+		}
+		result.SenderIDs = append(result.SenderIDs, senderID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	result.LastReadMessageID = params.MessageID
+
+	return &result, nil
+}
+
+// GetReadBy returns the members whose read cursor reached the message, its
+// author excluded, most recent readers first. sql.ErrNoRows is returned when
+// the message doesn't exist or the user is not a member of its chat.
+func (pr *chatMessageRepo) GetReadBy(params *repo.GetReadByParams) (*repo.GetAllUsersResult, error) {
+	result := repo.GetAllUsersResult{
+		Users: make([]*repo.User, 0),
+	}
+
+	var chatID, authorID int64
+	err := pr.db.QueryRow(`
+		SELECT m.chat_id, m.user_id FROM chat_messages m
+		INNER JOIN chat_members cm ON cm.chat_id=m.chat_id AND cm.user_id=$2
+		WHERE m.id=$1
+	`, params.MessageID, params.UserID).Scan(&chatID, &authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := `
+		WHERE cm.chat_id=$1 AND cm.user_id<>$2 AND cm.last_read_message_id>=$3
+	`
+
+	offset := (params.Page - 1) * params.Limit
+	query := `
+		SELECT
+			u.id,
+			u.first_name,
+			u.last_name,
+			u.email,
+			u.username,
+			u.profile_image_url,
+			u.created_at
+		FROM users u
+		INNER JOIN chat_members cm ON cm.user_id=u.id
+	` + filter + `
+		ORDER BY cm.read_at DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := pr.db.Query(query, chatID, authorID, params.MessageID, params.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			u                         repo.User
+			username, profileImageUrl sql.NullString
+		)
+
+		err := rows.Scan(
+			&u.ID,
+			&u.FirstName,
+			&u.LastName,
+			&u.Email,
+			&username,
+			&profileImageUrl,
+			&u.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		u.Username = username.String
+		u.ProfileImageUrl = profileImageUrl.String
+
+		result.Users = append(result.Users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	queryCount := `SELECT count(1) FROM chat_members cm` + filter
+	err = pr.db.QueryRow(queryCount, chatID, authorID, params.MessageID).Scan(&result.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// AddReaction reacts to the message with the emoji, reacting twice with the
+// same emoji is a no-op. sql.ErrNoRows is returned when the user is not a
+// member of the chat of the message.
+func (pr *chatMessageRepo) AddReaction(params *repo.ReactionParams) (*repo.MessageReactions, error) {
+	chatID, allowedReactions, err := pr.reactionChat(params)
+	if err != nil {
+		return nil, err
+	}
+	if !reactionAllowed(allowedReactions, params.Emoji) {
+		return nil, repo.ErrReactionNotAllowed
+	}
+
+	_, err = pr.db.Exec(`
+		INSERT INTO message_reactions (message_id, user_id, emoji)
+		VALUES($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, params.MessageID, params.UserID, params.Emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	return pr.getReactions(params.MessageID, chatID, params.UserID)
+}
+
+// RemoveReaction takes the reaction of the user back, it is a no-op when
+// there is none.
+func (pr *chatMessageRepo) RemoveReaction(params *repo.ReactionParams) (*repo.MessageReactions, error) {
+	chatID, _, err := pr.reactionChat(params)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = pr.db.Exec(
+		"DELETE FROM message_reactions WHERE message_id=$1 AND user_id=$2 AND emoji=$3",
+		params.MessageID,
+		params.UserID,
+		params.Emoji,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pr.getReactions(params.MessageID, chatID, params.UserID)
+}
+
+// reactionChat returns the chat of the message the user reacts to and its
+// allowed reactions. sql.ErrNoRows is returned when the user is not a member
+// of it.
+func (pr *chatMessageRepo) reactionChat(params *repo.ReactionParams) (int64, []string, error) {
+	var (
+		chatID  int64
+		allowed []string
+	)
+	err := pr.db.QueryRow(`
+		SELECT m.chat_id, c.allowed_reactions FROM chat_messages m
+		INNER JOIN chats c ON c.id=m.chat_id
+		INNER JOIN chat_members cm ON cm.chat_id=m.chat_id AND cm.user_id=$2
+		WHERE m.id=$1 AND m.deleted_at IS NULL
+	`, params.MessageID, params.UserID).Scan(&chatID, pq.Array(&allowed))
+
+	return chatID, allowed, err
+}
+
+// reactionAllowed reports whether the emoji is in the allowed reactions of a
+// chat, any emoji is allowed when there are none.
+func reactionAllowed(allowed []string, emoji string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+func (pr *chatMessageRepo) getReactions(messageID, chatID, userID int64) (*repo.MessageReactions, error) {
+	message := repo.ChatMessage{ID: messageID}
+	if err := pr.loadReactions([]*repo.ChatMessage{&message}, userID); err != nil {
+		return nil, err
+	}
+
+	return &repo.MessageReactions{
+		MessageID: messageID,
+		ChatID:    chatID,
+		Reactions: message.Reactions,
+	}, nil
+}
+
+// loadReactions sets the reactions of the messages with one query, Reacted
+// is set for the user.
+func (pr *chatMessageRepo) loadReactions(messages []*repo.ChatMessage, userID int64) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*repo.ChatMessage, len(messages))
+	ids := make([]int64, 0, len(messages))
+	for _, m := range messages {
+		m.Reactions = make([]*repo.Reaction, 0)
+		byID[m.ID] = m
+		ids = append(ids, m.ID)
+	}
+
+	rows, err := pr.db.Query(`
+		SELECT message_id, emoji, count(1), bool_or(user_id=$2)
+		FROM message_reactions
+		WHERE message_id = ANY($1)
+		GROUP BY message_id, emoji
+		ORDER BY message_id, min(created_at)
+	`, pq.Array(ids), userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			messageID int64
+			reaction  repo.Reaction
+		)
+		err := rows.Scan(&messageID, &reaction.Emoji, &reaction.Count, &reaction.Reacted)
+		if err != nil {
+			return err
+		}
+
+		m := byID[messageID]
+		m.Reactions = append(m.Reactions, &reaction)
+	}
+
+	return rows.Err()
+}
+
+// Forward copies the messages to the chat in the order of their ids, with
+// the forwarding user as their sender. sql.ErrNoRows is returned when the
+// user is not a member of the chat or of the chat of any of the messages.
+func (pr *chatMessageRepo) Forward(params *repo.ForwardParams) ([]*repo.ChatMessage, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var member bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM chat_members WHERE chat_id=$1 AND user_id=$2)",
+		params.ChatID,
+		params.UserID,
+	).Scan(&member)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, sql.ErrNoRows
+	}
+
+	sources, err := forwardSources(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO chat_messages (
+			message,
+			user_id,
+			chat_id,
+			forwarded_from_message_id,
+			forwarded_from_chat_id,
+			forwarded_from_user_id,
+			forwarded_from_created_at,
+			type,
+			content
+		) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	messages := make([]*repo.ChatMessage, 0, len(sources))
+	for _, source := range sources {
+		message := repo.ChatMessage{
+			Message:       source.Message,
+			UserId:        params.UserID,
+			ChatId:        params.ChatID,
+			ForwardedFrom: source.ForwardedFrom,
+			Type:          source.Type,
+			Location:      source.Location,
+			Contact:       source.Contact,
+		}
+		content, err := encodeContent(&message)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.QueryRow(
+			query,
+			message.Message,
+			message.UserId,
+			message.ChatId,
+			message.ForwardedFrom.MessageID,
+			message.ForwardedFrom.ChatID,
+			message.ForwardedFrom.UserID,
+			message.ForwardedFrom.CreatedAt,
+			message.Type,
+			utils.NullString(string(content)),
+		).Scan(&message.ID, &message.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := copyAttachments(tx, source.ID, message.ID, params.UserID); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := loadAttachments(pr.db, messages); err != nil {
+		return nil, err
+	}
+
+	userInfo, err := getUserInfo(pr.db, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range messages {
+		message.UserInfo = userInfo
+		message.ForwardedFrom.UserInfo, err = getForwardedUserInfo(pr.db, message.ForwardedFrom.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return messages, nil
+}
+
+// forwardSources returns the content and the original of the messages to
+// forward, the original of a forwarded message is the one it was forwarded
+// from.
+func forwardSources(tx *sql.Tx, params *repo.ForwardParams) ([]*repo.ChatMessage, error) {
+	rows, err := tx.Query(`
+		SELECT
+			m.id,
+			m.message,
+			m.chat_id,
+			m.user_id,
+			m.created_at,
+			m.forwarded_from_message_id,
+			m.forwarded_from_chat_id,
+			m.forwarded_from_user_id,
+			m.forwarded_from_created_at,
+			m.type,
+			m.content,
+			c.disallow_forwarding
+		FROM chat_messages m
+		INNER JOIN chats c ON c.id=m.chat_id
+		INNER JOIN chat_members cm ON cm.chat_id=m.chat_id AND cm.user_id=$2
+		WHERE m.id = ANY($1) AND m.deleted_at IS NULL AND m.type<>'system'
+		ORDER BY m.id
+	`, pq.Array(params.MessageIDs), params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		sources    []*repo.ChatMessage
+		disallowed bool
+	)
+	for rows.Next() {
+		var (
+			source             repo.ChatMessage
+			forwarded          forwardedColumns
+			content            []byte
+			disallowForwarding bool
+		)
+
+		err := rows.Scan(
+			&source.ID,
+			&source.Message,
+			&source.ChatId,
+			&source.UserId,
+			&source.CreatedAt,
+			&forwarded.messageID,
+			&forwarded.chatID,
+			&forwarded.userID,
+			&forwarded.createdAt,
+			&source.Type,
+			&content,
+			&disallowForwarding,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeContent(content, &source); err != nil {
+			return nil, err
+		}
+		disallowed = disallowed || disallowForwarding
+
+		source.ForwardedFrom = &repo.ForwardedFrom{
+			MessageID: source.ID,
+			ChatID:    source.ChatId,
+			UserID:    source.UserId,
+			CreatedAt: source.CreatedAt,
+		}
+		if forwarded.createdAt.Valid {
+			source.ForwardedFrom = &repo.ForwardedFrom{
+				MessageID: forwarded.messageID.Int64,
+				ChatID:    forwarded.chatID.Int64,
+				UserID:    forwarded.userID.Int64,
+				CreatedAt: forwarded.createdAt.Time,
+			}
+		}
+
+		sources = append(sources, &source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sources) != len(params.MessageIDs) {
+		return nil, sql.ErrNoRows
+	}
+	if disallowed {
+		return nil, repo.ErrForwardingDisallowed
+	}
+
+	return sources, nil
+}
+
+// forwardedColumns are the forwarded_from columns of a message.
+type forwardedColumns struct {
+	messageID, chatID, userID sql.NullInt64
+	createdAt                 sql.NullTime
+}
+
+// load returns the original of the message, nil when it wasn't forwarded.
+func (c *forwardedColumns) load(db *sqlx.DB) (*repo.ForwardedFrom, error) {
+	if !c.createdAt.Valid {
+		return nil, nil
+	}
+
+	userInfo, err := getForwardedUserInfo(db, c.userID.Int64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repo.ForwardedFrom{
+		MessageID: c.messageID.Int64,
+		ChatID:    c.chatID.Int64,
+		UserID:    c.userID.Int64,
+		UserInfo:  userInfo,
+		CreatedAt: c.createdAt.Time,
+	}, nil
+}
+
+// getForwardedUserInfo returns the original sender of a forwarded message,
+// nil when the user was deleted since.
+func getForwardedUserInfo(db *sqlx.DB, userID int64) (*repo.GetUserInfo, error) {
+	userInfo, err := getUserInfo(db, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return userInfo, err
+}
+
+// GetRevisions returns the previous texts of the message, newest first.
+// sql.ErrNoRows is returned unless the user is the author of the message or
+// the owner of its chat.
+func (pr *chatMessageRepo) GetRevisions(params *repo.GetRevisionsParams) (*repo.MessageRevisions, error) {
+	result := repo.MessageRevisions{
+		Revisions: make([]*repo.MessageRevision, 0),
+	}
+
+	var allowed bool
+	err := pr.db.QueryRow(`
+		SELECT true FROM chat_messages m
+		INNER JOIN chats c ON c.id=m.chat_id
+		WHERE m.id=$1 AND (m.user_id=$2 OR c.user_id=$2)
+	`, params.MessageID, params.UserID).Scan(&allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	rows, err := pr.db.Query(`
+		SELECT message, created_at FROM message_revisions
+		WHERE message_id=$1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`, params.MessageID, params.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision repo.MessageRevision
+		if err := rows.Scan(&revision.Message, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		result.Revisions = append(result.Revisions, &revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = pr.db.QueryRow(
+		"SELECT count(1) FROM message_revisions WHERE message_id=$1",
+		params.MessageID,
+	).Scan(&result.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Pin pins the message to its chat and appends a system message replying to
+// it, pinning a pinned message again is a no-op. sql.ErrNoRows is returned
+// when the user is not a member of the chat of the message, ErrPinNotAllowed
+// when the chat is a group the user doesn't own.
+func (pr *chatMessageRepo) Pin(params *repo.PinParams) (*repo.PinResult, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chatID, err := pinChat(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO pinned_messages (message_id, chat_id, user_id)
+		VALUES($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, params.MessageID, chatID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var systemMessageID int64
+	if pinned, _ := res.RowsAffected(); pinned > 0 {
+		err := tx.QueryRow(`
+			INSERT INTO chat_messages (message, user_id, chat_id, reply_to_message_id, type)
+			VALUES($1, $2, $3, $4, $5)
+			RETURNING id
+		`,
+			pinnedSystemMessage,
+			params.UserID,
+			chatID,
+			params.MessageID,
+			repo.MessageTypeSystem,
+		).Scan(&systemMessageID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var result repo.PinResult
+	result.Message, err = pr.get(params.MessageID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if systemMessageID > 0 {
+		result.SystemMessage, err = pr.get(systemMessageID, params.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
+
+// Unpin unpins the message, it is a no-op when it isn't pinned. The errors
+// are the ones of Pin.
+func (pr *chatMessageRepo) Unpin(params *repo.PinParams) (*repo.ChatMessage, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := pinChat(tx, params); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM pinned_messages WHERE message_id=$1", params.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return pr.get(params.MessageID, params.UserID)
+}
+
+// pinChat returns the chat of the message the user pins or unpins. The owner
+// of a group chat and both members of a private chat can pin messages.
+func pinChat(tx *sql.Tx, params *repo.PinParams) (int64, error) {
+	var (
+		chatID  int64
+		allowed bool
+	)
+	err := tx.QueryRow(`
+		SELECT m.chat_id, c.chat_type='private_chat' OR c.user_id=$2
+		FROM chat_messages m
+		INNER JOIN chats c ON c.id=m.chat_id
+		INNER JOIN chat_members cm ON cm.chat_id=m.chat_id AND cm.user_id=$2
+		WHERE m.id=$1 AND m.deleted_at IS NULL AND m.type<>'system'
+	`, params.MessageID, params.UserID).Scan(&chatID, &allowed)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, repo.ErrPinNotAllowed
+	}
+
+	return chatID, nil
+}
+
+// GetPinned returns the pinned messages of the chat, most recently pinned
+// first. sql.ErrNoRows is returned when the user is not a member of the chat.
+func (pr *chatMessageRepo) GetPinned(params *repo.GetPinnedParams) (*repo.GetAllMessages, error) {
+	result := repo.GetAllMessages{
+		Messages: make([]*repo.ChatMessage, 0),
+	}
+
+	var member bool
+	err := pr.db.QueryRow(
+		"SELECT true FROM chat_members WHERE chat_id=$1 AND user_id=$2",
+		params.ChatID,
+		params.UserID,
+	).Scan(&member)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	from := " FROM pinned_messages p INNER JOIN chat_messages m ON m.id=p.message_id "
+	filter := " WHERE p.chat_id=$1 " + notHiddenFor("$2")
+
+	query := `
+		SELECT ` + messageColumns + from + quoteJoin + filter + `
+		ORDER BY p.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := pr.db.Query(query, params.ChatID, params.UserID, params.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Messages, err = pr.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := pr.loadReactions(result.Messages, params.UserID); err != nil {
+		return nil, err
+	}
+
+	queryCount := `SELECT count(1)` + from + filter
+	err = pr.db.QueryRow(queryCount, params.ChatID, params.UserID).Scan(&result.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// get returns the message, Reacted is set for the user.
+func (pr *chatMessageRepo) get(id, userID int64) (*repo.ChatMessage, error) {
+	rows, err := pr.db.Query(`
+		SELECT `+messageColumns+`
+		FROM chat_messages m `+quoteJoin+`
+		WHERE m.id=$1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := pr.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	if err := pr.loadReactions(messages, userID); err != nil {
+		return nil, err
+	}
+
+	return messages[0], nil
+}
+
+// nullTime returns nil for a NULL column.
+func nullTime(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	return &nt.Time
+}
+
+// insertMentions stores the members of the chat mentioned in the message.
+func insertMentions(tx *sql.Tx, message *repo.ChatMessage) error {
+	if len(message.Mentions) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO chat_message_mentions (message_id, user_id)
+		SELECT $1, u.id FROM users u
+		INNER JOIN chat_members cm ON cm.user_id=u.id AND cm.chat_id=$2
+		WHERE lower(u.username) = ANY($3)
+		ON CONFLICT DO NOTHING
+	`, message.ID, message.ChatId, pq.Array(message.Mentions))
+
+	return err
+}
+
+// Columns of a message selected from chat_messages m joined with quoteJoin,
+// in the order scanMessages reads them.
+var messageColumns = fmt.Sprintf(`
+	m.id,
+	m.message,
+	m.user_id,
+	m.chat_id,
+	m.created_at,
+	m.client_message_id,
+	m.reply_to_message_id,
+	m.thread_root_id,
+	m.thread_reply_count,
+	m.thread_last_reply_at,
+	m.forwarded_from_message_id,
+	m.forwarded_from_chat_id,
+	m.forwarded_from_user_id,
+	m.forwarded_from_created_at,
+	m.edited_at,
+	m.deleted_at,
+	m.type,
+	m.content,
+	EXISTS (SELECT 1 FROM pinned_messages pm WHERE pm.message_id=m.id),
+	q.id,
+	left(q.message, %d),
+	q.type,
+	q.user_id
+`, quotePreviewLength)
+
+// quoteJoin joins the message a message replies to as q.
+const quoteJoin = " LEFT JOIN chat_messages q ON q.id = m.reply_to_message_id AND q.deleted_at IS NULL "
+
+// notHiddenFor filters out the messages m the user hid for themselves.
+func notHiddenFor(user string) string {
+	return " AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id=m.id AND h.user_id=" + user + ") "
+}
+
+// getQuotedMessage returns the preview of a replied message, nil when id is
+// 0 and a deleted placeholder when the message doesn't exist anymore.
+func getQuotedMessage(db *sqlx.DB, id int64) (*repo.QuotedMessage, error) {
+	if id == 0 {
+		return nil, nil
+	}
+
+	result := repo.QuotedMessage{ID: id}
+	err := db.QueryRow(
+		"SELECT left(message, $2), type, user_id FROM chat_messages WHERE id=$1 AND deleted_at IS NULL",
+		id,
+		quotePreviewLength,
+	).Scan(&result.Message, &result.Type, &result.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Deleted = true
+		return &result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.UserInfo, err = getUserInfo(db, result.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// getThreadSummary returns the counters of the thread root, nil when id
+// is 0.
+func getThreadSummary(db *sqlx.DB, id int64) (*repo.ThreadSummary, error) {
+	if id == 0 {
+		return nil, nil
+	}
+
+	var (
+		replyCount  int64
+		lastReplyAt sql.NullTime
+	)
+	err := db.QueryRow(
+		"SELECT thread_reply_count, thread_last_reply_at FROM chat_messages WHERE id=$1",
+		id,
+	).Scan(&replyCount, &lastReplyAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repo.ThreadSummary{
+		RootID:      id,
+		ReplyCount:  replyCount,
+		LastReplyAt: lastReplyAt.Time,
+	}, nil
+}
+
+// threadSummary returns the thread started by the message, nil when it has
+// no replies.
+func threadSummary(id, replyCount int64, lastReplyAt sql.NullTime) *repo.ThreadSummary {
+	if replyCount == 0 {
+		return nil
+	}
+
+	return &repo.ThreadSummary{
+		RootID:      id,
+		ReplyCount:  replyCount,
+		LastReplyAt: lastReplyAt.Time,
+	}
+}
+
+// scanMessages reads the rows of a messages query and closes them.
+func (pr *chatMessageRepo) scanMessages(rows *sql.Rows) ([]*repo.ChatMessage, error) {
+	defer rows.Close()
+
+	messages := make([]*repo.ChatMessage, 0)
+	for rows.Next() {
+		var (
+			message                                repo.ChatMessage
+			clientMessageID, quoteText, quoteType  sql.NullString
+			replyToMessageID, quoteID, quoteUserID sql.NullInt64
+			threadRootID                           sql.NullInt64
+			threadReplyCount                       int64
+			threadLastReplyAt, editedAt, deletedAt sql.NullTime
+			forwarded                              forwardedColumns
+			content                                []byte
+		)
+
+		err := rows.Scan(
+			&message.ID,
+			&message.Message,
+			&message.UserId,
+			&message.ChatId,
+			&message.CreatedAt,
+			&clientMessageID,
+			&replyToMessageID,
+			&threadRootID,
+			&threadReplyCount,
+			&threadLastReplyAt,
+			&forwarded.messageID,
+			&forwarded.chatID,
+			&forwarded.userID,
+			&forwarded.createdAt,
+			&editedAt,
+			&deletedAt,
+			&message.Type,
+			&content,
+			&message.Pinned,
+			&quoteID,
+			&quoteText,
+			&quoteType,
+			&quoteUserID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		message.ClientMessageID = clientMessageID.String
+		message.ReplyToMessageID = replyToMessageID.Int64
+		message.ThreadRootID = threadRootID.Int64
+		message.Thread = threadSummary(message.ID, threadReplyCount, threadLastReplyAt)
+		message.ForwardedFrom, err = forwarded.load(pr.db)
+		if err != nil {
+			return nil, err
+		}
+		message.EditedAt = nullTime(editedAt)
+		message.DeletedAt = nullTime(deletedAt)
+		if err := decodeContent(content, &message); err != nil {
+			return nil, err
+		}
+
+		if replyToMessageID.Valid {
+			message.ReplyTo = &repo.QuotedMessage{
+				ID:      replyToMessageID.Int64,
+				Deleted: !quoteID.Valid,
+			}
+			if quoteID.Valid {
+				message.ReplyTo.Message = quoteText.String
+				message.ReplyTo.Type = quoteType.String
+				message.ReplyTo.UserID = quoteUserID.Int64
+				message.ReplyTo.UserInfo, err = getUserInfo(pr.db, quoteUserID.Int64)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		message.UserInfo, err = getUserInfo(pr.db, message.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadAttachments(pr.db, messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// messageContent is the content column of a message.
+type messageContent struct {
+	Location *repo.Location `json:"location,omitempty"`
+	Contact  *repo.Contact  `json:"contact,omitempty"`
+}
+
+// encodeContent returns the content column of the message, nil when it
+// has none.
+func encodeContent(message *repo.ChatMessage) ([]byte, error) {
+	if message.Location == nil && message.Contact == nil {
+		return nil, nil
+	}
+	return json.Marshal(messageContent{
+		Location: message.Location,
+		Contact:  message.Contact,
+	})
+}
+
+// decodeContent sets the location or the contact of the message from its
+// content column.
+func decodeContent(data []byte, message *repo.ChatMessage) error {
+	if data == nil {
+		return nil
+	}
+
+	var content messageContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return err
+	}
+	message.Location = content.Location
+	message.Contact = content.Contact
+
+	return nil
+}
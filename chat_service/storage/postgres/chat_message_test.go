@@ -0,0 +1,596 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v4"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/chat_service/storage/repo"
+)
+
+func TestCreateMessageIsIdempotent(t *testing.T) {
+	chat := createChat(t)
+	clientMessageID := faker.UUIDHyphenated()
+
+	first, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:         faker.Sentence(),
+		UserId:          chat.UserID,
+		ChatId:          chat.ID,
+		ClientMessageID: clientMessageID,
+	})
+	require.NoError(t, err)
+
+	resent, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:         faker.Sentence(),
+		UserId:          chat.UserID,
+		ChatId:          chat.ID,
+		ClientMessageID: clientMessageID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, first.ID, resent.ID)
+	require.Equal(t, first.Message, resent.Message)
+	require.False(t, first.Resent)
+	require.True(t, resent.Resent)
+
+	// The id doesn't bring the message to another chat
+	other := createChat(t, chat.UserID)
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:         faker.Sentence(),
+		UserId:          chat.UserID,
+		ChatId:          other.ID,
+		ClientMessageID: clientMessageID,
+	})
+	require.ErrorIs(t, err, repo.ErrClientMessageIDReused)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), messages.Count)
+}
+
+func TestGetAllMessagesForMembersOnly(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	_, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+		UserID: member.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), messages.Count)
+
+	_, err = strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+		UserID: createUser(t).ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Without a chat the messages of the chats of the user are listed
+	messages, err = strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		UserID: createUser(t).ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, messages.Count)
+}
+
+func TestMarkRead(t *testing.T) {
+	reader := createUser(t)
+	chat := createChat(t, reader.ID)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+
+	cursor, err := strg.ChatMessage().MarkRead(&repo.MarkReadParams{
+		ChatID:    chat.ID,
+		UserID:    reader.ID,
+		MessageID: message.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, message.ID, cursor.LastReadMessageID)
+	require.Equal(t, []int64{chat.UserID}, cursor.SenderIDs)
+
+	// Reading it again doesn't notify the sender twice
+	cursor, err = strg.ChatMessage().MarkRead(&repo.MarkReadParams{
+		ChatID:    chat.ID,
+		UserID:    reader.ID,
+		MessageID: message.ID,
+	})
+	require.NoError(t, err)
+	require.Empty(t, cursor.SenderIDs)
+
+	readBy, err := strg.ChatMessage().GetReadBy(&repo.GetReadByParams{
+		MessageID: message.ID,
+		UserID:    chat.UserID,
+		Limit:     10,
+		Page:      1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), readBy.Count)
+	require.Equal(t, reader.ID, readBy.Users[0].ID)
+}
+
+func TestReplyToMessage(t *testing.T) {
+	chat := createChat(t)
+
+	original, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+
+	reply, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:          faker.Sentence(),
+		UserId:           chat.UserID,
+		ChatId:           chat.ID,
+		ReplyToMessageID: original.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, original.ID, reply.ReplyToMessageID)
+	require.NotNil(t, reply.ReplyTo)
+	require.Equal(t, original.Message, reply.ReplyTo.Message)
+	require.False(t, reply.ReplyTo.Deleted)
+
+	other := createChat(t)
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:          faker.Sentence(),
+		UserId:           other.UserID,
+		ChatId:           other.ID,
+		ReplyToMessageID: original.ID,
+	})
+	require.ErrorIs(t, err, repo.ErrReplyNotInChat)
+
+	_, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{original.ID},
+		UserID:     chat.UserID,
+	})
+	require.NoError(t, err)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages.Messages, 2)
+	require.Equal(t, original.ID, messages.Messages[0].ReplyTo.ID)
+	require.True(t, messages.Messages[0].ReplyTo.Deleted)
+	require.NotNil(t, messages.Messages[1].DeletedAt)
+	require.Empty(t, messages.Messages[1].Message)
+}
+
+func TestThread(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	root, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+	require.Nil(t, root.Thread)
+
+	var replies []*repo.ChatMessage
+	for i := 0; i < 2; i++ {
+		reply, err := strg.ChatMessage().Create(&repo.ChatMessage{
+			Message:      faker.Sentence(),
+			UserId:       member.ID,
+			ChatId:       chat.ID,
+			ThreadRootID: root.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, root.ID, reply.ThreadRootID)
+		require.Equal(t, int64(i+1), reply.Thread.ReplyCount)
+		replies = append(replies, reply)
+	}
+
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:      faker.Sentence(),
+		UserId:       member.ID,
+		ChatId:       chat.ID,
+		ThreadRootID: replies[0].ID,
+	})
+	require.ErrorIs(t, err, repo.ErrInvalidThreadRoot)
+
+	timeline, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), timeline.Count)
+	require.Equal(t, root.ID, timeline.Messages[0].ID)
+	require.Equal(t, int64(2), timeline.Messages[0].Thread.ReplyCount)
+	require.Equal(t, replies[1].CreatedAt.Unix(), timeline.Messages[0].Thread.LastReplyAt.Unix())
+
+	thread, err := strg.ChatMessage().GetThread(&repo.GetThreadParams{
+		RootID: root.ID,
+		UserID: chat.UserID,
+		Limit:  10,
+		Page:   1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), thread.Count)
+	require.Equal(t, replies[1].ID, thread.Messages[0].ID)
+
+	_, err = strg.ChatMessage().GetThread(&repo.GetThreadParams{
+		RootID: root.ID,
+		UserID: createUser(t).ID,
+		Limit:  10,
+		Page:   1,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{replies[1].ID},
+		UserID:     member.ID,
+	})
+	require.NoError(t, err)
+
+	timeline, err = strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), timeline.Messages[0].Thread.ReplyCount)
+	require.Equal(t, replies[0].CreatedAt.Unix(), timeline.Messages[0].Thread.LastReplyAt.Unix())
+}
+
+func TestReactions(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+
+	for _, userID := range []int64{chat.UserID, member.ID} {
+		reactions, err := strg.ChatMessage().AddReaction(&repo.ReactionParams{
+			MessageID: message.ID,
+			UserID:    userID,
+			Emoji:     "👍",
+		})
+		require.NoError(t, err)
+		require.Equal(t, chat.ID, reactions.ChatID)
+	}
+
+	_, err = strg.ChatMessage().AddReaction(&repo.ReactionParams{
+		MessageID: message.ID,
+		UserID:    createUser(t).ID,
+		Emoji:     "👍",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	reactions, err := strg.ChatMessage().RemoveReaction(&repo.ReactionParams{
+		MessageID: message.ID,
+		UserID:    member.ID,
+		Emoji:     "👍",
+	})
+	require.NoError(t, err)
+	require.Len(t, reactions.Reactions, 1)
+	require.Equal(t, int64(1), reactions.Reactions[0].Count)
+	require.False(t, reactions.Reactions[0].Reacted)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+		UserID: chat.UserID,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages.Messages[0].Reactions, 1)
+	require.True(t, messages.Messages[0].Reactions[0].Reacted)
+
+	err = strg.Chat().UpdateSettings(&repo.UpdateChatSettingsReq{
+		ChatID:   chat.ID,
+		UserID:   chat.UserID,
+		Settings: repo.ChatSettings{AllowedReactions: []string{"❤️"}},
+	})
+	require.NoError(t, err)
+
+	_, err = strg.ChatMessage().AddReaction(&repo.ReactionParams{
+		MessageID: message.ID,
+		UserID:    member.ID,
+		Emoji:     "👍",
+	})
+	require.ErrorIs(t, err, repo.ErrReactionNotAllowed)
+}
+
+func TestForward(t *testing.T) {
+	member := createUser(t)
+	source := createChat(t, member.ID)
+	destination := createChat(t, member.ID)
+
+	original, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  source.UserID,
+		ChatId:  source.ID,
+	})
+	require.NoError(t, err)
+
+	forwarded, err := strg.ChatMessage().Forward(&repo.ForwardParams{
+		MessageIDs: []int64{original.ID},
+		ChatID:     destination.ID,
+		UserID:     member.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, forwarded, 1)
+	require.Equal(t, original.Message, forwarded[0].Message)
+	require.Equal(t, member.ID, forwarded[0].UserId)
+	require.Equal(t, original.ID, forwarded[0].ForwardedFrom.MessageID)
+	require.Equal(t, source.UserID, forwarded[0].ForwardedFrom.UserID)
+
+	// Forwarding again keeps the first original
+	again, err := strg.ChatMessage().Forward(&repo.ForwardParams{
+		MessageIDs: []int64{forwarded[0].ID},
+		ChatID:     source.ID,
+		UserID:     member.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, original.ID, again[0].ForwardedFrom.MessageID)
+	require.Equal(t, source.ID, again[0].ForwardedFrom.ChatID)
+
+	_, err = strg.ChatMessage().Forward(&repo.ForwardParams{
+		MessageIDs: []int64{original.ID},
+		ChatID:     createChat(t).ID,
+		UserID:     member.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = strg.Chat().UpdateSettings(&repo.UpdateChatSettingsReq{
+		ChatID:   source.ID,
+		UserID:   source.UserID,
+		Settings: repo.ChatSettings{DisallowForwarding: true},
+	})
+	require.NoError(t, err)
+
+	_, err = strg.ChatMessage().Forward(&repo.ForwardParams{
+		MessageIDs: []int64{original.ID},
+		ChatID:     destination.ID,
+		UserID:     member.ID,
+	})
+	require.ErrorIs(t, err, repo.ErrForwardingDisallowed)
+}
+
+func TestUpdateKeepsRevisions(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: "first",
+		UserId:  member.ID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+	require.Nil(t, message.EditedAt)
+
+	for _, text := range []string{"second", "third", "third"} {
+		message, err = strg.ChatMessage().Update(&repo.ChatMessage{
+			ID:      message.ID,
+			Message: text,
+			UserId:  member.ID,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, message.EditedAt)
+	}
+
+	// The owner of the chat can see the revisions as well
+	revisions, err := strg.ChatMessage().GetRevisions(&repo.GetRevisionsParams{
+		MessageID: message.ID,
+		UserID:    chat.UserID,
+		Limit:     10,
+		Page:      1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revisions.Count)
+	require.Equal(t, "second", revisions.Revisions[0].Message)
+	require.Equal(t, "first", revisions.Revisions[1].Message)
+
+	_, err = strg.ChatMessage().GetRevisions(&repo.GetRevisionsParams{
+		MessageID: message.ID,
+		UserID:    createUser(t).ID,
+		Limit:     10,
+		Page:      1,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateAfterEditWindow(t *testing.T) {
+	chat := createChat(t)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+
+	err = strg.Chat().UpdateSettings(&repo.UpdateChatSettingsReq{
+		ChatID:   chat.ID,
+		UserID:   chat.UserID,
+		Settings: repo.ChatSettings{EditWindowSeconds: 1},
+	})
+	require.NoError(t, err)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	_, err = strg.ChatMessage().Update(&repo.ChatMessage{
+		ID:      message.ID,
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+	})
+	require.ErrorIs(t, err, repo.ErrEditWindowExpired)
+}
+
+func TestDeleteMessages(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	var messages []*repo.ChatMessage
+	for i := 0; i < 3; i++ {
+		message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+			Message: faker.Sentence(),
+			UserId:  member.ID,
+			ChatId:  chat.ID,
+		})
+		require.NoError(t, err)
+		messages = append(messages, message)
+	}
+
+	deleted, err := strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{messages[0].ID},
+		UserID:     chat.UserID,
+		OnlyMe:     true,
+	})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+
+	for userID, count := range map[int64]int64{chat.UserID: 2, member.ID: 3} {
+		result, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+			Limit:  10,
+			Page:   1,
+			ChatId: chat.ID,
+			UserID: userID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, count, result.Count)
+	}
+
+	// Only the author and the owner of the chat can delete for everyone
+	_, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{messages[1].ID, messages[2].ID},
+		UserID:     createUser(t).ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = strg.Chat().UpdateSettings(&repo.UpdateChatSettingsReq{
+		ChatID:   chat.ID,
+		UserID:   chat.UserID,
+		Settings: repo.ChatSettings{DeleteWindowSeconds: 1},
+	})
+	require.NoError(t, err)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	_, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{messages[1].ID},
+		UserID:     member.ID,
+	})
+	require.ErrorIs(t, err, repo.ErrDeleteWindowExpired)
+
+	deleted, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{messages[1].ID, messages[2].ID},
+		UserID:     chat.UserID,
+	})
+	require.NoError(t, err)
+	require.Len(t, deleted, 2)
+
+	result, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+		UserID: member.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), result.Count)
+	require.NotNil(t, result.Messages[0].DeletedAt)
+	require.Empty(t, result.Messages[0].Message)
+
+	// Deleting a tombstone again is a no-op
+	deleted, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{messages[2].ID},
+		UserID:     chat.UserID,
+	})
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+}
+
+func TestPinMessage(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	message, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  member.ID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+	require.False(t, message.Pinned)
+
+	// Only the owner pins in a group chat
+	_, err = strg.ChatMessage().Pin(&repo.PinParams{
+		MessageID: message.ID,
+		UserID:    member.ID,
+	})
+	require.ErrorIs(t, err, repo.ErrPinNotAllowed)
+
+	pin, err := strg.ChatMessage().Pin(&repo.PinParams{
+		MessageID: message.ID,
+		UserID:    chat.UserID,
+	})
+	require.NoError(t, err)
+	require.True(t, pin.Message.Pinned)
+	require.NotNil(t, pin.SystemMessage)
+	require.Equal(t, repo.MessageTypeSystem, pin.SystemMessage.Type)
+	require.Equal(t, message.ID, pin.SystemMessage.ReplyToMessageID)
+
+	pin, err = strg.ChatMessage().Pin(&repo.PinParams{
+		MessageID: message.ID,
+		UserID:    chat.UserID,
+	})
+	require.NoError(t, err)
+	require.Nil(t, pin.SystemMessage)
+
+	pinned, err := strg.ChatMessage().GetPinned(&repo.GetPinnedParams{
+		ChatID: chat.ID,
+		UserID: member.ID,
+		Limit:  10,
+		Page:   1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pinned.Count)
+	require.Equal(t, message.ID, pinned.Messages[0].ID)
+
+	unpinned, err := strg.ChatMessage().Unpin(&repo.PinParams{
+		MessageID: message.ID,
+		UserID:    chat.UserID,
+	})
+	require.NoError(t, err)
+	require.False(t, unpinned.Pinned)
+
+	pinned, err = strg.ChatMessage().GetPinned(&repo.GetPinnedParams{
+		ChatID: chat.ID,
+		UserID: member.ID,
+		Limit:  10,
+		Page:   1,
+	})
+	require.NoError(t, err)
+	require.Zero(t, pinned.Count)
+}
@@ -0,0 +1,163 @@
+package postgres_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bxcodec/faker/v4"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/chat_service/storage/repo"
+)
+
+func createChat(t *testing.T, members ...int64) *repo.Chat {
+	owner := createUser(t)
+
+	c, err := strg.Chat().Create(&repo.CreateChatReq{
+		Name:     faker.Word(),
+		UserID:   owner.ID,
+		ChatType: "group_chat",
+		Members:  append([]int64{owner.ID}, members...),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, c)
+
+	return c
+}
+
+func TestGetChatPeers(t *testing.T) {
+	member := createUser(t)
+	stranger := createUser(t)
+	chat := createChat(t, member.ID)
+
+	peers, err := strg.Chat().GetChatPeers(member.ID)
+	require.NoError(t, err)
+	require.Equal(t, []int64{chat.UserID}, peers)
+
+	common, err := strg.Chat().HaveCommonChat(member.ID, chat.UserID)
+	require.NoError(t, err)
+	require.True(t, common)
+
+	common, err = strg.Chat().HaveCommonChat(member.ID, stranger.ID)
+	require.NoError(t, err)
+	require.False(t, common)
+}
+
+func TestGetChatMembersPagesNamesakes(t *testing.T) {
+	var members []int64
+	for i := 0; i < 5; i++ {
+		u, err := strg.User().Create(&repo.User{
+			FirstName: "Alex",
+			LastName:  "Smith",
+			Email:     faker.Email(),
+			Password:  faker.Password(),
+			Type:      repo.UserTypeUser,
+		})
+		require.NoError(t, err)
+		members = append(members, u.ID)
+	}
+	chat := createChat(t, members...)
+
+	seen := make(map[int64]bool)
+	for page := int64(1); page <= 3; page++ {
+		result, err := strg.Chat().GetChatMembers(&repo.GetChatMembersParams{
+			ChatID: chat.ID,
+			Limit:  2,
+			Page:   page,
+		})
+		require.NoError(t, err)
+		for _, u := range result.Users {
+			require.False(t, seen[u.ID], "member %d listed twice", u.ID)
+			seen[u.ID] = true
+		}
+	}
+	// The owner with the namesakes
+	require.Len(t, seen, 6)
+}
+
+func TestGetAllChatsWithUnreadCounts(t *testing.T) {
+	reader := createUser(t)
+	reader.Username = fmt.Sprintf("reader_%d", reader.ID)
+	_, err := strg.User().Update(reader)
+	require.NoError(t, err)
+
+	chat := createChat(t, reader.ID)
+
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:  "@" + reader.Username + " hi",
+		UserId:   chat.UserID,
+		ChatId:   chat.ID,
+		Mentions: []string{reader.Username},
+	})
+	require.NoError(t, err)
+
+	// Mentions the reader hid or got in a thread are not unread
+	hidden, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:  "@" + reader.Username + " hidden",
+		UserId:   chat.UserID,
+		ChatId:   chat.ID,
+		Mentions: []string{reader.Username},
+	})
+	require.NoError(t, err)
+	_, err = strg.ChatMessage().Delete(&repo.DeleteMessagesParams{
+		MessageIDs: []int64{hidden.ID},
+		UserID:     reader.ID,
+		OnlyMe:     true,
+	})
+	require.NoError(t, err)
+
+	last, err := strg.ChatMessage().Create(&repo.ChatMessage{
+		Message: faker.Sentence(),
+		UserId:  chat.UserID,
+		ChatId:  chat.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = strg.ChatMessage().Create(&repo.ChatMessage{
+		Message:      "@" + reader.Username + " in a thread",
+		UserId:       chat.UserID,
+		ChatId:       chat.ID,
+		ThreadRootID: last.ID,
+		Mentions:     []string{reader.Username},
+	})
+	require.NoError(t, err)
+
+	// Joined after the messages were sent, a chat without messages is
+	// active since then
+	joined := createChat(t, reader.ID)
+
+	chats, err := strg.Chat().GetAll(&repo.GetAllChatsParams{
+		Limit:  10,
+		Page:   1,
+		UserID: reader.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), chats.Count)
+
+	require.Equal(t, joined.ID, chats.Chats[0].ID)
+	require.Nil(t, chats.Chats[0].LastMessage)
+
+	active := chats.Chats[1]
+	require.Equal(t, chat.ID, active.ID)
+	require.Equal(t, int64(2), active.UnreadCount)
+	require.Equal(t, int64(1), active.UnreadMentionCount)
+	require.NotNil(t, active.LastMessage)
+	require.Equal(t, last.ID, active.LastMessage.ID)
+
+	// Every chat without a user
+	all, err := strg.Chat().GetAll(&repo.GetAllChatsParams{
+		Limit: 1000,
+		Page:  1,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, all.Count, int64(2))
+
+	listed := false
+	for _, c := range all.Chats {
+		if c.ID == chat.ID {
+			listed = true
+			require.Zero(t, c.UnreadCount)
+			require.Equal(t, last.ID, c.LastMessage.ID)
+		}
+	}
+	require.True(t, listed)
+}
@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gitlab.com/telegram_clone/chat_service/pkg/utils"
+	"gitlab.com/telegram_clone/chat_service/storage/repo"
+)
+
+type scheduledMessageRepo struct {
+	db *sqlx.DB
+}
+
+func NewScheduledMessage(db *sqlx.DB) repo.ScheduledMessageStorageI {
+	return &scheduledMessageRepo{
+		db: db,
+	}
+}
+
+const scheduledMessageColumns = `
+	id,
+	message,
+	user_id,
+	chat_id,
+	client_message_id,
+	reply_to_message_id,
+	thread_root_id,
+	send_at,
+	created_at
+`
+
+// Create stores the message until it is due. A message scheduled again with
+// the same client message id by the same user is not stored twice, the
+// stored one is returned. The references to other messages are checked like
+// for a message sent right away.
+func (pr *scheduledMessageRepo) Create(m *repo.ScheduledMessage) (*repo.ScheduledMessage, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = checkReferences(tx, &repo.ChatMessage{
+		ChatId:           m.ChatID,
+		ReplyToMessageID: m.ReplyToMessageID,
+		ThreadRootID:     m.ThreadRootID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow(`
+		INSERT INTO scheduled_messages (
+			message,
+			user_id,
+			chat_id,
+			client_message_id,
+			reply_to_message_id,
+			thread_root_id,
+			send_at
+		) VALUES($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, client_message_id) DO UPDATE SET
+			client_message_id = EXCLUDED.client_message_id
+		RETURNING `+scheduledMessageColumns,
+		m.Message,
+		m.UserID,
+		m.ChatID,
+		utils.NullString(m.ClientMessageID),
+		utils.NullInt64(m.ReplyToMessageID),
+		utils.NullInt64(m.ThreadRootID),
+		m.SendAt,
+	)
+	result, err := scanScheduledMessage(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result.UserInfo, err = getUserInfo(pr.db, result.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Update changes the text and the time of a scheduled message of the user.
+// sql.ErrNoRows is returned when it isn't one or it was already sent.
+func (pr *scheduledMessageRepo) Update(m *repo.ScheduledMessage) (*repo.ScheduledMessage, error) {
+	row := pr.db.QueryRow(`
+		UPDATE scheduled_messages SET
+			message=$1,
+			send_at=$2
+		WHERE id=$3 AND user_id=$4
+		RETURNING `+scheduledMessageColumns,
+		m.Message,
+		m.SendAt,
+		m.ID,
+		m.UserID,
+	)
+	result, err := scanScheduledMessage(row)
+	if err != nil {
+		return nil, err
+	}
+
+	result.UserInfo, err = getUserInfo(pr.db, result.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete cancels a scheduled message of the user. sql.ErrNoRows is returned
+// when it isn't one or it was already sent.
+func (pr *scheduledMessageRepo) Delete(id, userID int64) error {
+	result, err := pr.db.Exec("DELETE FROM scheduled_messages WHERE id=$1 AND user_id=$2", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAll returns the scheduled messages of the user, the next due first.
+func (pr *scheduledMessageRepo) GetAll(params *repo.GetScheduledMessagesParams) (*repo.GetScheduledMessages, error) {
+	result := repo.GetScheduledMessages{
+		Messages: make([]*repo.ScheduledMessage, 0),
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	filter := " WHERE user_id=$1 AND ($2 = 0 OR chat_id=$2) "
+
+	rows, err := pr.db.Query(`
+		SELECT `+scheduledMessageColumns+`
+		FROM scheduled_messages`+filter+`
+		ORDER BY send_at, id
+		LIMIT $3 OFFSET $4
+	`, params.UserID, params.ChatID, params.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userInfo *repo.GetUserInfo
+	for rows.Next() {
+		m, err := scanScheduledMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		if userInfo == nil {
+			userInfo, err = getUserInfo(pr.db, m.UserID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		m.UserInfo = userInfo
+
+		result.Messages = append(result.Messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	queryCount := "SELECT count(1) FROM scheduled_messages" + filter
+	err = pr.db.QueryRow(queryCount, params.UserID, params.ChatID).Scan(&result.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// SendDue creates the earliest due scheduled message as a chat message and
+// removes it from the schedule in the same transaction. Rows locked by
+// another replica and the ones in skip are passed over, so every message is
+// sent once however many replicas run the dispatcher. nil is returned when
+// no message is due. On failure the id of the scheduled message is returned
+// along with the error so it can be skipped.
+func (pr *scheduledMessageRepo) SendDue(skip []int64) (*repo.SentScheduledMessage, error) {
+	if skip == nil {
+		skip = []int64{}
+	}
+
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`
+		SELECT `+scheduledMessageColumns+`
+		FROM scheduled_messages
+		WHERE send_at <= now() AND id <> ALL($1::int[])
+		ORDER BY send_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, pq.Array(skip))
+	m, err := scanScheduledMessage(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := repo.SentScheduledMessage{
+		ScheduledID: m.ID,
+	}
+
+	_, err = tx.Exec("DELETE FROM scheduled_messages WHERE id=$1", m.ID)
+	if err != nil {
+		return &result, err
+	}
+
+	// The message is dropped when it can't be sent anymore.
+	var member bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM chat_members WHERE chat_id=$1 AND user_id=$2)",
+		m.ChatID,
+		m.UserID,
+	).Scan(&member)
+	if err != nil {
+		return &result, err
+	}
+
+	var message *repo.ChatMessage
+	if member {
+		message = &repo.ChatMessage{
+			Message:          m.Message,
+			UserId:           m.UserID,
+			ChatId:           m.ChatID,
+			ClientMessageID:  m.ClientMessageID,
+			Mentions:         utils.ParseMentions(m.Message),
+			ReplyToMessageID: m.ReplyToMessageID,
+			ThreadRootID:     m.ThreadRootID,
+		}
+		err = createMessage(tx, message)
+		if errors.Is(err, repo.ErrReplyNotInChat) || errors.Is(err, repo.ErrInvalidThreadRoot) ||
+			errors.Is(err, repo.ErrClientMessageIDReused) {
+			message, err = nil, nil
+		}
+		if err != nil {
+			return &result, err
+		}
+		// Sent already with the same client message id
+		if message != nil && message.Resent {
+			message = nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &result, err
+	}
+
+	if message != nil {
+		result.Message, err = (&chatMessageRepo{db: pr.db}).get(message.ID, m.UserID)
+		if err != nil {
+			return &result, err
+		}
+	}
+
+	return &result, nil
+}
+
+func scanScheduledMessage(row interface{ Scan(dest ...any) error }) (*repo.ScheduledMessage, error) {
+	var (
+		m                              repo.ScheduledMessage
+		clientMessageID                sql.NullString
+		replyToMessageID, threadRootID sql.NullInt64
+	)
+	err := row.Scan(
+		&m.ID,
+		&m.Message,
+		&m.UserID,
+		&m.ChatID,
+		&clientMessageID,
+		&replyToMessageID,
+		&threadRootID,
+		&m.SendAt,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.ClientMessageID = clientMessageID.String
+	m.ReplyToMessageID = replyToMessageID.Int64
+	m.ThreadRootID = threadRootID.Int64
+
+	return &m, nil
+}
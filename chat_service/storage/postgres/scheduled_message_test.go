@@ -0,0 +1,138 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v4"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/telegram_clone/chat_service/storage/repo"
+)
+
+// sendDue runs the dispatcher until no message is due and returns the sent
+// messages by scheduled message id.
+func sendDue(t *testing.T) map[int64]*repo.SentScheduledMessage {
+	sent := make(map[int64]*repo.SentScheduledMessage)
+	for {
+		s, err := strg.ScheduledMessage().SendDue(nil)
+		require.NoError(t, err)
+		if s == nil {
+			return sent
+		}
+		sent[s.ScheduledID] = s
+	}
+}
+
+func TestScheduledMessages(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	scheduled, err := strg.ScheduledMessage().Create(&repo.ScheduledMessage{
+		Message: faker.Sentence(),
+		UserID:  member.ID,
+		ChatID:  chat.ID,
+		SendAt:  time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Only the author sees it until it is sent
+	list, err := strg.ScheduledMessage().GetAll(&repo.GetScheduledMessagesParams{
+		UserID: member.ID,
+		Limit:  10,
+		Page:   1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), list.Count)
+	require.Equal(t, scheduled.ID, list.Messages[0].ID)
+
+	list, err = strg.ScheduledMessage().GetAll(&repo.GetScheduledMessagesParams{
+		UserID: chat.UserID,
+		ChatID: chat.ID,
+		Limit:  10,
+		Page:   1,
+	})
+	require.NoError(t, err)
+	require.Zero(t, list.Count)
+
+	require.NotContains(t, sendDue(t), scheduled.ID)
+
+	_, err = strg.ScheduledMessage().Update(&repo.ScheduledMessage{
+		ID:      scheduled.ID,
+		Message: faker.Sentence(),
+		UserID:  chat.UserID,
+		SendAt:  time.Now(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updated, err := strg.ScheduledMessage().Update(&repo.ScheduledMessage{
+		ID:      scheduled.ID,
+		Message: "announcement",
+		UserID:  member.ID,
+		SendAt:  time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "announcement", updated.Message)
+
+	sent := sendDue(t)
+	require.Contains(t, sent, scheduled.ID)
+	message := sent[scheduled.ID].Message
+	require.NotNil(t, message)
+	require.Equal(t, "announcement", message.Message)
+	require.Equal(t, member.ID, message.UserId)
+
+	messages, err := strg.ChatMessage().GetAll(&repo.GetAllMessagesParams{
+		Limit:  10,
+		Page:   1,
+		ChatId: chat.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), messages.Count)
+	require.Equal(t, message.ID, messages.Messages[0].ID)
+
+	// Sent once
+	require.NotContains(t, sendDue(t), scheduled.ID)
+	err = strg.ScheduledMessage().Delete(scheduled.ID, member.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCancelScheduledMessage(t *testing.T) {
+	chat := createChat(t)
+
+	scheduled, err := strg.ScheduledMessage().Create(&repo.ScheduledMessage{
+		Message: faker.Sentence(),
+		UserID:  chat.UserID,
+		ChatID:  chat.ID,
+		SendAt:  time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	err = strg.ScheduledMessage().Delete(scheduled.ID, createUser(t).ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = strg.ScheduledMessage().Delete(scheduled.ID, chat.UserID)
+	require.NoError(t, err)
+}
+
+func TestScheduledMessageDroppedAfterLeavingChat(t *testing.T) {
+	member := createUser(t)
+	chat := createChat(t, member.ID)
+
+	scheduled, err := strg.ScheduledMessage().Create(&repo.ScheduledMessage{
+		Message: faker.Sentence(),
+		UserID:  member.ID,
+		ChatID:  chat.ID,
+		SendAt:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	err = strg.Chat().RemoveMember(&repo.RemoveMemberRequest{
+		ChatId: chat.ID,
+		UserId: member.ID,
+	})
+	require.NoError(t, err)
+
+	sent := sendDue(t)
+	require.Contains(t, sent, scheduled.ID)
+	require.Nil(t, sent[scheduled.ID].Message)
+}
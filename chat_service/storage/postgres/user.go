@@ -72,7 +72,8 @@ func (ur *userRepo) Get(id int64) (*repo.User, error) {
 			username,
 			profile_image_url,
 			type,
-			created_at
+			created_at,
+			last_seen_visibility
 		FROM users
 		WHERE id=$1
 	`
@@ -88,6 +89,7 @@ func (ur *userRepo) Get(id int64) (*repo.User, error) {
 		&profileImageUrl,
 		&result.Type,
 		&result.CreatedAt,
+		&result.LastSeenVisibility,
 	)
 	if err != nil {
 		return nil, err
@@ -311,3 +313,44 @@ func (ur *userRepo) SetUserImage(req *repo.SetUserImageRequest) (*repo.User, err
 	}
 	return result, nil
 }
+
+func (ur *userRepo) SetLastSeenVisibility(userID int64, visibility string) (*repo.User, error) {
+	var (
+		result                    repo.User
+		username, profileImageUrl sql.NullString
+	)
+
+	row := ur.db.QueryRow(`
+		UPDATE users SET
+			last_seen_visibility=$1
+		WHERE id=$2
+		RETURNING
+			id,
+			first_name,
+			last_name,
+			email,
+			username,
+			profile_image_url,
+			type,
+			created_at,
+			last_seen_visibility
+	`, visibility, userID)
+	if err := row.Scan(
+		&result.ID,
+		&result.FirstName,
+		&result.LastName,
+		&result.Email,
+		&username,
+		&profileImageUrl,
+		&result.Type,
+		&result.CreatedAt,
+		&result.LastSeenVisibility,
+	); err != nil {
+		return nil, err
+	}
+
+	result.Username = username.String
+	result.ProfileImageUrl = profileImageUrl.String
+
+	return &result, nil
+}
@@ -0,0 +1,31 @@
+package repo
+
+import "time"
+
+type AttachmentStorageI interface {
+	Create(a *Attachment) (*Attachment, error)
+	// GetMedia finds what refers to the stored file, sql.ErrNoRows once
+	// nothing does. With a userID only attachments the user uploaded or
+	// that were sent to their chats are found. Without one profile and
+	// chat images are found too, returned with only the URL set.
+	GetMedia(url string, userID int64) (*Attachment, error)
+}
+
+// Attachment is an uploaded media file, it can be sent once by its uploader
+// with a message.
+type Attachment struct {
+	ID     int64
+	UserID int64
+	// 0 until it is sent
+	MessageID int64
+	URL       string
+	FileName  string
+	MimeType  string
+	Size      int64
+	// Pixels for images and videos, 0 when unknown
+	Width  int32
+	Height int32
+	// Seconds for videos and voice notes, 0 when unknown
+	Duration  int32
+	CreatedAt time.Time
+}
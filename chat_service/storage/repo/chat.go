@@ -8,10 +8,13 @@ type ChatStrogeI interface {
 	Update(chat *Chat) (*Chat, error)
 	Delete(id, user_id int64) error
 	GetAll(params *GetAllChatsParams) (*GetAllChats, error)
+	UpdateSettings(req *UpdateChatSettingsReq) error
 
 	AddMember(*AddMemberRequest) error
 	RemoveMember(*RemoveMemberRequest) error
 	GetChatMembers(params *GetChatMembersParams) (*GetAllUsersResult, error)
+	GetChatPeers(userID int64) ([]int64, error)
+	HaveCommonChat(userID, otherUserID int64) (bool, error)
 }
 
 type Chat struct {
@@ -21,6 +24,44 @@ type Chat struct {
 	UserInfo *GetUserInfo
 	ChatType string
 	ImageUrl string
+
+	// Set in the chat list of a user
+	LastMessage        *LastMessage
+	UnreadCount        int64
+	UnreadMentionCount int64
+	LastActivityAt     time.Time
+
+	Settings ChatSettings
+}
+
+type ChatSettings struct {
+	// Any emoji is allowed when empty
+	AllowedReactions []string
+	// Messages of the chat can't be forwarded to other chats
+	DisallowForwarding bool
+	// Messages can be edited for this long after they were sent, forever
+	// when 0
+	EditWindowSeconds int64
+	// Authors can delete their messages for everyone for this long after
+	// they were sent, forever when 0. The owner of the chat always can.
+	DeleteWindowSeconds int64
+}
+
+type UpdateChatSettingsReq struct {
+	ChatID int64
+	// Only the owner of the chat can change its settings
+	UserID   int64
+	Settings ChatSettings
+}
+
+type LastMessage struct {
+	ID      int64
+	Message string
+	// Lets the chat list show e.g. "Photo" for a message without a caption
+	Type      string
+	UserID    int64
+	UserInfo  *GetUserInfo
+	CreatedAt time.Time
 }
 
 type CreateChatReq struct {
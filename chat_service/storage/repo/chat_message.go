@@ -1,30 +1,298 @@
 package repo
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrReplyNotInChat is returned when a message replies to a message of
+// another chat or to one that doesn't exist.
+var ErrReplyNotInChat = errors.New("replied message is not in the chat")
+
+// ErrInvalidThreadRoot is returned when a thread reply names a root which is
+// not a message of the chat or is itself a thread reply.
+var ErrInvalidThreadRoot = errors.New("thread root is not a message of the chat")
+
+// ErrReactionNotAllowed is returned when the emoji is not in the allowed
+// reactions of the chat.
+var ErrReactionNotAllowed = errors.New("reaction is not allowed in the chat")
+
+// ErrForwardingDisallowed is returned when a message of a chat which
+// disallows forwarding is forwarded.
+var ErrForwardingDisallowed = errors.New("forwarding is disallowed in the chat")
+
+// ErrEditWindowExpired is returned when a message is edited after the edit
+// window of its chat.
+var ErrEditWindowExpired = errors.New("message can't be edited anymore")
+
+// ErrDeleteWindowExpired is returned when the author deletes a message for
+// everyone after the delete window of its chat.
+var ErrDeleteWindowExpired = errors.New("message can't be deleted for everyone anymore")
+
+// ErrPinNotAllowed is returned when a member of a group chat other than its
+// owner pins or unpins a message.
+var ErrPinNotAllowed = errors.New("only the owner of the chat can pin messages")
+
+// ErrInvalidAttachment is returned when a message names an attachment which
+// the author didn't upload, which was already sent or which doesn't fit the
+// type of the message.
+var ErrInvalidAttachment = errors.New("attachment can't be sent with the message")
+
+// ErrClientMessageIDReused is returned when a message is sent with the
+// client message id of a message of the user in another chat.
+var ErrClientMessageIDReused = errors.New("client_message_id is already used for a message of another chat")
+
+// Types of messages.
+const (
+	MessageTypeText = "text"
+	// Written by the service, e.g. when a message is pinned
+	MessageTypeSystem = "system"
+
+	// Sent with attachments, Message is an optional caption
+	MessageTypePhoto   = "photo"
+	MessageTypeVideo   = "video"
+	MessageTypeFile    = "file"
+	MessageTypeVoice   = "voice"
+	MessageTypeSticker = "sticker"
+
+	MessageTypeLocation = "location"
+	MessageTypeContact  = "contact"
+)
 
 type ChatMessageStrogeI interface {
 	Create(m *ChatMessage) (*ChatMessage, error)
 	Update(m *ChatMessage) (*ChatMessage, error)
-	Delete(id, user_id int64) error
+	Delete(params *DeleteMessagesParams) ([]*DeletedMessage, error)
 	GetAll(params *GetAllMessagesParams) (*GetAllMessages, error)
+	GetThread(params *GetThreadParams) (*GetAllMessages, error)
+	GetAllSince(params *GetMessagesSinceParams) (*GetAllMessages, error)
+	MarkRead(params *MarkReadParams) (*ReadCursor, error)
+	GetReadBy(params *GetReadByParams) (*GetAllUsersResult, error)
+	AddReaction(params *ReactionParams) (*MessageReactions, error)
+	RemoveReaction(params *ReactionParams) (*MessageReactions, error)
+	Forward(params *ForwardParams) ([]*ChatMessage, error)
+	GetRevisions(params *GetRevisionsParams) (*MessageRevisions, error)
+	Pin(params *PinParams) (*PinResult, error)
+	Unpin(params *PinParams) (*ChatMessage, error)
+	GetPinned(params *GetPinnedParams) (*GetAllMessages, error)
 }
 
 type ChatMessage struct {
-	ID        int64
-	Message   string
-	UserId    int64
+	ID              int64
+	Message         string
+	UserId          int64
+	UserInfo        *GetUserInfo
+	ChatId          int64
+	CreatedAt       time.Time
+	ClientMessageID string
+	// Usernames mentioned in the message, only members of the chat are
+	// stored as mentioned
+	Mentions []string
+
+	ReplyToMessageID int64
+	ReplyTo          *QuotedMessage
+
+	// Set for thread replies, they are not part of the chat timeline
+	ThreadRootID int64
+	// Thread started by the message, nil when it has no replies. For a
+	// thread reply returned by Create or Update it is the thread of the
+	// reply.
+	Thread *ThreadSummary
+
+	// Reactions per emoji, Reacted is set for the user the message was
+	// fetched for
+	Reactions []*Reaction
+
+	// Set when the message was forwarded from another chat
+	ForwardedFrom *ForwardedFrom
+
+	// Set once the message was edited
+	EditedAt *time.Time
+	// Set once the message was deleted for everyone, Message is empty
+	DeletedAt *time.Time
+
+	Type   string
+	Pinned bool
+
+	// Uploaded media of the message in the order they were attached. Create
+	// attaches the ones named by AttachmentIDs.
+	Attachments   []*Attachment
+	AttachmentIDs []int64
+	// Set for location and contact messages
+	Location *Location
+	Contact  *Contact
+
+	// Set by Create when the message was resent and the persisted one is
+	// returned, the members got it already
+	Resent bool
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
+	// Set when the contact is a user of the service
+	UserID int64 `json:"user_id,omitempty"`
+}
+
+type PinParams struct {
+	MessageID int64
+	// Owner of a group chat or member of a private chat
+	UserID int64
+}
+
+type PinResult struct {
+	Message *ChatMessage
+	// System message appended to the chat, nil when the message was
+	// already pinned
+	SystemMessage *ChatMessage
+}
+
+type GetPinnedParams struct {
+	ChatID int64
+	UserID int64
+	Limit  int64
+	Page   int64
+}
+
+type DeleteMessagesParams struct {
+	// Distinct ids of the messages to delete
+	MessageIDs []int64
+	UserID     int64
+	// Hide the messages for the user instead of deleting them for everyone
+	OnlyMe bool
+}
+
+type DeletedMessage struct {
+	ID     int64
+	ChatID int64
+}
+
+// MessageRevision is a previous text of an edited message.
+type MessageRevision struct {
+	Message string
+	// When the text was written
+	CreatedAt time.Time
+}
+
+type GetRevisionsParams struct {
+	MessageID int64
+	// Only the author of the message and the owner of its chat can see
+	// the revisions
+	UserID int64
+	Limit  int64
+	Page   int64
+}
+
+type MessageRevisions struct {
+	Revisions []*MessageRevision
+	Count     int64
+}
+
+// ForwardedFrom is the original of a forwarded message, a message forwarded
+// again keeps the first original.
+type ForwardedFrom struct {
+	MessageID int64
+	ChatID    int64
+	UserID    int64
+	// Nil when the original sender was deleted
 	UserInfo  *GetUserInfo
-	ChatId    int64
 	CreatedAt time.Time
 }
 
+type ForwardParams struct {
+	// Distinct ids of the messages to forward
+	MessageIDs []int64
+	// Chat the messages are forwarded to
+	ChatID int64
+	UserID int64
+}
+
+type Reaction struct {
+	Emoji   string
+	Count   int64
+	Reacted bool
+}
+
+type ReactionParams struct {
+	MessageID int64
+	UserID    int64
+	Emoji     string
+}
+
+type MessageReactions struct {
+	MessageID int64
+	ChatID    int64
+	Reactions []*Reaction
+}
+
+type ThreadSummary struct {
+	RootID      int64
+	ReplyCount  int64
+	LastReplyAt time.Time
+}
+
+// QuotedMessage is the preview of a replied message. Only ID is set when
+// the message was deleted.
+type QuotedMessage struct {
+	ID       int64
+	Message  string
+	Type     string
+	UserID   int64
+	UserInfo *GetUserInfo
+	Deleted  bool
+}
+
 type GetAllMessagesParams struct {
 	Limit  int64
 	Page   int64
 	ChatId int64
+	// User the messages are listed for, only the messages of the chats the
+	// user is a member of are listed and the reactions are marked as
+	// reacted for the user
+	UserID int64
+}
+
+type GetThreadParams struct {
+	RootID int64
+	UserID int64
+	Limit  int64
+	Page   int64
+}
+
+type GetMessagesSinceParams struct {
+	UserID        int64
+	LastMessageID int64
+	Limit         int64
 }
 
 type GetAllMessages struct {
 	Messages []*ChatMessage
 	Count    int64
 }
+
+type MarkReadParams struct {
+	ChatID    int64
+	UserID    int64
+	MessageID int64
+}
+
+type ReadCursor struct {
+	ChatID            int64
+	UserID            int64
+	LastReadMessageID int64
+	// Authors of the messages read by the call
+	SenderIDs []int64
+}
+
+type GetReadByParams struct {
+	MessageID int64
+	UserID    int64
+	Limit     int64
+	Page      int64
+}
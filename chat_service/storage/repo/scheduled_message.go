@@ -0,0 +1,48 @@
+package repo
+
+import "time"
+
+type ScheduledMessageStorageI interface {
+	Create(m *ScheduledMessage) (*ScheduledMessage, error)
+	Update(m *ScheduledMessage) (*ScheduledMessage, error)
+	Delete(id, userID int64) error
+	GetAll(params *GetScheduledMessagesParams) (*GetScheduledMessages, error)
+	SendDue(skip []int64) (*SentScheduledMessage, error)
+}
+
+// ScheduledMessage is created as a chat message at SendAt, only its author
+// can see it until then.
+type ScheduledMessage struct {
+	ID               int64
+	Message          string
+	UserID           int64
+	UserInfo         *GetUserInfo
+	ChatID           int64
+	ClientMessageID  string
+	ReplyToMessageID int64
+	ThreadRootID     int64
+	SendAt           time.Time
+	CreatedAt        time.Time
+}
+
+type GetScheduledMessagesParams struct {
+	// Author of the messages
+	UserID int64
+	// All chats of the user when 0
+	ChatID int64
+	Limit  int64
+	Page   int64
+}
+
+type GetScheduledMessages struct {
+	Messages []*ScheduledMessage
+	Count    int64
+}
+
+// SentScheduledMessage is a scheduled message the dispatcher handled.
+// Message is nil when it was dropped because its author left the chat or
+// the message it replies to is gone.
+type SentScheduledMessage struct {
+	ScheduledID int64
+	Message     *ChatMessage
+}
@@ -7,6 +7,13 @@ const (
 	UserTypeUser       = "user"
 )
 
+// Who may see whether a user is online and when it was last seen.
+const (
+	LastSeenEverybody = "everybody"
+	LastSeenChats     = "chats"
+	LastSeenNobody    = "nobody"
+)
+
 type User struct {
 	ID              int64
 	FirstName       string
@@ -17,6 +24,8 @@ type User struct {
 	ProfileImageUrl string
 	Type            string
 	CreatedAt       time.Time
+
+	LastSeenVisibility string
 }
 
 type GetAllUsersParams struct {
@@ -49,4 +58,5 @@ type UserStorageI interface {
 	Update(u *User) (*User, error)
 	Delete(id int64) error
 	SetUserImage(*SetUserImageRequest) (*User, error)
+	SetLastSeenVisibility(userID int64, visibility string) (*User, error)
 }
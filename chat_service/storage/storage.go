@@ -11,6 +11,8 @@ type StorageI interface {
 	Permission() repo.PermissionStorageI
 	Chat() repo.ChatStrogeI
 	ChatMessage() repo.ChatMessageStrogeI
+	ScheduledMessage() repo.ScheduledMessageStorageI
+	Attachment() repo.AttachmentStorageI
 }
 
 type storagePg struct {
@@ -18,6 +20,9 @@ type storagePg struct {
 	permissionRepo  repo.PermissionStorageI
 	chatRepo        repo.ChatStrogeI
 	chatMessageRepo repo.ChatMessageStrogeI
+
+	scheduledMessageRepo repo.ScheduledMessageStorageI
+	attachmentRepo       repo.AttachmentStorageI
 }
 
 func NewStoragePg(db *sqlx.DB) StorageI {
@@ -26,6 +31,9 @@ func NewStoragePg(db *sqlx.DB) StorageI {
 		permissionRepo:  postgres.NewPermission(db),
 		chatRepo:        postgres.NewChat(db),
 		chatMessageRepo: postgres.NewChatMessage(db),
+
+		scheduledMessageRepo: postgres.NewScheduledMessage(db),
+		attachmentRepo:       postgres.NewAttachment(db),
 	}
 }
 
@@ -44,3 +52,11 @@ func (s *storagePg) Chat() repo.ChatStrogeI {
 func (s *storagePg) ChatMessage() repo.ChatMessageStrogeI {
 	return s.chatMessageRepo
 }
+
+func (s *storagePg) ScheduledMessage() repo.ScheduledMessageStorageI {
+	return s.scheduledMessageRepo
+}
+
+func (s *storagePg) Attachment() repo.AttachmentStorageI {
+	return s.attachmentRepo
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 
+	"github.com/go-redis/redis/v9"
 	_ "github.com/lib/pq"
 
 	"gitlab.com/telegram_clone/websocket_service/config"
@@ -19,5 +20,30 @@ func main() {
 		log.Fatalf("failed to get grpc connections: %v", err)
 	}
 
-	websocket.Run(cfg, grpcConn)
+	var rdb *redis.Client
+	if cfg.Redis.Addr != "" {
+		rdb = redis.NewClient(&redis.Options{
+			Addr: cfg.Redis.Addr,
+		})
+	}
+
+	broker := websocket.NewMemoryBroker()
+	if cfg.Broker == config.BrokerRedis {
+		if rdb == nil {
+			log.Fatalf("the %s broker needs REDIS_ADDR", config.BrokerRedis)
+		}
+		broker = websocket.NewRedisBroker(rdb, cfg.Redis.Channel)
+	}
+
+	var chatEvents websocket.ChatEvents
+	if rdb != nil && cfg.Redis.ChatEventsChannel != "" {
+		chatEvents = websocket.NewRedisChatEvents(rdb, cfg.Redis.ChatEventsChannel)
+	}
+
+	var presence websocket.Presence
+	if rdb != nil {
+		presence = websocket.NewRedisPresence(rdb)
+	}
+
+	websocket.Run(cfg, grpcConn, broker, chatEvents, presence)
 }
@@ -1,15 +1,37 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
+const (
+	BrokerMemory = "memory"
+	BrokerRedis  = "redis"
+)
+
 type Config struct {
 	WsPort string
 
 	ChatServiceGrpcPort string
 	ChatServiceHost     string
+
+	// Broker is the fan-out backend between replicas, memory or redis.
+	Broker string
+	Redis  Redis
+
+	// How long chat members are cached when no change is reported.
+	MemberCacheTTL time.Duration
+}
+
+type Redis struct {
+	Addr    string
+	Channel string
+	// Channel the chat service publishes its events to, membership
+	// changes are received from it when set.
+	ChatEventsChannel string
 }
 
 func Load(path string) Config {
@@ -18,10 +40,21 @@ func Load(path string) Config {
 	conf := viper.New()
 	conf.AutomaticEnv()
 
+	conf.SetDefault("BROKER", BrokerMemory)
+	conf.SetDefault("REDIS_CHANNEL", "websocket_deliveries")
+	conf.SetDefault("MEMBER_CACHE_TTL", time.Minute)
+
 	cfg := Config{
 		WsPort:              conf.GetString("WS_PORT"),
 		ChatServiceHost:     conf.GetString("CHAT_SERVICE_HOST"),
 		ChatServiceGrpcPort: conf.GetString("CHAT_SERVICE_GRPC_PORT"),
+		Broker:              conf.GetString("BROKER"),
+		Redis: Redis{
+			Addr:              conf.GetString("REDIS_ADDR"),
+			Channel:           conf.GetString("REDIS_CHANNEL"),
+			ChatEventsChannel: conf.GetString("REDIS_CHAT_EVENTS_CHANNEL"),
+		},
+		MemberCacheTTL: conf.GetDuration("MEMBER_CACHE_TTL"),
 	}
 
 	return cfg
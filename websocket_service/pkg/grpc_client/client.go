@@ -13,6 +13,7 @@ type GrpcClientI interface {
 	AuthService() pbc.AuthServiceClient
 	ChatService() pbc.ChatServiceClient
 	MessageService() pbc.MessageServiceClient
+	UserService() pbc.UserServiceClient
 }
 
 type GrpcClient struct {
@@ -36,6 +37,7 @@ func New(cfg config.Config) (GrpcClientI, error) {
 			"auth_service":    pbc.NewAuthServiceClient(connChatService),
 			"chat_service":    pbc.NewChatServiceClient(connChatService),
 			"message_service": pbc.NewMessageServiceClient(connChatService),
+			"user_service":    pbc.NewUserServiceClient(connChatService),
 		},
 	}, nil
 }
@@ -51,3 +53,7 @@ func (g *GrpcClient) ChatService() pbc.ChatServiceClient {
 func (g *GrpcClient) MessageService() pbc.MessageServiceClient {
 	return g.connections["message_service"].(pbc.MessageServiceClient)
 }
+
+func (g *GrpcClient) UserService() pbc.UserServiceClient {
+	return g.connections["user_service"].(pbc.UserServiceClient)
+}
@@ -0,0 +1,49 @@
+package websocket
+
+import "encoding/json"
+
+// Delivery is an event addressed to the connections of some users. It is
+// published through the broker so every websocket service replica delivers
+// it to the users connected to it.
+type Delivery struct {
+	UserIDs []int64         `json:"user_ids"`
+	Data    json.RawMessage `json:"data"`
+
+	// The connection the event originated from, it already knows about it.
+	SkipUserID    int64  `json:"skip_user_id,omitempty"`
+	SkipSessionID string `json:"skip_session_id,omitempty"`
+}
+
+// Broker fans deliveries out to the hubs of all replicas.
+type Broker interface {
+	// Publish hands the delivery to every subscribed hub, this one included.
+	Publish(d *Delivery) error
+	// Subscribe registers the function called for every published delivery.
+	Subscribe(deliver func(d *Delivery)) error
+	Close() error
+}
+
+// memoryBroker delivers in process, it is enough when a single replica runs.
+type memoryBroker struct {
+	deliver func(d *Delivery)
+}
+
+func NewMemoryBroker() Broker {
+	return &memoryBroker{}
+}
+
+func (b *memoryBroker) Publish(d *Delivery) error {
+	if b.deliver != nil {
+		b.deliver(d)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(deliver func(d *Delivery)) error {
+	b.deliver = deliver
+	return nil
+}
+
+func (b *memoryBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/go-redis/redis/v9"
+)
+
+// redisBroker publishes deliveries to a redis channel every replica is
+// subscribed to.
+type redisBroker struct {
+	client  *redis.Client
+	channel string
+	pubsub  *redis.PubSub
+}
+
+func NewRedisBroker(rdb *redis.Client, channel string) Broker {
+	return &redisBroker{
+		client:  rdb,
+		channel: channel,
+	}
+}
+
+func (b *redisBroker) Publish(d *Delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(context.Background(), b.channel, data).Err()
+}
+
+func (b *redisBroker) Subscribe(deliver func(d *Delivery)) error {
+	b.pubsub = b.client.Subscribe(context.Background(), b.channel)
+
+	// Wait for the subscription to be confirmed so no delivery published
+	// after startup is missed.
+	if _, err := b.pubsub.Receive(context.Background()); err != nil {
+		return err
+	}
+
+	go func() {
+		for msg := range b.pubsub.Channel() {
+			var d Delivery
+			if err := json.Unmarshal([]byte(msg.Payload), &d); err != nil {
+				log.Printf("failed to unmarshal delivery: %v", err)
+				continue
+			}
+			deliver(&d)
+		}
+	}()
+
+	return nil
+}
+
+func (b *redisBroker) Close() error {
+	if b.pubsub != nil {
+		return b.pubsub.Close()
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/go-redis/redis/v9"
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Types of the events published by the chat service.
+const (
+	chatEventMemberAdded   = "chat.member_added"
+	chatEventMemberRemoved = "chat.member_removed"
+	chatEventDeleted       = "chat.deleted"
+	chatEventMessageRead   = "message.read"
+	chatEventReaction      = "message.reaction"
+	chatEventMessageUpdate = "message.updated"
+	chatEventMessageDelete = "message.deleted"
+	chatEventMessageCreate = "message.created"
+)
+
+// chatEvent is a change made through the chat service, e.g. by the api
+// gateway, which the hub has to know about.
+type chatEvent struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id"`
+	UserID int64  `json:"user_id,omitempty"`
+
+	MessageID int64   `json:"message_id,omitempty"`
+	UserIDs   []int64 `json:"user_ids,omitempty"`
+
+	// Messages of the chat deleted at once, only for the user with OnlyMe
+	MessageIDs []int64 `json:"message_ids,omitempty"`
+	OnlyMe     bool    `json:"only_me,omitempty"`
+
+	Reaction *struct {
+		Emoji     string     `json:"emoji"`
+		Added     bool       `json:"added"`
+		Reactions []Reaction `json:"reactions"`
+	} `json:"reaction,omitempty"`
+	// Protobuf JSON encoding of the chat service message
+	Message json.RawMessage `json:"message,omitempty"`
+}
+
+// ChatEvents is the source of the chat service events.
+type ChatEvents interface {
+	Subscribe(handle func(e *chatEvent)) error
+	Close() error
+}
+
+type redisChatEvents struct {
+	client  *redis.Client
+	channel string
+	pubsub  *redis.PubSub
+}
+
+func NewRedisChatEvents(rdb *redis.Client, channel string) ChatEvents {
+	return &redisChatEvents{
+		client:  rdb,
+		channel: channel,
+	}
+}
+
+func (s *redisChatEvents) Subscribe(handle func(e *chatEvent)) error {
+	s.pubsub = s.client.Subscribe(context.Background(), s.channel)
+	if _, err := s.pubsub.Receive(context.Background()); err != nil {
+		return err
+	}
+
+	go func() {
+		for msg := range s.pubsub.Channel() {
+			var e chatEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				log.Printf("failed to unmarshal chat event: %v", err)
+				continue
+			}
+			handle(&e)
+		}
+	}()
+
+	return nil
+}
+
+func (s *redisChatEvents) Close() error {
+	if s.pubsub != nil {
+		return s.pubsub.Close()
+	}
+	return nil
+}
+
+// handleChatEvent keeps the member cache up to date and tells the members
+// of the chat about the change. Every replica receives the event, so it is
+// delivered to the local connections only.
+func (h *Hub) handleChatEvent(e *chatEvent) {
+	switch e.Type {
+	case chatEventMessageRead:
+		h.relayRead(e)
+		return
+	case chatEventReaction:
+		h.relayReaction(e)
+		return
+	case chatEventMessageUpdate:
+		h.relayMessage(e, updatedEvent)
+		return
+	case chatEventMessageCreate:
+		h.relayMessage(e, createdEvent)
+		return
+	case chatEventMessageDelete:
+		h.relayMessageDeleted(e)
+		return
+	}
+
+	h.members.invalidate(e.ChatID)
+
+	var eventType string
+	switch e.Type {
+	case chatEventMemberAdded:
+		eventType = EventChatMemberAdded
+	case chatEventMemberRemoved:
+		eventType = EventChatMemberRemoved
+	default:
+		return
+	}
+
+	members, _, err := h.members.get(e.ChatID, e.UserID)
+	if err != nil {
+		log.Printf("failed to get chat members: %v", err)
+		return
+	}
+
+	data, err := newEnvelope(eventType, "", ChatMemberPayload{
+		ChatID: e.ChatID,
+		UserID: e.UserID,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// A removed member isn't in the list anymore but has to know as well.
+	h.deliver(&Delivery{
+		UserIDs: withUser(members, e.UserID),
+		Data:    data,
+	})
+}
+
+// relayRead tells the authors of the messages read, and the reader's
+// devices, about the new read cursor.
+func (h *Hub) relayRead(e *chatEvent) {
+	data, err := newEnvelope(EventRead, "", ReadPayload{
+		ChatID:            e.ChatID,
+		UserID:            e.UserID,
+		LastReadMessageID: e.MessageID,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	h.deliver(&Delivery{
+		UserIDs: withUser(e.UserIDs, e.UserID),
+		Data:    data,
+	})
+}
+
+// relayMessage sends a message changed through the chat service to the
+// members of its chat, e.g. edits made through the api gateway or system
+// messages. eventType picks the event it is sent with.
+func (h *Hub) relayMessage(e *chatEvent, eventType func(*chat_service.ChatMessage) string) {
+	var message chat_service.ChatMessage
+	if err := protojson.Unmarshal(e.Message, &message); err != nil {
+		log.Printf("failed to unmarshal %s message: %v", e.Type, err)
+		return
+	}
+
+	members, _, err := h.members.get(e.ChatID, e.UserID)
+	if err != nil {
+		log.Printf("failed to get chat members: %v", err)
+		return
+	}
+
+	data, err := newEnvelope(eventType(&message), "", parseMessage(&message))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	h.deliver(&Delivery{
+		UserIDs: members,
+		Data:    data,
+	})
+}
+
+// relayMessageDeleted tells the members of the chat about deleted messages,
+// or only the devices of the user when the messages were hidden for them.
+func (h *Hub) relayMessageDeleted(e *chatEvent) {
+	users := []int64{e.UserID}
+	if !e.OnlyMe {
+		members, _, err := h.members.get(e.ChatID, e.UserID)
+		if err != nil {
+			log.Printf("failed to get chat members: %v", err)
+			return
+		}
+		users = members
+	}
+
+	for _, id := range e.MessageIDs {
+		data, err := newEnvelope(EventMessageDeleted, "", MessageDeletedPayload{
+			ID:     id,
+			ChatID: e.ChatID,
+			OnlyMe: e.OnlyMe,
+		})
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		h.deliver(&Delivery{
+			UserIDs: users,
+			Data:    data,
+		})
+	}
+}
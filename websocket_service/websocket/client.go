@@ -3,8 +3,12 @@ package websocket
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -23,7 +27,7 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 
 	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	maxMessageSize = 4096
 )
 
 var (
@@ -41,11 +45,24 @@ type Client struct {
 	hub    *Hub
 	userID int64
 
+	// sessionID identifies the device or tab of the connection, a user can
+	// have several connections open at the same time.
+	sessionID string
+
 	// The websocket connection.
 	conn *websocket.Conn
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// While replaying the messages missed since the last connection, live
+	// events are held back in pending. Both are guarded by the mu of the
+	// user's registry shard.
+	replaying bool
+	pending   [][]byte
+
+	// Limits how often the connection sends typing indicators.
+	typing typingLimiter
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -55,7 +72,7 @@ type Client struct {
 // reads from this goroutine.
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.unregister(c)
 		c.conn.Close()
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
@@ -70,7 +87,7 @@ func (c *Client) readPump() {
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		c.hub.broadcast <- message
+		c.hub.dispatch(&inbound{client: c, data: message})
 	}
 }
 
@@ -124,6 +141,17 @@ func (c *Client) writePump() {
 func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request, grpcClient grpc_client.GrpcClientI) {
 	token := r.URL.Query().Get("token")
 
+	// Cursor of the last message the client has seen, set on reconnect.
+	var lastMessageID int64
+	if cursor := r.URL.Query().Get("last_message_id"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid last_message_id", http.StatusBadRequest)
+			return
+		}
+		lastMessageID = id
+	}
+
 	payload, err := grpcClient.AuthService().VerifyToken(context.Background(), &chat_service.VerifyTokenRequest{
 		AccessToken: token,
 	})
@@ -138,11 +166,33 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request, grpcClient grpc_c
 		return
 	}
 
-	client := &Client{hub: hub, userID: payload.UserId, conn: conn, send: make(chan []byte, 256)}
-	client.hub.register <- client
+	sessionID := r.URL.Query().Get("device_id")
+	if sessionID == "" {
+		sessionID = newSessionID()
+	}
+
+	client := &Client{hub: hub, userID: payload.UserId, sessionID: sessionID, conn: conn, send: make(chan []byte, 256), replaying: lastMessageID > 0}
+	client.hub.register(client)
+
+	// Catch up on what was missed while disconnected before going live.
+	if client.replaying {
+		if err := client.replay(lastMessageID); err != nil {
+			log.Printf("failed to replay messages: %v", err)
+		}
+	}
 
 	// Allow collection of memory referenced by the caller by doing all work in
 	// new goroutines.
 	go client.writePump()
 	go client.readPump()
 }
+
+// newSessionID generates an identifier for connections opened without an
+// explicit device_id.
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
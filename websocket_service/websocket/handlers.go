@@ -0,0 +1,323 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxClientMessageIDLength matches the client_message_id column.
+const maxClientMessageIDLength = 64
+
+// commandHandler executes a client command. The hub acknowledges the command
+// with the returned payload or sends the error back as an error event.
+type commandHandler func(client *Client, env *Envelope) (*AckPayload, *commandError)
+
+type commandError struct {
+	code    string
+	message string
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+func badRequest(message string) *commandError {
+	return &commandError{code: ErrCodeBadRequest, message: message}
+}
+
+func notMember(chatID int64) *commandError {
+	return &commandError{code: ErrCodeNotMember, message: fmt.Sprintf("you are not a member of chat %d", chatID)}
+}
+
+// grpcError converts an error of the chat service to a command error.
+func grpcError(err error, message string) *commandError {
+	s, _ := status.FromError(err)
+	switch s.Code() {
+	case codes.NotFound:
+		return &commandError{code: ErrCodeNotFound, message: message}
+	case codes.InvalidArgument:
+		return badRequest(s.Message())
+	case codes.FailedPrecondition, codes.PermissionDenied:
+		return &commandError{code: ErrCodeForbidden, message: s.Message()}
+	}
+	return &commandError{code: ErrCodeInternal, message: message}
+}
+
+func decodePayload(env *Envelope, v interface{}) *commandError {
+	if err := json.Unmarshal(env.Payload, v); err != nil {
+		return badRequest("invalid payload")
+	}
+	return nil
+}
+
+func (h *Hub) handleMessageCreate(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	var cmd MessageCreateCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.ChatID == 0 {
+		return nil, badRequest("chat_id is required")
+	}
+	// The chat service checks the content of the other types.
+	if (cmd.Type == "" || cmd.Type == "text") && cmd.Message == "" {
+		return nil, badRequest("message is required")
+	}
+	if len(cmd.ClientMessageID) > maxClientMessageIDLength {
+		return nil, badRequest(fmt.Sprintf("client_message_id must not be longer than %d", maxClientMessageIDLength))
+	}
+
+	members, ok, err := h.chatMembers(cmd.ChatID, client.userID)
+	if err != nil {
+		return nil, grpcError(err, "failed to get chat members")
+	}
+	if !ok {
+		return nil, notMember(cmd.ChatID)
+	}
+
+	// The sender is never taken from the frame, the connection is already
+	// authenticated.
+	message, err := h.grpcClient.MessageService().Create(context.Background(), &chat_service.ChatMessage{
+		Message: cmd.Message,
+		ChatId:  cmd.ChatID,
+		UserId:  client.userID,
+
+		ClientMessageId:  cmd.ClientMessageID,
+		ReplyToMessageId: cmd.ReplyToMessageID,
+		ThreadRootId:     cmd.ThreadRootID,
+		SendAt:           cmd.SendAt,
+
+		Type:          cmd.Type,
+		AttachmentIds: cmd.AttachmentIDs,
+		Location:      cmd.location(),
+		Contact:       cmd.contact(),
+	})
+	if err != nil {
+		return nil, grpcError(err, "failed to create message")
+	}
+
+	// The members got a resent message when it was first sent, and get a
+	// scheduled one through the chat service events once it is sent.
+	if message.Resent {
+		return &AckPayload{
+			MessageID:       message.Id,
+			ClientMessageID: message.ClientMessageId,
+			CreatedAt:       message.CreatedAt,
+			Resent:          true,
+		}, nil
+	}
+	if message.SendAt != "" {
+		return &AckPayload{
+			MessageID:       message.Id,
+			ClientMessageID: message.ClientMessageId,
+			CreatedAt:       message.CreatedAt,
+			SendAt:          message.SendAt,
+		}, nil
+	}
+
+	data, err := newEnvelope(createdEvent(message), "", parseMessage(message))
+	if err != nil {
+		return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+	}
+
+	// The sender's other devices get the message as well, only the
+	// originating connection is skipped.
+	h.publish(members, data, client)
+
+	return &AckPayload{
+		MessageID:       message.Id,
+		ClientMessageID: message.ClientMessageId,
+		CreatedAt:       message.CreatedAt,
+	}, nil
+}
+
+func (h *Hub) handleMessageUpdate(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	var cmd MessageUpdateCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.ID == 0 || cmd.Message == "" {
+		return nil, badRequest("id and message are required")
+	}
+
+	message, err := h.grpcClient.MessageService().Update(context.Background(), &chat_service.ChatMessage{
+		Id:      cmd.ID,
+		Message: cmd.Message,
+		UserId:  client.userID,
+	})
+	if err != nil {
+		return nil, grpcError(err, "failed to update message")
+	}
+
+	// Otherwise the chat service event is relayed.
+	if h.relayChatEvents {
+		return &AckPayload{MessageID: message.Id}, nil
+	}
+
+	members, ok, err := h.chatMembers(message.ChatId, client.userID)
+	if err != nil {
+		return nil, grpcError(err, "failed to get chat members")
+	}
+	if !ok {
+		return nil, notMember(message.ChatId)
+	}
+
+	data, err := newEnvelope(updatedEvent(message), "", parseMessage(message))
+	if err != nil {
+		return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+	}
+	h.publish(members, data, client)
+
+	return &AckPayload{MessageID: message.Id}, nil
+}
+
+// handleMessageDelete deletes messages for everyone or hides them for the
+// user. The chat service checks the user may delete them, chat_id only
+// orders the command with the other commands of the chat.
+func (h *Hub) handleMessageDelete(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	var cmd MessageDeleteCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	ids := cmd.IDs
+	if cmd.ID > 0 {
+		ids = append(ids, cmd.ID)
+	}
+	if len(ids) == 0 {
+		return nil, badRequest("id or ids is required")
+	}
+
+	deleted, err := h.grpcClient.MessageService().DeleteMessages(context.Background(), &chat_service.DeleteMessagesRequest{
+		MessageIds: ids,
+		UserId:     client.userID,
+		OnlyMe:     cmd.OnlyMe,
+	})
+	if err != nil {
+		return nil, grpcError(err, "failed to delete message")
+	}
+
+	// Otherwise the chat service event is relayed.
+	if h.relayChatEvents {
+		return &AckPayload{MessageID: cmd.ID}, nil
+	}
+
+	byChat := make(map[int64][]int64)
+	for _, m := range deleted.Messages {
+		byChat[m.ChatId] = append(byChat[m.ChatId], m.Id)
+	}
+	for chatID, messageIDs := range byChat {
+		users := []int64{client.userID}
+		if !cmd.OnlyMe {
+			users, _, err = h.chatMembers(chatID, client.userID)
+			if err != nil {
+				return nil, grpcError(err, "failed to get chat members")
+			}
+		}
+
+		for _, id := range messageIDs {
+			data, err := newEnvelope(EventMessageDeleted, "", MessageDeletedPayload{
+				ID:     id,
+				ChatID: chatID,
+				OnlyMe: cmd.OnlyMe,
+			})
+			if err != nil {
+				return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+			}
+			h.publish(users, data, client)
+		}
+	}
+
+	return &AckPayload{MessageID: cmd.ID}, nil
+}
+
+func (h *Hub) handleChatAddMember(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	var cmd ChatAddMemberCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.ChatID == 0 || cmd.UserID == 0 {
+		return nil, badRequest("chat_id and user_id are required")
+	}
+
+	members, ok, err := h.chatMembers(cmd.ChatID, client.userID)
+	if err != nil {
+		return nil, grpcError(err, "failed to get chat members")
+	}
+	if !ok {
+		return nil, notMember(cmd.ChatID)
+	}
+
+	_, err = h.grpcClient.ChatService().AddMember(context.Background(), &chat_service.AddMemberRequest{
+		ChatId: cmd.ChatID,
+		UserId: cmd.UserID,
+	})
+	if err != nil {
+		return nil, grpcError(err, "failed to add member")
+	}
+
+	// Otherwise the chat service event is relayed to the members.
+	if h.relayChatEvents {
+		return &AckPayload{}, nil
+	}
+	h.members.invalidate(cmd.ChatID)
+
+	data, err := newEnvelope(EventChatMemberAdded, "", ChatMemberPayload{
+		ChatID: cmd.ChatID,
+		UserID: cmd.UserID,
+	})
+	if err != nil {
+		return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+	}
+
+	// The new member is told as well, the member list was read before it
+	// joined.
+	h.publish(withUser(members, cmd.UserID), data, client)
+
+	return &AckPayload{}, nil
+}
+
+func (h *Hub) handleMessageRead(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	var cmd MessageReadCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.ChatID == 0 || cmd.MessageID == 0 {
+		return nil, badRequest("chat_id and message_id are required")
+	}
+
+	cursor, err := h.grpcClient.MessageService().MarkRead(context.Background(), &chat_service.MarkReadRequest{
+		ChatId:    cmd.ChatID,
+		UserId:    client.userID,
+		MessageId: cmd.MessageID,
+	})
+	if err != nil {
+		return nil, grpcError(err, "failed to mark read")
+	}
+
+	// Otherwise the chat service event is relayed, nothing is sent when the
+	// cursor didn't move.
+	if h.relayChatEvents || len(cursor.SenderIds) == 0 {
+		return &AckPayload{MessageID: cursor.LastReadMessageId}, nil
+	}
+
+	data, err := newEnvelope(EventRead, "", ReadPayload{
+		ChatID:            cursor.ChatId,
+		UserID:            cursor.UserId,
+		LastReadMessageID: cursor.LastReadMessageId,
+	})
+	if err != nil {
+		return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+	}
+	h.publish(withUser(cursor.SenderIds, client.userID), data, client)
+
+	return &AckPayload{MessageID: cursor.LastReadMessageId}, nil
+}
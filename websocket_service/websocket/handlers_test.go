@@ -0,0 +1,229 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pbc "gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// testMessageService records the messages the hub asks the simulated chat
+// service of the benchmark to store. Like the chat service it stores a
+// client_message_id of a user once and returns the stored message marked as
+// resent for the next sends.
+type testMessageService struct {
+	*benchMessageService
+
+	mu       sync.Mutex
+	created  []*pbc.ChatMessage
+	byClient map[clientMessageKey]*pbc.ChatMessage
+}
+
+type clientMessageKey struct {
+	userID          int64
+	clientMessageID string
+}
+
+func (s *testMessageService) Create(ctx context.Context, in *pbc.ChatMessage, opts ...grpc.CallOption) (*pbc.ChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := clientMessageKey{in.UserId, in.ClientMessageId}
+	if stored, ok := s.byClient[key]; ok && key.clientMessageID != "" {
+		resent := proto.Clone(stored).(*pbc.ChatMessage)
+		resent.Resent = true
+		return resent, nil
+	}
+
+	message, err := s.benchMessageService.Create(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.created = append(s.created, in)
+	if s.byClient == nil {
+		s.byClient = make(map[clientMessageKey]*pbc.ChatMessage)
+	}
+	s.byClient[key] = message
+
+	return message, nil
+}
+
+func (s *testMessageService) createdMessages() []*pbc.ChatMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*pbc.ChatMessage(nil), s.created...)
+}
+
+// newTestHub returns a hub delivering in process, with users 1 to
+// benchChatSize in chat 1 and the next ones in chat 2 like in the benchmark.
+func newTestHub(t *testing.T) (*Hub, *testMessageService) {
+	messages := &testMessageService{benchMessageService: &benchMessageService{}}
+	grpcClient := &benchGrpcClient{
+		chats:    &benchChatService{},
+		messages: messages,
+	}
+
+	hub := newHub(grpcClient, NewMemoryBroker(), newMemberCache(grpcClient, time.Hour))
+	if err := hub.broker.Subscribe(hub.deliver); err != nil {
+		t.Fatal(err)
+	}
+
+	return hub, messages
+}
+
+// connect registers a connection of the user without a websocket, its
+// events are read with received.
+func connect(hub *Hub, userID int64, sessionID string) *Client {
+	client := &Client{
+		hub:       hub,
+		userID:    userID,
+		sessionID: sessionID,
+		send:      make(chan []byte, 64),
+	}
+	hub.register(client)
+	return client
+}
+
+// send runs a frame of the connection and returns once it is handled.
+func send(hub *Hub, client *Client, frame string) {
+	hub.handle(&inbound{client: client, data: []byte(frame)})
+}
+
+// received returns the events queued for the connection so far.
+func received(t *testing.T, client *Client) []Envelope {
+	t.Helper()
+
+	var events []Envelope
+	for {
+		select {
+		case data, ok := <-client.send:
+			if !ok {
+				return events
+			}
+			var env Envelope
+			if err := json.Unmarshal(data, &env); err != nil {
+				t.Fatalf("invalid event %s: %v", data, err)
+			}
+			events = append(events, env)
+		default:
+			return events
+		}
+	}
+}
+
+// onlyEvent fails unless exactly one event of the type was received.
+func onlyEvent(t *testing.T, events []Envelope, eventType string) Envelope {
+	t.Helper()
+
+	if len(events) != 1 || events[0].Type != eventType {
+		t.Fatalf("want a single %s event, got %+v", eventType, events)
+	}
+	return events[0]
+}
+
+func TestMessageCreateStampsSender(t *testing.T) {
+	hub, messages := newTestHub(t)
+	phone := connect(hub, 1, "phone")
+	laptop := connect(hub, 1, "laptop")
+	member := connect(hub, 2, "phone")
+
+	// Claims to be sent by another user
+	send(hub, phone, `{"v":1,"type":"message.create","id":"c-1","payload":{"chat_id":1,"message":"hello","user_id":7}}`)
+
+	created := messages.createdMessages()
+	if len(created) != 1 || created[0].UserId != 1 {
+		t.Fatalf("want one message of user 1 stored, got %+v", created)
+	}
+
+	ack := onlyEvent(t, received(t, phone), EventAck)
+	if ack.ID != "c-1" {
+		t.Errorf("ack id = %q, want c-1", ack.ID)
+	}
+
+	for _, client := range []*Client{laptop, member} {
+		event := onlyEvent(t, received(t, client), EventMessageCreated)
+		var message MessagePayload
+		if err := json.Unmarshal(event.Payload, &message); err != nil {
+			t.Fatal(err)
+		}
+		if message.UserID != 1 {
+			t.Errorf("%d/%s got a message of user %d, want 1", client.userID, client.sessionID, message.UserID)
+		}
+	}
+}
+
+func TestMessageCreateRejectsNonMembers(t *testing.T) {
+	hub, messages := newTestHub(t)
+	phone := connect(hub, 1, "phone")
+	laptop := connect(hub, 1, "laptop")
+	// A member of chat 2, user 1 is not
+	member := connect(hub, benchChatSize+1, "phone")
+
+	send(hub, phone, `{"v":1,"type":"message.create","id":"c-1","payload":{"chat_id":2,"message":"hello"}}`)
+
+	if created := messages.createdMessages(); len(created) != 0 {
+		t.Fatalf("want nothing stored, got %+v", created)
+	}
+
+	event := onlyEvent(t, received(t, phone), EventError)
+	if event.ID != "c-1" {
+		t.Errorf("error id = %q, want c-1", event.ID)
+	}
+	var payload ErrorPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Code != ErrCodeNotMember || payload.Message == "" {
+		t.Errorf("error = %+v, want %s with a message", payload, ErrCodeNotMember)
+	}
+
+	// Only the offending connection hears about it
+	for _, client := range []*Client{laptop, member} {
+		if events := received(t, client); len(events) != 0 {
+			t.Errorf("%d/%s got %+v, want nothing", client.userID, client.sessionID, events)
+		}
+	}
+}
+
+func TestMessageCreateResend(t *testing.T) {
+	hub, messages := newTestHub(t)
+	phone := connect(hub, 1, "phone")
+	member := connect(hub, 2, "phone")
+
+	frame := `{"v":1,"type":"message.create","id":"%s","payload":{"chat_id":1,"message":"hello","client_message_id":"7f3c"}}`
+	send(hub, phone, fmt.Sprintf(frame, "c-1"))
+	first := onlyEvent(t, received(t, phone), EventAck)
+	onlyEvent(t, received(t, member), EventMessageCreated)
+
+	// Sent again after a reconnect, the ack got lost
+	send(hub, phone, fmt.Sprintf(frame, "c-2"))
+	second := onlyEvent(t, received(t, phone), EventAck)
+
+	if created := messages.createdMessages(); len(created) != 1 {
+		t.Fatalf("want one message stored, got %d", len(created))
+	}
+
+	var firstAck, secondAck AckPayload
+	if err := json.Unmarshal(first.Payload, &firstAck); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(second.Payload, &secondAck); err != nil {
+		t.Fatal(err)
+	}
+	if firstAck.Resent || !secondAck.Resent {
+		t.Errorf("resent = %t then %t, want false then true", firstAck.Resent, secondAck.Resent)
+	}
+	if second.ID != "c-2" || secondAck.MessageID != firstAck.MessageID || secondAck.ClientMessageID != "7f3c" {
+		t.Errorf("second ack %s %+v, want c-2 acking message %d", second.ID, secondAck, firstAck.MessageID)
+	}
+
+	if events := received(t, member); len(events) != 0 {
+		t.Errorf("member got %+v again, want nothing", events)
+	}
+}
@@ -1,105 +1,198 @@
 package websocket
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
-	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
 	grpcPkg "gitlab.com/telegram_clone/websocket_service/pkg/grpc_client"
 )
 
 // Hub maintains the set of active clients and broadcasts messages to the
 // clients.
 type Hub struct {
-	// Registered clients.
-	clients map[int64]*Client
+	// Registered clients grouped by user. Connections register and
+	// deliveries arrive on many goroutines at once, so the registry is
+	// sharded by user to keep them from contending on a single lock.
+	clients *registry
 
-	// Inbound messages from the clients.
-	broadcast chan []byte
+	// Fans events out to the hubs of all replicas.
+	broker Broker
 
-	// Register requests from the clients.
-	register chan *Client
+	// Member ids of the chats.
+	members *memberCache
+
+	// Set when membership changes are received from the chat service, the
+	// member events are relayed from there instead of sent by the commands.
+	relayChatEvents bool
+
+	// Records which users are online, nil when presence is not tracked.
+	presence Presence
+
+	// Runs the inbound commands, one goroutine per chat.
+	workers *chatWorkers
 
-	// Unregister requests from clients.
-	unregister chan *Client
 	grpcClient grpcPkg.GrpcClientI
+
+	// Command handlers by envelope type.
+	handlers map[string]commandHandler
+}
+
+// inbound is a raw frame read from a client together with the connection
+// it arrived on, so the hub can trust the authenticated sender.
+type inbound struct {
+	client *Client
+	data   []byte
 }
 
-func newHub(grpcClient grpcPkg.GrpcClientI) *Hub {
-	return &Hub{
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		clients:    make(map[int64]*Client),
+func newHub(grpcClient grpcPkg.GrpcClientI, broker Broker, members *memberCache) *Hub {
+	h := &Hub{
+		clients:    newRegistry(),
+		broker:     broker,
+		members:    members,
+		workers:    newChatWorkers(),
 		grpcClient: grpcClient,
 	}
+	h.handlers = map[string]commandHandler{
+		CommandMessageCreate:  h.handleMessageCreate,
+		CommandMessageUpdate:  h.handleMessageUpdate,
+		CommandMessageDelete:  h.handleMessageDelete,
+		CommandChatAddMember:  h.handleChatAddMember,
+		CommandTyping:         h.handleTyping,
+		CommandMessageRead:    h.handleMessageRead,
+		CommandReactionAdd:    h.handleReactionAdd,
+		CommandReactionRemove: h.handleReactionRemove,
+	}
+
+	return h
 }
 
-type Message struct {
-	UserID   int64  `json:"user_id"`
-	ChatType string `json:"chat_type"`
-	ChatID   int64  `json:"chat_id"`
-	Message  string `json:"message"`
+func (h *Hub) register(client *Client) {
+	shard := h.clients.shard(client.userID)
+	shard.mu.Lock()
+	shard.add(client)
+	shard.mu.Unlock()
+	fmt.Println("New client connected", client.userID, client.sessionID)
+
+	// Presence changes of a user run in order on the worker of the user.
+	if h.presence != nil {
+		userID := client.userID
+		h.workers.dispatch(userWorkerKey(userID), func() {
+			h.connected(userID)
+		})
+	}
 }
 
-func (h *Hub) run() {
-	for {
-		select {
-		case client := <-h.register:
-			h.clients[client.userID] = client
-			fmt.Println("New client connected", client.userID)
-		case client := <-h.unregister:
-			if _, ok := h.clients[client.userID]; ok {
-				delete(h.clients, client.userID)
-				close(client.send)
-			}
-			fmt.Println("Client disconnected", client.userID)
-		case data := <-h.broadcast:
-			var message Message
-			err := json.Unmarshal(data, &message)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
+func (h *Hub) unregister(client *Client) {
+	shard := h.clients.shard(client.userID)
+	shard.mu.Lock()
+	shard.remove(client)
+	shard.mu.Unlock()
+	fmt.Println("Client disconnected", client.userID, client.sessionID)
 
-			// Create message
-			_, err = h.grpcClient.MessageService().Create(context.Background(), &chat_service.ChatMessage{
-				Message: message.Message,
-				ChatId:  message.ChatID,
-				UserId:  message.UserID,
-			})
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
+	if h.presence != nil {
+		userID, at := client.userID, time.Now()
+		h.workers.dispatch(userWorkerKey(userID), func() {
+			h.disconnected(userID, at)
+		})
+	}
+}
 
-			// Get Chat members
-			result, err := h.grpcClient.ChatService().GetChatMembers(context.Background(), &chat_service.GetChatMembersParams{
-				Limit:  1000,
-				Page:   1,
-				ChatId: message.ChatID,
-			})
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
+// dispatch hands an inbound frame to the worker of its chat.
+func (h *Hub) dispatch(in *inbound) {
+	h.workers.dispatch(workerKey(in), func() {
+		h.handle(in)
+	})
+}
+
+// handle decodes the envelope of an inbound frame and dispatches it to the
+// handler of its type.
+func (h *Hub) handle(in *inbound) {
+	var env Envelope
+	if err := json.Unmarshal(in.data, &env); err != nil {
+		h.sendError(in.client, "", ErrCodeBadRequest, "invalid envelope")
+		return
+	}
+
+	if env.V != ProtocolVersion {
+		h.sendError(in.client, env.ID, ErrCodeBadRequest, fmt.Sprintf("unsupported protocol version %d", env.V))
+		return
+	}
 
-			for _, user := range result.Users {
-				if message.UserID == user.Id {
-					continue
-				}
-
-				client, ok := h.clients[user.Id]
-				if ok {
-					select {
-					case client.send <- data:
-					default:
-						close(client.send)
-						delete(h.clients, client.userID)
-					}
-				}
+	handler, ok := h.handlers[env.Type]
+	if !ok {
+		h.sendError(in.client, env.ID, ErrCodeUnknownCommand, fmt.Sprintf("unknown command %q", env.Type))
+		return
+	}
+
+	ack, err := handler(in.client, &env)
+	if err != nil {
+		fmt.Println(err)
+		h.sendError(in.client, env.ID, err.code, err.message)
+		return
+	}
+
+	h.sendEvent(in.client, EventAck, env.ID, ack)
+}
+
+// chatMembers returns the member ids of the chat and reports whether the
+// user is one of them.
+func (h *Hub) chatMembers(chatID, userID int64) ([]int64, bool, error) {
+	return h.members.get(chatID, userID)
+}
+
+// publish hands the event to the broker, every replica delivers it to the
+// connections of the users it holds.
+func (h *Hub) publish(userIDs []int64, data []byte, skip *Client) {
+	d := &Delivery{
+		UserIDs: userIDs,
+		Data:    data,
+	}
+	if skip != nil {
+		d.SkipUserID = skip.userID
+		d.SkipSessionID = skip.sessionID
+	}
+
+	if err := h.broker.Publish(d); err != nil {
+		fmt.Println("failed to publish delivery:", err)
+	}
+}
+
+// deliver sends a published event to the local connections of its users.
+func (h *Hub) deliver(d *Delivery) {
+	for _, userID := range d.UserIDs {
+		shard := h.clients.shard(userID)
+		shard.mu.Lock()
+		for client := range shard.clients[userID] {
+			if client.userID == d.SkipUserID && client.sessionID == d.SkipSessionID {
+				continue
 			}
+			shard.send(client, d.Data)
 		}
+		shard.mu.Unlock()
+	}
+}
+
+// sendEvent sends an event to a single connection if it is still registered.
+func (h *Hub) sendEvent(client *Client, eventType, id string, payload interface{}) {
+	data, err := newEnvelope(eventType, id, payload)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
+
+	shard := h.clients.shard(client.userID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if shard.registered(client) {
+		shard.send(client, data)
+	}
+}
+
+func (h *Hub) sendError(client *Client, id, code, message string) {
+	h.sendEvent(client, EventError, id, ErrorPayload{
+		Code:    code,
+		Message: message,
+	})
 }
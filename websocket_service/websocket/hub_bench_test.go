@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pbc "gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+	"google.golang.org/grpc"
+)
+
+// Members of every simulated chat.
+const benchChatSize = 50
+
+// Time the simulated chat service takes to store a message.
+const benchCreateLatency = time.Millisecond
+
+type benchGrpcClient struct {
+	chats    pbc.ChatServiceClient
+	messages pbc.MessageServiceClient
+}
+
+func (g *benchGrpcClient) AuthService() pbc.AuthServiceClient       { return nil }
+func (g *benchGrpcClient) ChatService() pbc.ChatServiceClient       { return g.chats }
+func (g *benchGrpcClient) MessageService() pbc.MessageServiceClient { return g.messages }
+func (g *benchGrpcClient) UserService() pbc.UserServiceClient       { return nil }
+
+// benchChatService puts users 1 to benchChatSize in chat 1, the next
+// benchChatSize users in chat 2 and so on.
+type benchChatService struct {
+	pbc.ChatServiceClient
+}
+
+func (s *benchChatService) GetChatMembers(ctx context.Context, in *pbc.GetChatMembersParams, opts ...grpc.CallOption) (*pbc.GetAllUsersResponse, error) {
+	result := &pbc.GetAllUsersResponse{Count: benchChatSize}
+	if in.Page > 1 {
+		return result, nil
+	}
+	for i := int64(1); i <= benchChatSize; i++ {
+		result.Users = append(result.Users, &pbc.User{Id: (in.ChatId-1)*benchChatSize + i})
+	}
+	return result, nil
+}
+
+type benchMessageService struct {
+	pbc.MessageServiceClient
+	lastID int64
+}
+
+func (s *benchMessageService) Create(ctx context.Context, in *pbc.ChatMessage, opts ...grpc.CallOption) (*pbc.ChatMessage, error) {
+	time.Sleep(benchCreateLatency)
+	return &pbc.ChatMessage{
+		Id:              atomic.AddInt64(&s.lastID, 1),
+		Message:         in.Message,
+		ChatId:          in.ChatId,
+		UserId:          in.UserId,
+		ClientMessageId: in.ClientMessageId,
+		CreatedAt:       time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// BenchmarkHubMessageCreate sends message.create commands from simulated
+// clients spread over chats of benchChatSize members and waits until every
+// command is acknowledged, by then each message is delivered to its chat.
+func BenchmarkHubMessageCreate(b *testing.B) {
+	for _, clients := range []int{1000, 5000, 10000} {
+		b.Run(fmt.Sprintf("clients=%d", clients), func(b *testing.B) {
+			benchmarkHubMessageCreate(b, clients)
+		})
+	}
+}
+
+func benchmarkHubMessageCreate(b *testing.B, clientCount int) {
+	grpcClient := &benchGrpcClient{
+		chats:    &benchChatService{},
+		messages: &benchMessageService{},
+	}
+	hub := newHub(grpcClient, NewMemoryBroker(), newMemberCache(grpcClient, time.Hour))
+	if err := hub.broker.Subscribe(hub.deliver); err != nil {
+		b.Fatal(err)
+	}
+
+	var (
+		acks       sync.WaitGroup
+		deliveries int64
+		ack        = []byte(`"type":"ack"`)
+		drained    sync.WaitGroup
+	)
+
+	clients := make([]*Client, clientCount)
+	for i := range clients {
+		client := &Client{
+			hub:       hub,
+			userID:    int64(i + 1),
+			sessionID: "bench",
+			send:      make(chan []byte, 1024),
+		}
+		clients[i] = client
+
+		// Registered without hub.register, which logs every connection.
+		shard := hub.clients.shard(client.userID)
+		shard.mu.Lock()
+		shard.add(client)
+		shard.mu.Unlock()
+
+		drained.Add(1)
+		go func() {
+			defer drained.Done()
+			for data := range client.send {
+				if bytes.Contains(data, ack) {
+					acks.Done()
+				} else {
+					atomic.AddInt64(&deliveries, 1)
+				}
+			}
+		}()
+	}
+
+	frames := make([][]byte, clientCount)
+	for i, client := range clients {
+		chatID := (client.userID-1)/benchChatSize + 1
+		frames[i] = []byte(fmt.Sprintf(`{"v":1,"type":"message.create","id":"%d","payload":{"chat_id":%d,"message":"hello"}}`, i, chatID))
+	}
+
+	var next int64
+	acks.Add(b.N)
+	b.ResetTimer()
+	start := time.Now()
+
+	// Roughly one goroutine per connection, like the read pumps.
+	b.SetParallelism(clientCount / runtime.GOMAXPROCS(0))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := atomic.AddInt64(&next, 1) % int64(clientCount)
+			hub.dispatch(&inbound{client: clients[i], data: frames[i]})
+		}
+	})
+	acks.Wait()
+
+	elapsed := time.Since(start)
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)/elapsed.Seconds(), "msgs/s")
+	b.ReportMetric(float64(atomic.LoadInt64(&deliveries))/elapsed.Seconds(), "deliveries/s")
+
+	for _, client := range clients {
+		shard := hub.clients.shard(client.userID)
+		shard.mu.Lock()
+		shard.remove(client)
+		shard.mu.Unlock()
+	}
+	drained.Wait()
+}
@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+	grpcPkg "gitlab.com/telegram_clone/websocket_service/pkg/grpc_client"
+)
+
+// Page size used to read all members of a chat on a cache miss.
+const membersPageSize = 1000
+
+// memberCache keeps the member ids of chats so the hub doesn't ask the chat
+// service on every frame. Entries are filled on demand, dropped when the
+// chat service reports a membership change and expire after ttl as a safety
+// net for missed notifications.
+type memberCache struct {
+	grpcClient grpcPkg.GrpcClientI
+	ttl        time.Duration
+
+	mu    sync.RWMutex
+	chats map[int64]*chatMembers
+}
+
+type chatMembers struct {
+	ids       []int64
+	set       map[int64]struct{}
+	expiresAt time.Time
+}
+
+func newMemberCache(grpcClient grpcPkg.GrpcClientI, ttl time.Duration) *memberCache {
+	return &memberCache{
+		grpcClient: grpcClient,
+		ttl:        ttl,
+		chats:      make(map[int64]*chatMembers),
+	}
+}
+
+// get returns the member ids of the chat and reports whether the user is
+// one of them. The returned slice is shared and must not be modified.
+func (c *memberCache) get(chatID, userID int64) ([]int64, bool, error) {
+	c.mu.RLock()
+	members, ok := c.chats[chatID]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(members.expiresAt) {
+		var err error
+		members, err = c.load(chatID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// An empty chat is most likely a chat that doesn't exist (yet), it
+		// isn't worth caching.
+		if len(members.ids) > 0 {
+			c.mu.Lock()
+			c.chats[chatID] = members
+			c.mu.Unlock()
+		}
+	}
+
+	_, isMember := members.set[userID]
+	return members.ids, isMember, nil
+}
+
+// load reads every member of the chat page by page.
+func (c *memberCache) load(chatID int64) (*chatMembers, error) {
+	members := &chatMembers{
+		ids:       make([]int64, 0),
+		set:       make(map[int64]struct{}),
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	for page := int64(1); ; page++ {
+		result, err := c.grpcClient.ChatService().GetChatMembers(context.Background(), &chat_service.GetChatMembersParams{
+			Limit:  membersPageSize,
+			Page:   page,
+			ChatId: chatID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range result.Users {
+			if _, ok := members.set[user.Id]; ok {
+				continue
+			}
+			members.set[user.Id] = struct{}{}
+			members.ids = append(members.ids, user.Id)
+		}
+
+		if len(result.Users) < membersPageSize || int32(len(members.ids)) >= result.Count {
+			return members, nil
+		}
+	}
+}
+
+func (c *memberCache) invalidate(chatID int64) {
+	c.mu.Lock()
+	delete(c.chats, chatID)
+	c.mu.Unlock()
+}
+
+// withUser returns a copy of ids with userID appended unless it is there
+// already, a user listed twice would get the event twice.
+func withUser(ids []int64, userID int64) []int64 {
+	result := make([]int64, 0, len(ids)+1)
+	for _, id := range ids {
+		if id == userID {
+			return append(result, ids...)
+		}
+	}
+	result = append(result, ids...)
+	return append(result, userID)
+}
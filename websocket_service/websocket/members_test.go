@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pbc "gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+	"google.golang.org/grpc"
+)
+
+// testChatService counts the member pages read. Chats with a size set get
+// that many members, the others the ones of the benchmark.
+type testChatService struct {
+	*benchChatService
+	sizes map[int64]int64
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *testChatService) GetChatMembers(ctx context.Context, in *pbc.GetChatMembersParams, opts ...grpc.CallOption) (*pbc.GetAllUsersResponse, error) {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+
+	size, ok := s.sizes[in.ChatId]
+	if !ok {
+		return s.benchChatService.GetChatMembers(ctx, in, opts...)
+	}
+
+	result := &pbc.GetAllUsersResponse{Count: int32(size)}
+	for id := (in.Page-1)*in.Limit + 1; id <= in.Page*in.Limit && id <= size; id++ {
+		result.Users = append(result.Users, &pbc.User{Id: id})
+	}
+	return result, nil
+}
+
+func (s *testChatService) pages() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func newTestMemberCache(sizes map[int64]int64, ttl time.Duration) (*memberCache, *testChatService) {
+	chats := &testChatService{benchChatService: &benchChatService{}, sizes: sizes}
+	return newMemberCache(&benchGrpcClient{chats: chats}, ttl), chats
+}
+
+func TestMemberCacheReadsEveryPage(t *testing.T) {
+	cache, chats := newTestMemberCache(map[int64]int64{9: 2*membersPageSize + 500}, time.Hour)
+
+	ids, ok, err := cache.get(9, 2*membersPageSize+500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || len(ids) != 2*membersPageSize+500 {
+		t.Fatalf("got %d members, member %t, want %d and true", len(ids), ok, 2*membersPageSize+500)
+	}
+	if chats.pages() != 3 {
+		t.Errorf("read %d pages, want 3", chats.pages())
+	}
+}
+
+func TestMemberCacheInvalidate(t *testing.T) {
+	cache, chats := newTestMemberCache(nil, time.Hour)
+
+	if _, ok, err := cache.get(1, 1); err != nil || !ok {
+		t.Fatalf("user 1 in chat 1: %t, %v", ok, err)
+	}
+	if _, ok, err := cache.get(1, benchChatSize+1); err != nil || ok {
+		t.Fatalf("user %d in chat 1: %t, %v", benchChatSize+1, ok, err)
+	}
+	if chats.pages() != 1 {
+		t.Fatalf("read %d pages, want 1 for both lookups", chats.pages())
+	}
+
+	// A membership change was reported
+	cache.invalidate(1)
+	if _, _, err := cache.get(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if chats.pages() != 2 {
+		t.Errorf("read %d pages, want the chat read again", chats.pages())
+	}
+}
+
+func TestMemberCacheExpires(t *testing.T) {
+	cache, chats := newTestMemberCache(map[int64]int64{9: 0}, -time.Second)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := cache.get(1, 1); err != nil {
+			t.Fatal(err)
+		}
+		// An empty chat isn't cached
+		if _, ok, err := cache.get(9, 1); err != nil || ok {
+			t.Fatalf("user 1 in empty chat: %t, %v", ok, err)
+		}
+	}
+
+	if chats.pages() != 4 {
+		t.Errorf("read %d pages, want every lookup to read", chats.pages())
+	}
+}
+
+func TestChatEventInvalidatesMembers(t *testing.T) {
+	cache, chats := newTestMemberCache(map[int64]int64{9: 3}, time.Hour)
+	hub := newHub(&benchGrpcClient{chats: chats}, NewMemoryBroker(), cache)
+	joined := connect(hub, 4, "phone")
+
+	if _, ok, err := hub.chatMembers(9, 4); err != nil || ok {
+		t.Fatalf("user 4 in chat 9 before joining: %t, %v", ok, err)
+	}
+
+	// Added through the api gateway
+	chats.sizes[9] = 4
+	hub.handleChatEvent(&chatEvent{Type: chatEventMemberAdded, ChatID: 9, UserID: 4})
+
+	onlyEvent(t, received(t, joined), EventChatMemberAdded)
+	if _, ok, err := hub.chatMembers(9, 4); err != nil || !ok {
+		t.Errorf("user 4 in chat 9 after joining: %t, %v", ok, err)
+	}
+}
+
+func TestWithUser(t *testing.T) {
+	ids := []int64{1, 2}
+
+	if got := withUser(ids, 3); len(got) != 3 || got[2] != 3 {
+		t.Errorf("withUser(%v, 3) = %v", ids, got)
+	}
+	if got := withUser(ids, 2); len(got) != 2 {
+		t.Errorf("withUser(%v, 2) = %v, want no duplicate", ids, got)
+	}
+}
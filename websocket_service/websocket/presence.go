@@ -0,0 +1,334 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+)
+
+// Keys the presence of a user is kept under, the chat service reads them to
+// return it with the user. The online key is a sorted set of the replicas
+// the user is connected to, scored by when the entry expires (unix
+// milliseconds) unless the replica renews it. The entries key holds the
+// same entries of every user as <user_id>:<replica>, for the replicas to
+// find the ones of a replica which stopped.
+const (
+	presenceOnlineKey   = "presence:online:%d"
+	presenceLastSeenKey = "presence:last_seen:%d"
+	presenceEntriesKey  = "presence:entries"
+)
+
+// A replica renews the entries of its users every presenceHeartbeat, an
+// entry not renewed for presenceTTL is of a replica which stopped, e.g.
+// crashed, and its users go offline.
+const (
+	presenceHeartbeat = 15 * time.Second
+	presenceTTL       = 3 * presenceHeartbeat
+)
+
+// Last seen visibility of the users hiding their presence from everyone.
+const lastSeenNobody = "nobody"
+
+// Presence records which users are connected to any replica.
+type Presence interface {
+	// Connect counts a new connection of the user and reports whether it is
+	// the only one.
+	Connect(userID int64) (bool, error)
+	// Disconnect counts a closed connection of the user and reports whether
+	// it was the last one, the user is last seen at that time then.
+	Disconnect(userID int64, at time.Time) (bool, error)
+	// Heartbeat renews the presence of the users connected to this replica.
+	Heartbeat() error
+	// Expire drops the presence kept by replicas which stopped renewing it
+	// and returns the users offline since then with when they were last seen.
+	Expire() (map[int64]time.Time, error)
+}
+
+type redisPresence struct {
+	client *redis.Client
+	// Identifies the entries of this replica
+	replica string
+
+	mu sync.Mutex
+	// Open connections of the users on this replica
+	connections map[int64]int
+	now         func() time.Time
+}
+
+func NewRedisPresence(rdb *redis.Client) Presence {
+	return &redisPresence{
+		client:      rdb,
+		replica:     newSessionID(),
+		connections: make(map[int64]int),
+		now:         time.Now,
+	}
+}
+
+func (p *redisPresence) keys(userID int64) []string {
+	return []string{
+		fmt.Sprintf(presenceOnlineKey, userID),
+		fmt.Sprintf(presenceLastSeenKey, userID),
+		presenceEntriesKey,
+	}
+}
+
+func (p *redisPresence) entry(userID int64) string {
+	return strconv.FormatInt(userID, 10) + ":" + p.replica
+}
+
+// connectScript adds the entry of the replica and reports whether it is the
+// only live one. Entries of replicas which stopped are dropped first.
+var connectScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+redis.call("ZADD", KEYS[1], ARGV[2], ARGV[3])
+redis.call("PEXPIREAT", KEYS[1], ARGV[2])
+redis.call("ZADD", KEYS[3], ARGV[2], ARGV[4])
+return redis.call("ZCARD", KEYS[1])
+`)
+
+func (p *redisPresence) Connect(userID int64) (bool, error) {
+	p.mu.Lock()
+	p.connections[userID]++
+	first := p.connections[userID] == 1
+	p.mu.Unlock()
+	// Online through this replica already
+	if !first {
+		return false, nil
+	}
+
+	now := p.now()
+	count, err := connectScript.Run(
+		context.Background(),
+		p.client,
+		p.keys(userID),
+		now.UnixMilli(),
+		now.Add(presenceTTL).UnixMilli(),
+		p.replica,
+		p.entry(userID),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// disconnectScript removes an entry of the user and records the last seen
+// time when no live one is left, in one step so a connection opened on
+// another replica meanwhile is not missed.
+var disconnectScript = redis.NewScript(`
+redis.call("ZREM", KEYS[1], ARGV[3])
+redis.call("ZREM", KEYS[3], ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZCARD", KEYS[1]) > 0 then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+redis.call("SET", KEYS[2], ARGV[2])
+return 1
+`)
+
+func (p *redisPresence) Disconnect(userID int64, at time.Time) (bool, error) {
+	p.mu.Lock()
+	p.connections[userID]--
+	last := p.connections[userID] <= 0
+	if last {
+		delete(p.connections, userID)
+	}
+	p.mu.Unlock()
+	if !last {
+		return false, nil
+	}
+
+	offline, err := disconnectScript.Run(
+		context.Background(),
+		p.client,
+		p.keys(userID),
+		p.now().UnixMilli(),
+		at.Format(time.RFC3339),
+		p.replica,
+		p.entry(userID),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return offline == 1, nil
+}
+
+// heartbeatScript renews an entry unless the user disconnected meanwhile.
+var heartbeatScript = redis.NewScript(`
+if redis.call("ZADD", KEYS[1], "XX", "CH", ARGV[1], ARGV[2]) == 0 and not redis.call("ZSCORE", KEYS[1], ARGV[2]) then
+	return 0
+end
+redis.call("PEXPIREAT", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[3], "XX", ARGV[1], ARGV[3])
+return 1
+`)
+
+func (p *redisPresence) Heartbeat() error {
+	p.mu.Lock()
+	users := make([]int64, 0, len(p.connections))
+	for userID := range p.connections {
+		users = append(users, userID)
+	}
+	p.mu.Unlock()
+
+	expiresAt := p.now().Add(presenceTTL).UnixMilli()
+
+	pipe := p.client.Pipeline()
+	for _, userID := range users {
+		heartbeatScript.Run(context.Background(), pipe, p.keys(userID), expiresAt, p.replica, p.entry(userID))
+	}
+	_, err := pipe.Exec(context.Background())
+
+	return err
+}
+
+// expireScript drops an expired entry and records when the user was last
+// seen when it was the last live one. Only the replica removing the entry
+// from the entries key gets 1, the user goes offline once.
+var expireScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[3], ARGV[3]) == 0 then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZCARD", KEYS[1]) > 0 then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+redis.call("SET", KEYS[2], ARGV[2])
+return 1
+`)
+
+func (p *redisPresence) Expire() (map[int64]time.Time, error) {
+	now := p.now()
+
+	entries, err := p.client.ZRangeByScoreWithScores(context.Background(), presenceEntriesKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	offline := make(map[int64]time.Time)
+	for _, z := range entries {
+		entry, _ := z.Member.(string)
+		user, replica, ok := strings.Cut(entry, ":")
+		userID, err := strconv.ParseInt(user, 10, 64)
+		if !ok || err != nil {
+			p.client.ZRem(context.Background(), presenceEntriesKey, entry)
+			continue
+		}
+
+		// Last seen at the last heartbeat of the replica
+		lastSeen := time.UnixMilli(int64(z.Score)).Add(-presenceTTL)
+		last, err := expireScript.Run(
+			context.Background(),
+			p.client,
+			p.keys(userID),
+			now.UnixMilli(),
+			lastSeen.UTC().Format(time.RFC3339),
+			entry,
+			replica,
+		).Int()
+		if err != nil {
+			return offline, err
+		}
+		if last == 1 {
+			offline[userID] = lastSeen
+		}
+	}
+
+	return offline, nil
+}
+
+// runPresence renews the presence of the users of this replica and tells the
+// peers of the users of stopped replicas that they went offline.
+func (h *Hub) runPresence() {
+	for range time.Tick(presenceHeartbeat) {
+		if err := h.presence.Heartbeat(); err != nil {
+			log.Printf("failed to renew presence: %v", err)
+		}
+
+		offline, err := h.presence.Expire()
+		if err != nil {
+			log.Printf("failed to expire presence: %v", err)
+		}
+		for userID, at := range offline {
+			userID, at := userID, at
+			h.workers.dispatch(userWorkerKey(userID), func() {
+				h.broadcastPresence(PresencePayload{UserID: userID, LastSeen: at.Format(time.RFC3339)})
+			})
+		}
+	}
+}
+
+// connected records the new connection of the user and tells the users
+// sharing a chat with it when it comes online.
+func (h *Hub) connected(userID int64) {
+	first, err := h.presence.Connect(userID)
+	if err != nil {
+		log.Printf("failed to record presence: %v", err)
+		return
+	}
+	if first {
+		h.broadcastPresence(PresencePayload{UserID: userID, Online: true})
+	}
+}
+
+// disconnected records the closed connection of the user and tells the
+// users sharing a chat with it when it goes offline.
+func (h *Hub) disconnected(userID int64, at time.Time) {
+	last, err := h.presence.Disconnect(userID, at)
+	if err != nil {
+		log.Printf("failed to record presence: %v", err)
+		return
+	}
+	if last {
+		h.broadcastPresence(PresencePayload{UserID: userID, LastSeen: at.Format(time.RFC3339)})
+	}
+}
+
+// broadcastPresence publishes a presence change to the users sharing a chat
+// with the user, unless it hides its presence from everyone.
+func (h *Hub) broadcastPresence(payload PresencePayload) {
+	user, err := h.grpcClient.UserService().Get(context.Background(), &chat_service.GetUserRequest{
+		Id:          payload.UserID,
+		RequesterId: payload.UserID,
+	})
+	if err != nil {
+		log.Printf("failed to get user: %v", err)
+		return
+	}
+	if user.LastSeenVisibility == lastSeenNobody {
+		return
+	}
+
+	peers, err := h.grpcClient.ChatService().GetChatPeers(context.Background(), &chat_service.GetChatPeersRequest{
+		UserId: payload.UserID,
+	})
+	if err != nil {
+		log.Printf("failed to get chat peers: %v", err)
+		return
+	}
+	if len(peers.UserIds) == 0 {
+		return
+	}
+
+	data, err := newEnvelope(EventPresence, "", payload)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	h.publish(peers.UserIds, data, nil)
+}
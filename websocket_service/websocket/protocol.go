@@ -0,0 +1,463 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+)
+
+// ProtocolVersion is the version of the envelope format spoken over /ws.
+// Frames with another version are rejected.
+const ProtocolVersion = 1
+
+// Envelope wraps every frame exchanged with the clients in both directions.
+// ID is set by the client on commands and echoed back on the events the
+// command produced for the same connection, e.g. an error.
+type Envelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Events sent by the server.
+const (
+	EventMessageCreated    = "message.created"
+	EventMessageUpdated    = "message.updated"
+	EventMessageDeleted    = "message.deleted"
+	EventChatMemberAdded   = "chat.member_added"
+	EventChatMemberRemoved = "chat.member_removed"
+	EventTyping            = "typing"
+	EventRead              = "read"
+	EventPresence          = "presence"
+	EventError             = "error"
+	EventAck               = "ack"
+	EventReplayCompleted   = "replay.completed"
+	EventReaction          = "reaction"
+
+	// Thread replies are sent apart from the chat timeline, the payload
+	// carries the updated thread of the root.
+	EventThreadMessageCreated = "thread.message_created"
+	EventThreadMessageUpdated = "thread.message_updated"
+)
+
+// Commands sent by the clients.
+const (
+	CommandMessageCreate  = "message.create"
+	CommandMessageUpdate  = "message.update"
+	CommandMessageDelete  = "message.delete"
+	CommandChatAddMember  = "chat.add_member"
+	CommandTyping         = "typing"
+	CommandMessageRead    = "message.read"
+	CommandReactionAdd    = "reaction.add"
+	CommandReactionRemove = "reaction.remove"
+)
+
+// Error codes of the error event.
+const (
+	ErrCodeBadRequest     = "bad_request"
+	ErrCodeUnknownCommand = "unknown_command"
+	ErrCodeNotMember      = "not_a_member"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeInternal       = "internal_error"
+	ErrCodeRateLimited    = "rate_limited"
+	ErrCodeForbidden      = "forbidden"
+)
+
+// AckPayload acknowledges a command, it is sent only to the connection the
+// command came from with the envelope id of the command.
+type AckPayload struct {
+	MessageID       int64  `json:"message_id,omitempty"`
+	ClientMessageID string `json:"client_message_id,omitempty"`
+	CreatedAt       string `json:"created_at,omitempty"`
+	// Set when the message was scheduled, MessageID is then the id of the
+	// scheduled message
+	SendAt string `json:"send_at,omitempty"`
+	// Set when an earlier send of the client_message_id stored the message,
+	// it isn't delivered again
+	Resent bool `json:"resent,omitempty"`
+}
+
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type UserInfo struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	ImageUrl  string `json:"image_url"`
+	CreatedAt string `json:"created_at"`
+}
+
+// MessagePayload is the persisted message as returned by the chat service.
+type MessagePayload struct {
+	ID        int64    `json:"id"`
+	Message   string   `json:"message"`
+	UserID    int64    `json:"user_id"`
+	UserInfo  UserInfo `json:"user_info"`
+	ChatID    int64    `json:"chat_id"`
+	CreatedAt string   `json:"created_at"`
+
+	ClientMessageID string `json:"client_message_id,omitempty"`
+
+	ReplyToMessageID int64          `json:"reply_to_message_id,omitempty"`
+	ReplyTo          *QuotedPayload `json:"reply_to,omitempty"`
+
+	ThreadRootID int64          `json:"thread_root_id,omitempty"`
+	Thread       *ThreadPayload `json:"thread,omitempty"`
+
+	Reactions []Reaction `json:"reactions,omitempty"`
+
+	ForwardedFrom *ForwardedPayload `json:"forwarded_from,omitempty"`
+	EditedAt      string            `json:"edited_at,omitempty"`
+	// Set once the message was deleted for everyone, Message is empty
+	DeletedAt string `json:"deleted_at,omitempty"`
+
+	// "text", "photo", "video", "file", "voice", "sticker", "location",
+	// "contact", or "system" for the messages written by the chat service,
+	// e.g. when a message is pinned
+	Type   string `json:"type"`
+	Pinned bool   `json:"pinned,omitempty"`
+
+	// Media of the message, Message is an optional caption for them
+	Attachments []AttachmentPayload `json:"attachments,omitempty"`
+	Location    *LocationPayload    `json:"location,omitempty"`
+	Contact     *ContactPayload     `json:"contact,omitempty"`
+}
+
+// AttachmentPayload is an uploaded file with what clients need to render it
+// before downloading it.
+type AttachmentPayload struct {
+	ID       int64  `json:"id"`
+	URL      string `json:"url"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	Width    int32  `json:"width,omitempty"`
+	Height   int32  `json:"height,omitempty"`
+	Duration int32  `json:"duration,omitempty"`
+}
+
+type LocationPayload struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ContactPayload struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
+	UserID      int64  `json:"user_id,omitempty"`
+}
+
+// ForwardedPayload is the original of a forwarded message.
+type ForwardedPayload struct {
+	MessageID int64     `json:"message_id"`
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	UserInfo  *UserInfo `json:"user_info,omitempty"`
+	CreatedAt string    `json:"created_at"`
+}
+
+// Reaction counts the reactions of a message with an emoji. Reacted is set
+// when the message was fetched for the user who reacted.
+type Reaction struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted,omitempty"`
+}
+
+// ReactionPayload tells the members of the chat that a user added or
+// removed a reaction, with the new counts of the message.
+type ReactionPayload struct {
+	ChatID    int64      `json:"chat_id"`
+	MessageID int64      `json:"message_id"`
+	UserID    int64      `json:"user_id"`
+	Emoji     string     `json:"emoji"`
+	Added     bool       `json:"added"`
+	Reactions []Reaction `json:"reactions"`
+}
+
+// ThreadPayload is the thread started by a message, or the thread of a
+// thread reply.
+type ThreadPayload struct {
+	RootID      int64  `json:"root_id"`
+	ReplyCount  int64  `json:"reply_count"`
+	LastReplyAt string `json:"last_reply_at,omitempty"`
+}
+
+// QuotedPayload is the preview of the message a message replies to. Only ID
+// is set when it was deleted.
+type QuotedPayload struct {
+	ID       int64     `json:"id"`
+	Message  string    `json:"message,omitempty"`
+	Type     string    `json:"type,omitempty"`
+	UserID   int64     `json:"user_id,omitempty"`
+	UserInfo *UserInfo `json:"user_info,omitempty"`
+	Deleted  bool      `json:"deleted,omitempty"`
+}
+
+// MessageDeletedPayload is sent once per deleted message. With OnlyMe the
+// message was hidden by the user on another device, the other members still
+// see it.
+type MessageDeletedPayload struct {
+	ID     int64 `json:"id"`
+	ChatID int64 `json:"chat_id"`
+	OnlyMe bool  `json:"only_me,omitempty"`
+}
+
+// ReplayCompletedPayload ends the replay of missed messages after a
+// reconnect, live events follow it.
+type ReplayCompletedPayload struct {
+	LastMessageID int64 `json:"last_message_id"`
+}
+
+type ChatMemberPayload struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+}
+
+// TypingPayload tells the members of a chat that a user started or stopped
+// typing. Typing indicators are not persisted, clients hide one after
+// ExpiresIn seconds unless it is refreshed.
+type TypingPayload struct {
+	ChatID    int64 `json:"chat_id"`
+	UserID    int64 `json:"user_id"`
+	Typing    bool  `json:"typing"`
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+}
+
+// PresencePayload tells the users sharing a chat with a user that it came
+// online or went offline, LastSeen is set on the latter.
+type PresencePayload struct {
+	UserID   int64  `json:"user_id"`
+	Online   bool   `json:"online"`
+	LastSeen string `json:"last_seen,omitempty"`
+}
+
+// ReadPayload tells the authors of the messages a member has read, and the
+// member's other devices, how far the member has read the chat.
+type ReadPayload struct {
+	ChatID            int64 `json:"chat_id"`
+	UserID            int64 `json:"user_id"`
+	LastReadMessageID int64 `json:"last_read_message_id"`
+}
+
+// MessageCreateCommand creates a message. ClientMessageID makes resending
+// safe, the same id always resolves to the same persisted message.
+// ReplyToMessageID must be a message of the same chat. ThreadRootID posts the
+// message as a reply in the thread of a message of the chat. A future
+// SendAt (RFC3339) schedules the message instead of sending it. Type is
+// "text" when empty, media messages name the files uploaded through the api
+// gateway with AttachmentIDs.
+type MessageCreateCommand struct {
+	ChatID           int64  `json:"chat_id"`
+	Message          string `json:"message"`
+	ClientMessageID  string `json:"client_message_id"`
+	ReplyToMessageID int64  `json:"reply_to_message_id"`
+	ThreadRootID     int64  `json:"thread_root_id"`
+	SendAt           string `json:"send_at"`
+
+	Type          string           `json:"type"`
+	AttachmentIDs []int64          `json:"attachment_ids"`
+	Location      *LocationPayload `json:"location"`
+	Contact       *ContactPayload  `json:"contact"`
+}
+
+func (cmd *MessageCreateCommand) location() *chat_service.Location {
+	if cmd.Location == nil {
+		return nil
+	}
+	return &chat_service.Location{
+		Latitude:  cmd.Location.Latitude,
+		Longitude: cmd.Location.Longitude,
+	}
+}
+
+func (cmd *MessageCreateCommand) contact() *chat_service.Contact {
+	if cmd.Contact == nil {
+		return nil
+	}
+	return &chat_service.Contact{
+		PhoneNumber: cmd.Contact.PhoneNumber,
+		FirstName:   cmd.Contact.FirstName,
+		LastName:    cmd.Contact.LastName,
+		UserId:      cmd.Contact.UserID,
+	}
+}
+
+type MessageUpdateCommand struct {
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+}
+
+// MessageDeleteCommand deletes the message ID, or up to a hundred messages
+// IDs at once, for everyone or with OnlyMe for the user only.
+type MessageDeleteCommand struct {
+	ID     int64   `json:"id"`
+	IDs    []int64 `json:"ids"`
+	ChatID int64   `json:"chat_id"`
+	OnlyMe bool    `json:"only_me"`
+}
+
+type ChatAddMemberCommand struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+}
+
+// TypingCommand starts or refreshes the typing indicator of the user in a
+// chat, with Stop set it hides it right away.
+type TypingCommand struct {
+	ChatID int64 `json:"chat_id"`
+	Stop   bool  `json:"stop"`
+}
+
+// ReactionCommand adds or removes a reaction of the user to a message of
+// the chat.
+type ReactionCommand struct {
+	ChatID    int64  `json:"chat_id"`
+	MessageID int64  `json:"message_id"`
+	Emoji     string `json:"emoji"`
+}
+
+// MessageReadCommand marks the messages of a chat up to MessageID read.
+type MessageReadCommand struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int64 `json:"message_id"`
+}
+
+func newEnvelope(eventType, id string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Envelope{
+		V:       ProtocolVersion,
+		Type:    eventType,
+		ID:      id,
+		Payload: data,
+	})
+}
+
+func parseMessage(m *chat_service.ChatMessage) MessagePayload {
+	payload := MessagePayload{
+		ID:        m.Id,
+		Message:   m.Message,
+		UserID:    m.UserId,
+		ChatID:    m.ChatId,
+		CreatedAt: m.CreatedAt,
+
+		ClientMessageID: m.ClientMessageId,
+
+		ReplyToMessageID: m.ReplyToMessageId,
+		ThreadRootID:     m.ThreadRootId,
+		EditedAt:         m.EditedAt,
+		DeletedAt:        m.DeletedAt,
+		Type:             m.Type,
+		Pinned:           m.Pinned,
+	}
+	if m.UserInfo != nil {
+		payload.UserInfo = parseUserInfo(m.UserInfo)
+	}
+	if m.ReplyTo != nil {
+		payload.ReplyTo = &QuotedPayload{
+			ID:      m.ReplyTo.Id,
+			Message: m.ReplyTo.Message,
+			Type:    m.ReplyTo.Type,
+			UserID:  m.ReplyTo.UserId,
+			Deleted: m.ReplyTo.Deleted,
+		}
+		if m.ReplyTo.UserInfo != nil {
+			userInfo := parseUserInfo(m.ReplyTo.UserInfo)
+			payload.ReplyTo.UserInfo = &userInfo
+		}
+	}
+
+	for _, r := range m.Reactions {
+		payload.Reactions = append(payload.Reactions, Reaction{
+			Emoji:   r.Emoji,
+			Count:   r.Count,
+			Reacted: r.Reacted,
+		})
+	}
+	if m.ForwardedFrom != nil {
+		payload.ForwardedFrom = &ForwardedPayload{
+			MessageID: m.ForwardedFrom.MessageId,
+			ChatID:    m.ForwardedFrom.ChatId,
+			UserID:    m.ForwardedFrom.UserId,
+			CreatedAt: m.ForwardedFrom.CreatedAt,
+		}
+		if m.ForwardedFrom.UserInfo != nil {
+			userInfo := parseUserInfo(m.ForwardedFrom.UserInfo)
+			payload.ForwardedFrom.UserInfo = &userInfo
+		}
+	}
+	if m.Thread != nil {
+		payload.Thread = &ThreadPayload{
+			RootID:      m.Thread.RootId,
+			ReplyCount:  m.Thread.ReplyCount,
+			LastReplyAt: m.Thread.LastReplyAt,
+		}
+	}
+
+	for _, a := range m.Attachments {
+		payload.Attachments = append(payload.Attachments, AttachmentPayload{
+			ID:       a.Id,
+			URL:      a.Url,
+			FileName: a.FileName,
+			MimeType: a.MimeType,
+			Size:     a.Size,
+			Width:    a.Width,
+			Height:   a.Height,
+			Duration: a.Duration,
+		})
+	}
+	if m.Location != nil {
+		payload.Location = &LocationPayload{
+			Latitude:  m.Location.Latitude,
+			Longitude: m.Location.Longitude,
+		}
+	}
+	if m.Contact != nil {
+		payload.Contact = &ContactPayload{
+			PhoneNumber: m.Contact.PhoneNumber,
+			FirstName:   m.Contact.FirstName,
+			LastName:    m.Contact.LastName,
+			UserID:      m.Contact.UserId,
+		}
+	}
+
+	return payload
+}
+
+// createdEvent is the event a new message is sent with, thread replies
+// don't show up in the chat timeline.
+func createdEvent(m *chat_service.ChatMessage) string {
+	if m.ThreadRootId > 0 {
+		return EventThreadMessageCreated
+	}
+	return EventMessageCreated
+}
+
+// updatedEvent is the event an edited message is sent with.
+func updatedEvent(m *chat_service.ChatMessage) string {
+	if m.ThreadRootId > 0 {
+		return EventThreadMessageUpdated
+	}
+	return EventMessageUpdated
+}
+
+func parseUserInfo(u *chat_service.GetUserInfo) UserInfo {
+	return UserInfo{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Username:  u.Username,
+		ImageUrl:  u.ImageUrl,
+		CreatedAt: u.CreatedAt,
+	}
+}
@@ -0,0 +1,49 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHandleRejectsInvalidEnvelopes(t *testing.T) {
+	hub, messages := newTestHub(t)
+	client := connect(hub, 1, "phone")
+
+	for _, tt := range []struct {
+		frame string
+		id    string
+		code  string
+	}{
+		{`not json`, "", ErrCodeBadRequest},
+		{`{"v":2,"type":"message.create","id":"c-1","payload":{"chat_id":1,"message":"hi"}}`, "c-1", ErrCodeBadRequest},
+		{`{"v":1,"type":"message.explode","id":"c-2","payload":{}}`, "c-2", ErrCodeUnknownCommand},
+		{`{"v":1,"type":"message.create","id":"c-3","payload":"hi"}`, "c-3", ErrCodeBadRequest},
+	} {
+		send(hub, client, tt.frame)
+
+		event := onlyEvent(t, received(t, client), EventError)
+		var payload ErrorPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if event.V != ProtocolVersion || event.ID != tt.id || payload.Code != tt.code {
+			t.Errorf("%s: got v%d %q %+v, want %q %s", tt.frame, event.V, event.ID, payload, tt.id, tt.code)
+		}
+	}
+
+	if created := messages.createdMessages(); len(created) != 0 {
+		t.Errorf("want nothing stored, got %+v", created)
+	}
+}
+
+func TestNewEnvelope(t *testing.T) {
+	data, err := newEnvelope(EventAck, "c-1", AckPayload{MessageID: 17})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"v":1,"type":"ack","id":"c-1","payload":{"message_id":17}}`
+	if string(data) != want {
+		t.Errorf("envelope = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"log"
+
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+)
+
+func (h *Hub) handleReactionAdd(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	return h.handleReaction(client, env, true)
+}
+
+func (h *Hub) handleReactionRemove(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	return h.handleReaction(client, env, false)
+}
+
+// handleReaction adds or removes a reaction and sends the new counts to the
+// members of the chat. The chat service checks the user is a member and the
+// emoji is allowed in the chat.
+func (h *Hub) handleReaction(client *Client, env *Envelope, add bool) (*AckPayload, *commandError) {
+	var cmd ReactionCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.ChatID == 0 || cmd.MessageID == 0 || cmd.Emoji == "" {
+		return nil, badRequest("chat_id, message_id and emoji are required")
+	}
+
+	members, ok, err := h.chatMembers(cmd.ChatID, client.userID)
+	if err != nil {
+		return nil, grpcError(err, "failed to get chat members")
+	}
+	if !ok {
+		return nil, notMember(cmd.ChatID)
+	}
+
+	req := &chat_service.ReactionRequest{
+		MessageId: cmd.MessageID,
+		UserId:    client.userID,
+		Emoji:     cmd.Emoji,
+	}
+	var reactions *chat_service.MessageReactions
+	if add {
+		reactions, err = h.grpcClient.MessageService().AddReaction(context.Background(), req)
+	} else {
+		reactions, err = h.grpcClient.MessageService().RemoveReaction(context.Background(), req)
+	}
+	if err != nil {
+		return nil, grpcError(err, "failed to react to message")
+	}
+
+	// Otherwise the chat service event is relayed.
+	if h.relayChatEvents {
+		return &AckPayload{MessageID: reactions.MessageId}, nil
+	}
+
+	payload := ReactionPayload{
+		ChatID:    reactions.ChatId,
+		MessageID: reactions.MessageId,
+		UserID:    client.userID,
+		Emoji:     cmd.Emoji,
+		Added:     add,
+		Reactions: make([]Reaction, 0, len(reactions.Reactions)),
+	}
+	for _, r := range reactions.Reactions {
+		payload.Reactions = append(payload.Reactions, Reaction{Emoji: r.Emoji, Count: r.Count})
+	}
+
+	data, err := newEnvelope(EventReaction, "", payload)
+	if err != nil {
+		return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+	}
+	h.publish(members, data, client)
+
+	return &AckPayload{MessageID: reactions.MessageId}, nil
+}
+
+// relayReaction tells the members of the chat about a reaction made through
+// the chat service.
+func (h *Hub) relayReaction(e *chatEvent) {
+	if e.Reaction == nil {
+		return
+	}
+
+	members, _, err := h.members.get(e.ChatID, e.UserID)
+	if err != nil {
+		log.Printf("failed to get chat members: %v", err)
+		return
+	}
+
+	data, err := newEnvelope(EventReaction, "", ReactionPayload{
+		ChatID:    e.ChatID,
+		MessageID: e.MessageID,
+		UserID:    e.UserID,
+		Emoji:     e.Reaction.Emoji,
+		Added:     e.Reaction.Added,
+		Reactions: e.Reaction.Reactions,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	h.deliver(&Delivery{
+		UserIDs: members,
+		Data:    data,
+	})
+}
@@ -0,0 +1,87 @@
+package websocket
+
+import "sync"
+
+// Number of shards the client registry is split into. Users are spread over
+// the shards by id, so deliveries to different users rarely wait on the
+// same lock.
+const registryShards = 64
+
+// registry holds the registered connections grouped by user, a user may be
+// connected from several devices at once.
+type registry struct {
+	shards [registryShards]registryShard
+}
+
+type registryShard struct {
+	mu      sync.Mutex
+	clients map[int64]map[*Client]bool
+}
+
+func newRegistry() *registry {
+	r := &registry{}
+	for i := range r.shards {
+		r.shards[i].clients = make(map[int64]map[*Client]bool)
+	}
+	return r
+}
+
+// shard returns the shard holding the connections of the user. Its mu also
+// guards the replay state of those connections.
+func (r *registry) shard(userID int64) *registryShard {
+	i := userID % registryShards
+	if i < 0 {
+		i = -i
+	}
+	return &r.shards[i]
+}
+
+// add registers the connection. The caller holds mu.
+func (s *registryShard) add(client *Client) {
+	sessions, ok := s.clients[client.userID]
+	if !ok {
+		sessions = make(map[*Client]bool)
+		s.clients[client.userID] = sessions
+	}
+	sessions[client] = true
+}
+
+// remove unregisters a single connection and closes its send channel. Other
+// connections of the same user stay registered. The caller holds mu.
+func (s *registryShard) remove(client *Client) {
+	sessions, ok := s.clients[client.userID]
+	if !ok || !sessions[client] {
+		return
+	}
+
+	delete(sessions, client)
+	close(client.send)
+	if len(sessions) == 0 {
+		delete(s.clients, client.userID)
+	}
+}
+
+// registered reports whether the connection is still registered. The caller
+// holds mu.
+func (s *registryShard) registered(client *Client) bool {
+	return s.clients[client.userID][client]
+}
+
+// send queues data for the client and drops the client if its buffer is
+// full. The caller holds mu.
+func (s *registryShard) send(client *Client, data []byte) {
+	if client.replaying {
+		if len(client.pending) >= maxPendingDeliveries {
+			s.remove(client)
+			return
+		}
+		client.pending = append(client.pending, data)
+		return
+	}
+
+	select {
+	case client.send <- data:
+	default:
+		s.remove(client)
+	}
+}
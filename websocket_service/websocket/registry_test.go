@@ -0,0 +1,59 @@
+package websocket
+
+import "testing"
+
+func TestDeliverReachesEveryConnectionOfUser(t *testing.T) {
+	hub, _ := newTestHub(t)
+	phone := connect(hub, 1, "phone")
+	laptop := connect(hub, 1, "laptop")
+	other := connect(hub, 2, "phone")
+
+	data, err := newEnvelope(EventTyping, "", TypingPayload{ChatID: 1, UserID: 3, Typing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.deliver(&Delivery{UserIDs: []int64{1}, Data: data})
+
+	for _, client := range []*Client{phone, laptop} {
+		onlyEvent(t, received(t, client), EventTyping)
+	}
+	if events := received(t, other); len(events) != 0 {
+		t.Errorf("user 2 got %+v, want nothing", events)
+	}
+}
+
+func TestUnregisterKeepsOtherConnections(t *testing.T) {
+	hub, _ := newTestHub(t)
+	phone := connect(hub, 1, "phone")
+	laptop := connect(hub, 1, "laptop")
+
+	hub.unregister(phone)
+
+	shard := hub.clients.shard(1)
+	shard.mu.Lock()
+	phoneRegistered, laptopRegistered := shard.registered(phone), shard.registered(laptop)
+	shard.mu.Unlock()
+	if phoneRegistered || !laptopRegistered {
+		t.Fatalf("registered phone %t laptop %t, want only the laptop", phoneRegistered, laptopRegistered)
+	}
+	if _, ok := <-phone.send; ok {
+		t.Error("send channel of the phone is still open")
+	}
+
+	data, err := newEnvelope(EventTyping, "", TypingPayload{ChatID: 1, UserID: 3, Typing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.deliver(&Delivery{UserIDs: []int64{1}, Data: data})
+	onlyEvent(t, received(t, laptop), EventTyping)
+
+	// Unregistering twice, e.g. after the hub dropped a slow connection,
+	// changes nothing
+	hub.unregister(phone)
+	shard.mu.Lock()
+	laptopRegistered = shard.registered(laptop)
+	shard.mu.Unlock()
+	if !laptopRegistered {
+		t.Error("laptop unregistered with the phone")
+	}
+}
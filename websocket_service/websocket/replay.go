@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+)
+
+const (
+	// Number of missed messages fetched from the chat service at once.
+	replayPageSize = 100
+
+	// Maximum number of live events buffered for a client while its
+	// missed messages are replayed.
+	maxPendingDeliveries = 1024
+)
+
+// replay writes the messages created in the client's chats after
+// lastMessageID straight to the connection, followed by a replay.completed
+// event. It runs before the pumps are started, so it is the only writer.
+// Live events published meanwhile are held back by the hub and flushed once
+// the replay is over.
+func (c *Client) replay(lastMessageID int64) error {
+	cursor := lastMessageID
+	defer func() {
+		c.hub.finishReplay(c, cursor)
+	}()
+
+	for {
+		result, err := c.hub.grpcClient.MessageService().GetAllSince(context.Background(), &chat_service.GetMessagesSinceParams{
+			UserId:        c.userID,
+			LastMessageId: cursor,
+			Limit:         replayPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, message := range result.Messages {
+			data, err := newEnvelope(createdEvent(message), "", parseMessage(message))
+			if err != nil {
+				return err
+			}
+			if err := c.write(data); err != nil {
+				return err
+			}
+			cursor = message.Id
+		}
+
+		if len(result.Messages) == 0 || int64(len(result.Messages)) >= result.Count {
+			break
+		}
+	}
+
+	data, err := newEnvelope(EventReplayCompleted, "", ReplayCompletedPayload{
+		LastMessageID: cursor,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.write(data)
+}
+
+func (c *Client) write(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// finishReplay switches the client to live delivery. Held back events for
+// messages the replay already sent are dropped.
+func (h *Hub) finishReplay(client *Client, lastMessageID int64) {
+	shard := h.clients.shard(client.userID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	pending := client.pending
+	client.pending = nil
+	client.replaying = false
+
+	if !shard.registered(client) {
+		return
+	}
+
+	for _, data := range pending {
+		if isReplayed(data, lastMessageID) {
+			continue
+		}
+		shard.send(client, data)
+	}
+}
+
+// isReplayed reports whether data is a message.created event of a message
+// not newer than lastMessageID.
+func isReplayed(data []byte, lastMessageID int64) bool {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil || (env.Type != EventMessageCreated && env.Type != EventThreadMessageCreated) {
+		return false
+	}
+
+	var message MessagePayload
+	if err := json.Unmarshal(env.Payload, &message); err != nil {
+		return false
+	}
+
+	return message.ID <= lastMessageID
+}
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	pbc "gitlab.com/telegram_clone/websocket_service/genproto/chat_service"
+)
+
+func createdEnvelope(t *testing.T, message *pbc.ChatMessage) []byte {
+	t.Helper()
+
+	data, err := newEnvelope(createdEvent(message), "", parseMessage(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestIsReplayed(t *testing.T) {
+	typing, err := newEnvelope(EventTyping, "", TypingPayload{ChatID: 1, UserID: 2, Typing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"sent by the replay", createdEnvelope(t, &pbc.ChatMessage{Id: 5, ChatId: 1}), true},
+		{"thread reply sent by the replay", createdEnvelope(t, &pbc.ChatMessage{Id: 4, ChatId: 1, ThreadRootId: 2}), true},
+		{"newer", createdEnvelope(t, &pbc.ChatMessage{Id: 6, ChatId: 1}), false},
+		{"not a message", typing, false},
+		{"broken", []byte("{"), false},
+	} {
+		if got := isReplayed(tt.data, 5); got != tt.want {
+			t.Errorf("%s: isReplayed = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFinishReplayFlushesPendingEvents(t *testing.T) {
+	hub, _ := newTestHub(t)
+	client := &Client{hub: hub, userID: 1, sessionID: "phone", send: make(chan []byte, 64), replaying: true}
+	hub.register(client)
+
+	typing, err := newEnvelope(EventTyping, "", TypingPayload{ChatID: 1, UserID: 2, Typing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Published while the missed messages are written
+	for _, data := range [][]byte{
+		createdEnvelope(t, &pbc.ChatMessage{Id: 5, ChatId: 1}),
+		typing,
+		createdEnvelope(t, &pbc.ChatMessage{Id: 6, ChatId: 1}),
+	} {
+		hub.deliver(&Delivery{UserIDs: []int64{1}, Data: data})
+	}
+	if events := received(t, client); len(events) != 0 {
+		t.Fatalf("got %+v during the replay, want nothing", events)
+	}
+
+	// The replay sent up to message 5
+	hub.finishReplay(client, 5)
+
+	events := received(t, client)
+	if len(events) != 2 || events[0].Type != EventTyping || events[1].Type != EventMessageCreated {
+		t.Fatalf("got %+v, want the typing event then message 6", events)
+	}
+	var message MessagePayload
+	if err := json.Unmarshal(events[1].Payload, &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.ID != 6 {
+		t.Errorf("got message %d, want 6", message.ID)
+	}
+
+	// Live from now on
+	hub.deliver(&Delivery{UserIDs: []int64{1}, Data: typing})
+	onlyEvent(t, received(t, client), EventTyping)
+}
+
+func TestReplayDropsClientWithTooManyPendingEvents(t *testing.T) {
+	hub, _ := newTestHub(t)
+	client := &Client{hub: hub, userID: 1, sessionID: "phone", send: make(chan []byte, 64), replaying: true}
+	hub.register(client)
+
+	typing, err := newEnvelope(EventTyping, "", TypingPayload{ChatID: 1, UserID: 2, Typing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= maxPendingDeliveries; i++ {
+		hub.deliver(&Delivery{UserIDs: []int64{1}, Data: typing})
+	}
+
+	shard := hub.clients.shard(1)
+	shard.mu.Lock()
+	registered := shard.registered(client)
+	shard.mu.Unlock()
+	if registered {
+		t.Fatal("client falling that far behind is still registered")
+	}
+
+	// Nothing is flushed to a dropped client
+	hub.finishReplay(client, 0)
+	if events := received(t, client); len(events) != 0 {
+		t.Errorf("got %d events, want none", len(events))
+	}
+}
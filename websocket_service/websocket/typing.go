@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// How long a typing indicator is shown unless it is refreshed.
+	typingTTL = 6 * time.Second
+
+	// Minimum time between two typing indicators of a connection in the
+	// same chat, clients refresh well within typingTTL.
+	typingInterval = 2 * time.Second
+)
+
+// typingLimiter rate limits the typing indicators of a connection. Commands
+// of different chats run on different workers, so it is safe for concurrent
+// use.
+type typingLimiter struct {
+	mu     sync.Mutex
+	sentAt map[int64]time.Time
+}
+
+// allow reports whether a typing indicator may be sent to the chat now and
+// records it if so.
+func (l *typingLimiter) allow(chatID int64, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.sentAt[chatID]) < typingInterval {
+		return false
+	}
+
+	if l.sentAt == nil {
+		l.sentAt = make(map[int64]time.Time)
+	}
+	l.sentAt[chatID] = now
+	return true
+}
+
+// reset lets the next indicator of the chat through, it is called when the
+// user stops typing.
+func (l *typingLimiter) reset(chatID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.sentAt, chatID)
+}
+
+// handleTyping fans a typing indicator out to the other members of the chat.
+// It is neither persisted nor sent through the chat service.
+func (h *Hub) handleTyping(client *Client, env *Envelope) (*AckPayload, *commandError) {
+	var cmd TypingCommand
+	if err := decodePayload(env, &cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.ChatID == 0 {
+		return nil, badRequest("chat_id is required")
+	}
+
+	if cmd.Stop {
+		client.typing.reset(cmd.ChatID)
+	} else if !client.typing.allow(cmd.ChatID, time.Now()) {
+		return nil, &commandError{code: ErrCodeRateLimited, message: "typing indicators are sent too often"}
+	}
+
+	members, ok, err := h.chatMembers(cmd.ChatID, client.userID)
+	if err != nil {
+		return nil, grpcError(err, "failed to get chat members")
+	}
+	if !ok {
+		return nil, notMember(cmd.ChatID)
+	}
+
+	payload := TypingPayload{
+		ChatID: cmd.ChatID,
+		UserID: client.userID,
+		Typing: !cmd.Stop,
+	}
+	if payload.Typing {
+		payload.ExpiresIn = int64(typingTTL / time.Second)
+	}
+
+	data, err := newEnvelope(EventTyping, "", payload)
+	if err != nil {
+		return nil, &commandError{code: ErrCodeInternal, message: err.Error()}
+	}
+	h.publish(members, data, client)
+
+	return &AckPayload{}, nil
+}
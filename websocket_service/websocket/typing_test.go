@@ -0,0 +1,76 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTypingLimiter(t *testing.T) {
+	var l typingLimiter
+	now := time.Now()
+
+	if !l.allow(1, now) {
+		t.Fatal("first indicator refused")
+	}
+	if l.allow(1, now.Add(typingInterval-time.Millisecond)) {
+		t.Error("indicator within the interval allowed")
+	}
+	// Chats are limited apart
+	if !l.allow(2, now.Add(time.Millisecond)) {
+		t.Error("indicator of another chat refused")
+	}
+	if !l.allow(1, now.Add(typingInterval)) {
+		t.Error("indicator after the interval refused")
+	}
+
+	// Stopping lets the next one through
+	l.reset(1)
+	if !l.allow(1, now.Add(typingInterval+time.Millisecond)) {
+		t.Error("indicator after a stop refused")
+	}
+}
+
+func TestHandleTyping(t *testing.T) {
+	hub, _ := newTestHub(t)
+	phone := connect(hub, 1, "phone")
+	member := connect(hub, 2, "phone")
+
+	send(hub, phone, `{"v":1,"type":"typing","id":"c-1","payload":{"chat_id":1}}`)
+	onlyEvent(t, received(t, phone), EventAck)
+
+	event := onlyEvent(t, received(t, member), EventTyping)
+	var payload TypingPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.UserID != 1 || !payload.Typing || payload.ExpiresIn != int64(typingTTL/time.Second) {
+		t.Errorf("typing = %+v, want user 1 typing for %s", payload, typingTTL)
+	}
+
+	// Sent again right away
+	send(hub, phone, `{"v":1,"type":"typing","id":"c-2","payload":{"chat_id":1}}`)
+	event = onlyEvent(t, received(t, phone), EventError)
+	var errPayload ErrorPayload
+	if err := json.Unmarshal(event.Payload, &errPayload); err != nil {
+		t.Fatal(err)
+	}
+	if errPayload.Code != ErrCodeRateLimited {
+		t.Errorf("error = %+v, want %s", errPayload, ErrCodeRateLimited)
+	}
+	if events := received(t, member); len(events) != 0 {
+		t.Errorf("member got %+v, want nothing", events)
+	}
+
+	// Stopping is never limited
+	send(hub, phone, `{"v":1,"type":"typing","id":"c-3","payload":{"chat_id":1,"stop":true}}`)
+	onlyEvent(t, received(t, phone), EventAck)
+	event = onlyEvent(t, received(t, member), EventTyping)
+	var stopped TypingPayload
+	if err := json.Unmarshal(event.Payload, &stopped); err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Typing || stopped.ExpiresIn != 0 {
+		t.Errorf("typing = %+v, want stopped", stopped)
+	}
+}
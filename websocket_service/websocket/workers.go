@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Number of jobs queued for a chat before the connections sending to it have
+// to wait.
+const chatQueueSize = 64
+
+// chatWorkers runs the commands of every chat on a goroutine of its own, so
+// a slow call to the chat service only holds back the chat it was made for.
+// Jobs with the same key run one after the other in the order they were
+// queued. A worker is started on the first job of a key and exits once its
+// queue is drained.
+type chatWorkers struct {
+	mu      sync.Mutex
+	workers map[int64]*chatWorker
+}
+
+type chatWorker struct {
+	queue chan func()
+
+	// Number of jobs handed to the worker and not handled yet, guarded by
+	// the mu of chatWorkers. The worker exits when it drops to zero.
+	pending int
+}
+
+func newChatWorkers() *chatWorkers {
+	return &chatWorkers{
+		workers: make(map[int64]*chatWorker),
+	}
+}
+
+// dispatch queues the job on the worker of the key, starting the worker if
+// the key has none. It blocks while the queue is full, which slows down the
+// reading connection only.
+func (p *chatWorkers) dispatch(key int64, job func()) {
+	p.mu.Lock()
+	w, ok := p.workers[key]
+	if !ok {
+		w = &chatWorker{queue: make(chan func(), chatQueueSize)}
+		p.workers[key] = w
+		go p.run(key, w)
+	}
+	w.pending++
+	p.mu.Unlock()
+
+	w.queue <- job
+}
+
+func (p *chatWorkers) run(key int64, w *chatWorker) {
+	for {
+		job := <-w.queue
+		job()
+
+		p.mu.Lock()
+		w.pending--
+		if w.pending == 0 {
+			delete(p.workers, key)
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+}
+
+// workerKey picks the worker of an inbound frame. Commands naming a chat run
+// on the worker of the chat, the others on the one of the user, see
+// userWorkerKey.
+func workerKey(in *inbound) int64 {
+	var target struct {
+		Payload struct {
+			ChatID int64 `json:"chat_id"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(in.data, &target); err == nil && target.Payload.ChatID > 0 {
+		return target.Payload.ChatID
+	}
+	return userWorkerKey(in.client.userID)
+}
+
+// userWorkerKey is the key of the worker running the jobs of a user that
+// belong to no chat. User ids are negated so they don't collide with chat
+// ids.
+func userWorkerKey(userID int64) int64 {
+	return -userID
+}
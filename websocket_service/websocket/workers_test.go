@@ -0,0 +1,54 @@
+package websocket
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestChatWorkersKeepOrderOfChat(t *testing.T) {
+	workers := newChatWorkers()
+
+	const jobs = 1000
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ran = make(map[int64][]int)
+	)
+
+	// Jobs of two chats interleaved, more than a queue holds
+	wg.Add(2 * jobs)
+	for i := 0; i < jobs; i++ {
+		for _, chatID := range []int64{1, 2} {
+			i, chatID := i, chatID
+			workers.dispatch(chatID, func() {
+				defer wg.Done()
+				mu.Lock()
+				ran[chatID] = append(ran[chatID], i)
+				mu.Unlock()
+			})
+		}
+	}
+	wg.Wait()
+
+	for _, chatID := range []int64{1, 2} {
+		if len(ran[chatID]) != jobs {
+			t.Fatalf("chat %d ran %d jobs, want %d", chatID, len(ran[chatID]), jobs)
+		}
+		for i, job := range ran[chatID] {
+			if job != i {
+				t.Fatalf("job %d of chat %d ran as number %d", job, chatID, i)
+			}
+		}
+	}
+}
+
+func TestWorkerKey(t *testing.T) {
+	client := &Client{userID: 8}
+
+	if key := workerKey(&inbound{client: client, data: []byte(`{"v":1,"type":"typing","payload":{"chat_id":3}}`)}); key != 3 {
+		t.Errorf("key of a chat command = %d, want 3", key)
+	}
+	if key := workerKey(&inbound{client: client, data: []byte(`{"v":1,"type":"message.update","payload":{"id":17}}`)}); key != userWorkerKey(8) {
+		t.Errorf("key of a command without chat = %d, want %d", key, userWorkerKey(8))
+	}
+}
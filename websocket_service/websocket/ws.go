@@ -23,9 +23,28 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "websocket/home.html")
 }
 
-func Run(cfg config.Config, grpcClient grpcPkg.GrpcClientI) {
-	hub := newHub(grpcClient)
-	go hub.run()
+// Run starts the websocket server. chatEvents is optional, without it the
+// member cache relies on its ttl and changes made outside of the websocket
+// service are not pushed to the clients. Presence is not tracked when
+// presence is nil.
+func Run(cfg config.Config, grpcClient grpcPkg.GrpcClientI, broker Broker, chatEvents ChatEvents, presence Presence) {
+	hub := newHub(grpcClient, broker, newMemberCache(grpcClient, cfg.MemberCacheTTL))
+	hub.presence = presence
+	if presence != nil {
+		go hub.runPresence()
+	}
+	if err := broker.Subscribe(hub.deliver); err != nil {
+		log.Fatal("failed to subscribe to broker: ", err)
+	}
+	defer broker.Close()
+
+	if chatEvents != nil {
+		if err := chatEvents.Subscribe(hub.handleChatEvent); err != nil {
+			log.Fatal("failed to subscribe to chat events: ", err)
+		}
+		defer chatEvents.Close()
+		hub.relayChatEvents = true
+	}
 
 	http.HandleFunc("/", serveHome)
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {